@@ -2,20 +2,31 @@
 package bundle
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
 
+// ErrNoBundle is returned by Find when a file system holds no bundle.
+var ErrNoBundle = errors.New("no familyfile.familydata found")
+
 // Bundle represents the directory structure of a Reunion family file.
+//
+// All paths are slash-separated paths in FS, as used by io/fs.
 type Bundle struct {
-	Path       string
-	Signature  string // path to familyfile.signature
-	FamilyData string // path to familyfile.familydata
+	FS         fs.FS             // file system the bundle is read from
+	Root       string            // bundle directory in FS
+	Path       string            // bundle directory on disk, "" if FS is not a directory
+	Signature  string            // path to familyfile.signature
+	FamilyData string            // path to familyfile.familydata
 	Caches     map[string]string // cache name -> path
 	Members    []MemberDir
 	NoteFiles  []string // all .note file paths across all members
+	Thumbnails []string // large person/family thumbnail JPEGs
 }
 
 // MemberDir represents a .member directory.
@@ -26,36 +37,49 @@ type MemberDir struct {
 	NotesDir   string // path to .notes directory, if any
 	MediaDir   string // path to .media directory, if any
 	NoteFiles  []string
+	MediaFiles []string // files in the .media directory
+}
+
+// OpenBundle validates and inventories a Reunion bundle directory on disk.
+func OpenBundle(dir string) (*Bundle, error) {
+	b, err := OpenBundleFS(os.DirFS(dir), ".")
+	if err != nil {
+		return nil, err
+	}
+	b.Path = dir
+	return b, nil
 }
 
-// OpenBundle validates and inventories a Reunion bundle directory.
-func OpenBundle(path string) (*Bundle, error) {
-	info, err := os.Stat(path)
+// OpenBundleFS validates and inventories the Reunion bundle at root in
+// fsys, such as a bundle inside a zip archive.
+func OpenBundleFS(fsys fs.FS, root string) (*Bundle, error) {
+	info, err := fs.Stat(fsys, root)
 	if err != nil {
 		return nil, fmt.Errorf("cannot access bundle: %w", err)
 	}
 	if !info.IsDir() {
-		return nil, fmt.Errorf("bundle path is not a directory: %s", path)
+		return nil, fmt.Errorf("bundle path is not a directory: %s", root)
 	}
 
 	b := &Bundle{
-		Path:   path,
+		FS:     fsys,
+		Root:   root,
 		Caches: make(map[string]string),
 	}
 
 	// Check for required files
-	sigPath := filepath.Join(path, "familyfile.signature")
-	if _, err := os.Stat(sigPath); err == nil {
+	sigPath := path.Join(root, "familyfile.signature")
+	if _, err := fs.Stat(fsys, sigPath); err == nil {
 		b.Signature = sigPath
 	}
 
-	fdPath := filepath.Join(path, "familyfile.familydata")
-	if _, err := os.Stat(fdPath); err == nil {
+	fdPath := path.Join(root, "familyfile.familydata")
+	if _, err := fs.Stat(fsys, fdPath); err == nil {
 		b.FamilyData = fdPath
 	}
 
 	// Discover cache files
-	entries, err := os.ReadDir(path)
+	entries, err := fs.ReadDir(fsys, root)
 	if err != nil {
 		return nil, fmt.Errorf("reading bundle directory: %w", err)
 	}
@@ -63,10 +87,10 @@ func OpenBundle(path string) (*Bundle, error) {
 	for _, entry := range entries {
 		name := entry.Name()
 		if strings.HasSuffix(name, ".cache") {
-			b.Caches[name] = filepath.Join(path, name)
+			b.Caches[name] = path.Join(root, name)
 		}
 		if entry.IsDir() && strings.HasSuffix(name, ".member") {
-			md, err := inventoryMember(filepath.Join(path, name), name)
+			md, err := inventoryMember(fsys, path.Join(root, name), name)
 			if err != nil {
 				continue
 			}
@@ -75,33 +99,91 @@ func OpenBundle(path string) (*Bundle, error) {
 		}
 	}
 
+	// Discover thumbnails: p{personID}-{hash}-{size}.jpg / f{familyID}-...
+	thumbDir := path.Join(root, "thumbnails", "thumbnails_large")
+	if thumbEntries, err := fs.ReadDir(fsys, thumbDir); err == nil {
+		for _, te := range thumbEntries {
+			if !te.IsDir() && strings.HasSuffix(te.Name(), ".jpg") {
+				b.Thumbnails = append(b.Thumbnails, path.Join(thumbDir, te.Name()))
+			}
+		}
+	}
+
 	return b, nil
 }
 
-func inventoryMember(dirPath, name string) (MemberDir, error) {
+// DiskPath returns the path on disk of a file in the bundle, or "" if the
+// bundle was not opened from a directory on disk.
+func (b *Bundle) DiskPath(name string) string {
+	if b.Path == "" {
+		return ""
+	}
+	// OpenBundle roots FS at the bundle directory.
+	return filepath.Join(b.Path, filepath.FromSlash(name))
+}
+
+// Find returns the directory in fsys that holds familyfile.familydata,
+// for a bundle whose location in fsys is not known: "." for a zip archive
+// of a bundle's contents, or the bundle directory for a zip archive of
+// the bundle. The shallowest match wins; macOS resource forks in
+// __MACOSX are skipped.
+func Find(fsys fs.FS) (string, error) {
+	root := ""
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == "__MACOSX" {
+			return fs.SkipDir
+		}
+		if !d.IsDir() && d.Name() == "familyfile.familydata" {
+			if dir := path.Dir(p); root == "" || depth(dir) < depth(root) {
+				root = dir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if root == "" {
+		return "", ErrNoBundle
+	}
+	return root, nil
+}
+
+// depth returns the number of directories in p, 0 for ".".
+func depth(p string) int {
+	if p == "." {
+		return 0
+	}
+	return strings.Count(p, "/") + 1
+}
+
+func inventoryMember(fsys fs.FS, dirPath, name string) (MemberDir, error) {
 	md := MemberDir{
 		Name: strings.TrimSuffix(name, ".member"),
 		Path: dirPath,
 	}
 
-	entries, err := os.ReadDir(dirPath)
+	entries, err := fs.ReadDir(fsys, dirPath)
 	if err != nil {
 		return md, err
 	}
 
 	for _, entry := range entries {
 		eName := entry.Name()
-		ePath := filepath.Join(dirPath, eName)
+		ePath := path.Join(dirPath, eName)
 		if strings.HasSuffix(eName, ".changes") {
 			md.Changes = ePath
 		}
 		if strings.HasSuffix(eName, ".notes") && entry.IsDir() {
 			md.NotesDir = ePath
-			noteEntries, err := os.ReadDir(ePath)
+			noteEntries, err := fs.ReadDir(fsys, ePath)
 			if err == nil {
 				for _, ne := range noteEntries {
 					if strings.HasSuffix(ne.Name(), ".note") {
-						notePath := filepath.Join(ePath, ne.Name())
+						notePath := path.Join(ePath, ne.Name())
 						md.NoteFiles = append(md.NoteFiles, notePath)
 					}
 				}
@@ -109,6 +191,14 @@ func inventoryMember(dirPath, name string) (MemberDir, error) {
 		}
 		if strings.HasSuffix(eName, ".media") && entry.IsDir() {
 			md.MediaDir = ePath
+			mediaEntries, err := fs.ReadDir(fsys, ePath)
+			if err == nil {
+				for _, me := range mediaEntries {
+					if !me.IsDir() && !strings.HasPrefix(me.Name(), ".") {
+						md.MediaFiles = append(md.MediaFiles, path.Join(ePath, me.Name()))
+					}
+				}
+			}
 		}
 	}
 
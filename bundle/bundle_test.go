@@ -0,0 +1,73 @@
+package bundle
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want string
+	}{
+		{"flat", fstest.MapFS{
+			"familyfile.familydata": {},
+			"familyfile.signature":  {},
+		}, "."},
+		{"directory", fstest.MapFS{
+			"__MACOSX/Family.familyfile14/familyfile.familydata":         {},
+			"Family.familyfile14/familyfile.familydata":                  {},
+			"Family.familyfile14/Old.familyfile14/familyfile.familydata": {},
+		}, "Family.familyfile14"},
+	}
+	for _, tt := range tests {
+		got, err := Find(tt.fsys)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: Find() = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	if _, err := Find(fstest.MapFS{"notes.txt": {}}); !errors.Is(err, ErrNoBundle) {
+		t.Errorf("Find(no bundle) error = %v, want ErrNoBundle", err)
+	}
+}
+
+func TestOpenBundleFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"F/familyfile.familydata":                  {},
+		"F/familyfile.signature":                   {},
+		"F/places.cache":                           {},
+		"F/Mac.member/Mac.notes/p1-1106-0.note":    {},
+		"F/Mac.member/Mac.media/p1-scan.png":       {},
+		"F/thumbnails/thumbnails_large/p1-a-1.jpg": {},
+	}
+	b, err := OpenBundleFS(fsys, "F")
+	if err != nil {
+		t.Fatalf("OpenBundleFS() error: %v", err)
+	}
+	if b.FamilyData != "F/familyfile.familydata" || b.Signature != "F/familyfile.signature" {
+		t.Errorf("FamilyData, Signature = %q, %q", b.FamilyData, b.Signature)
+	}
+	if b.Caches["places.cache"] != "F/places.cache" {
+		t.Errorf("Caches = %v", b.Caches)
+	}
+	if !slices.Equal(b.NoteFiles, []string{"F/Mac.member/Mac.notes/p1-1106-0.note"}) {
+		t.Errorf("NoteFiles = %q", b.NoteFiles)
+	}
+	if len(b.Members) != 1 || !slices.Equal(b.Members[0].MediaFiles, []string{"F/Mac.member/Mac.media/p1-scan.png"}) {
+		t.Errorf("Members = %+v", b.Members)
+	}
+	if !slices.Equal(b.Thumbnails, []string{"F/thumbnails/thumbnails_large/p1-a-1.jpg"}) {
+		t.Errorf("Thumbnails = %q", b.Thumbnails)
+	}
+	if b.Path != "" || b.DiskPath(b.FamilyData) != "" {
+		t.Errorf("bundle not on disk has Path %q", b.Path)
+	}
+
+	if _, err := OpenBundleFS(fsys, "F/familyfile.familydata"); err == nil {
+		t.Error("OpenBundleFS(file) succeeded")
+	}
+}
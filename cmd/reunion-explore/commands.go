@@ -1,14 +1,21 @@
 package main
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	reunion "github.com/kedoco/reunion-explore"
+	"github.com/kedoco/reunion-explore/diff"
 	"github.com/kedoco/reunion-explore/index"
 	"github.com/kedoco/reunion-explore/model"
+	"github.com/kedoco/reunion-explore/parser/familydata"
 )
 
 // printJSON marshals v as indented JSON to stdout.
@@ -76,6 +83,8 @@ func cmdStats(ff *model.FamilyFile, idx *Index, asJSON bool) error {
 			"sources":      len(ff.Sources),
 			"notes":        len(ff.Notes),
 			"media":        len(ff.MediaRefs),
+			"documents":    len(ff.Documents),
+			"reports":      len(ff.Reports),
 		})
 	}
 
@@ -92,31 +101,77 @@ func cmdStats(ff *model.FamilyFile, idx *Index, asJSON bool) error {
 	fmt.Printf("Sources:          %d\n", len(ff.Sources))
 	fmt.Printf("Notes:            %d\n", len(ff.Notes))
 	fmt.Printf("Media:            %d\n", len(ff.MediaRefs))
+	fmt.Printf("Documents:        %d\n", len(ff.Documents))
+	fmt.Printf("Reports:          %d\n", len(ff.Reports))
 	return nil
 }
 
 // --- persons ---
 
-func cmdPersons(ff *model.FamilyFile, idx *Index, surname string, asJSON bool) error {
+// personsOptions filters and orders the persons listing.
+type personsOptions struct {
+	Surname  string
+	Sort     string     // "id" (file order), "name", "birth" or "death"
+	BornFrom model.Date // zero for no lower bound
+	BornTo   model.Date // zero for no upper bound
+	Color    string     // color tag number, color or label; "" for any
+}
+
+func cmdPersons(ff *model.FamilyFile, idx *Index, opts personsOptions, asJSON bool) error {
+	ranged := !opts.BornFrom.IsZero() || !opts.BornTo.IsZero()
 	var filtered []model.Person
 	for _, p := range ff.Persons {
-		if surname != "" && !strings.EqualFold(p.Surname, surname) {
+		if opts.Surname != "" && !strings.EqualFold(p.Surname, opts.Surname) {
+			continue
+		}
+		if ranged && !idx.BirthDate(p.ID).Within(opts.BornFrom, opts.BornTo) {
+			continue
+		}
+		if opts.Color != "" && !idx.HasColor(p.ID, opts.Color) {
 			continue
 		}
 		filtered = append(filtered, p)
 	}
 
+	switch opts.Sort {
+	case "", "id":
+	case "name":
+		slices.SortStableFunc(filtered, func(a, b model.Person) int {
+			return strings.Compare(FormatName(&a), FormatName(&b))
+		})
+	case "birth":
+		slices.SortStableFunc(filtered, func(a, b model.Person) int {
+			return idx.BirthDate(a.ID).Compare(idx.BirthDate(b.ID))
+		})
+	case "death":
+		slices.SortStableFunc(filtered, func(a, b model.Person) int {
+			return idx.DeathDate(a.ID).Compare(idx.DeathDate(b.ID))
+		})
+	default:
+		return fmt.Errorf("unknown sort %q (want id, name, birth or death)", opts.Sort)
+	}
+
 	if asJSON {
 		return printJSON(filtered)
 	}
 
 	for i := range filtered {
 		p := &filtered[i]
-		fmt.Printf("#%-6d %s  %s\n", p.ID, p.Sex, FormatName(p))
+		fmt.Printf("#%-6d %s  %s%s\n", p.ID, p.Sex, FormatName(p), lifespan(idx, p.ID))
 	}
 	return nil
 }
 
+// lifespan formats "  (birth – death)" for a person, or "" if neither date
+// is known.
+func lifespan(idx *Index, id uint32) string {
+	birth, death := idx.BirthDate(id), idx.DeathDate(id)
+	if birth.IsZero() && death.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("  (%s – %s)", birth, death)
+}
+
 // --- person ---
 
 func cmdPerson(ff *model.FamilyFile, idx *Index, id uint32, asJSON bool) error {
@@ -140,6 +195,9 @@ func cmdPerson(ff *model.FamilyFile, idx *Index, id uint32, asJSON bool) error {
 				name = fmt.Sprintf("tag:0x%04X", evt.Tag)
 			}
 			line := fmt.Sprintf("  - %s", name)
+			if !evt.Date.IsZero() {
+				line += "  " + evt.Date.String()
+			}
 			for _, ref := range evt.PlaceRefs {
 				pname := idx.PlaceName(ref)
 				if pname != "" {
@@ -182,6 +240,23 @@ func cmdPerson(ff *model.FamilyFile, idx *Index, id uint32, asJSON bool) error {
 		}
 	}
 
+	var media []model.MediaRef
+	for _, m := range ff.MediaRefs {
+		if m.PersonID == p.ID {
+			media = append(media, m)
+		}
+	}
+	if len(media) > 0 {
+		fmt.Println("\nMedia:")
+		for _, m := range media {
+			line := fmt.Sprintf("  - %s", m.Caption)
+			if file := cmp.Or(m.Path, m.Filename); file != "" {
+				line += "  (" + file + ")"
+			}
+			fmt.Println(line)
+		}
+	}
+
 	return nil
 }
 
@@ -516,3 +591,249 @@ func countDescendants(idx *Index, id uint32, visited map[uint32]bool) {
 		countDescendants(idx, cid, visited)
 	}
 }
+
+// --- timeline ---
+
+func cmdTimeline(idx *Index, from, to model.Date, asJSON bool) error {
+	events := idx.EventsBetween(from, to)
+
+	if asJSON {
+		return printJSON(events)
+	}
+
+	for _, e := range events {
+		name := idx.SchemaName(e.SchemaID)
+		if name == "" {
+			name = fmt.Sprintf("tag:0x%04X", e.Tag)
+		}
+		who := ""
+		if e.PersonID > 0 {
+			who = fmt.Sprintf("#%d %s", e.PersonID, idx.PersonName(e.PersonID))
+		} else if f, ok := idx.Families[e.FamilyID]; ok {
+			who = fmt.Sprintf("family #%d %s & %s", f.ID, idx.PersonName(f.Partner1), idx.PersonName(f.Partner2))
+		}
+		fmt.Printf("%-24s %-20s %s\n", e.Date, name, who)
+	}
+	return nil
+}
+
+// --- recent ---
+
+func cmdRecent(idx *Index, since time.Time, limit int, asJSON bool) error {
+	recs := idx.Recent(since)
+	if limit > 0 && len(recs) > limit {
+		recs = recs[:limit]
+	}
+
+	if asJSON {
+		return printJSON(recs)
+	}
+
+	for _, r := range recs {
+		what := fmt.Sprintf("#%d %s", r.PersonID, idx.PersonName(r.PersonID))
+		if f, ok := idx.Families[r.FamilyID]; ok {
+			what = fmt.Sprintf("family #%d %s & %s", f.ID, idx.PersonName(f.Partner1), idx.PersonName(f.Partner2))
+		}
+		fmt.Printf("%s  %s\n", r.Modified.Local().Format("2006-01-02 15:04:05"), what)
+	}
+	return nil
+}
+
+// --- caches ---
+
+func cmdCaches(ff *model.FamilyFile, asJSON bool) error {
+	if asJSON {
+		return printJSON(ff.Caches)
+	}
+	for _, c := range ff.Caches {
+		status := c.Status
+		if c.Error != "" {
+			status += ": " + c.Error
+		}
+		fmt.Printf("%-24s %-8s %7d bytes %5d records  %s\n", c.Name, c.Magic, c.Size, c.Records, status)
+	}
+	return nil
+}
+
+func cmdBookmarks(ff *model.FamilyFile, idx *Index, asJSON bool) error {
+	if asJSON {
+		return printJSON(ff.Bookmarks)
+	}
+	for _, set := range ff.Bookmarks {
+		fmt.Printf("%s (%d persons)\n", set.Label, len(set.PersonIDs))
+		for _, id := range set.PersonIDs {
+			fmt.Printf("  #%-6d %s\n", id, idx.PersonName(id))
+		}
+	}
+	return nil
+}
+
+func cmdNoteboard(ff *model.FamilyFile, asJSON bool) error {
+	if asJSON {
+		return printJSON(ff.Noteboards)
+	}
+	for _, nb := range ff.Noteboards {
+		label := nb.Label
+		if label == "" {
+			label = "(unlabelled)"
+		}
+		fmt.Printf("%s (%d notes)\n", label, nb.NoteCount)
+		if nb.NoteCount > 0 {
+			fmt.Println("  notes are not decoded")
+		}
+	}
+	return nil
+}
+
+func cmdChanges(ff *model.FamilyFile, idx *Index, asJSON bool) error {
+	if len(ff.Members) > 0 {
+		fmt.Fprintln(os.Stderr, "Experimental: the .changes layout is inferred, not confirmed against a real sync log.")
+	}
+	if asJSON {
+		return printJSON(ff.Members)
+	}
+	if len(ff.Members) == 0 {
+		fmt.Println("No member directories")
+	}
+	for i, m := range ff.Members {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s (%d changes)\n", m.Name, len(m.Changes))
+		for _, c := range m.Changes {
+			when := "-"
+			if !c.Time.IsZero() {
+				when = c.Time.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("  %-19s  %-7s  %s\n", when, c.Operation, changeSubject(idx, c))
+		}
+	}
+	return nil
+}
+
+// changeSubject describes the record a change applies to, naming persons
+// and families from the change itself or else from the family file.
+func changeSubject(idx *Index, c model.ChangeRecord) string {
+	if c.Operation == model.ChangeUnknown {
+		return fmt.Sprintf("%d bytes at offset %d", c.Size, c.Offset)
+	}
+	what := fmt.Sprintf("%s #%d", familydata.RecordType(c.RecordType), c.RecordID)
+	switch {
+	case c.Person != nil:
+		what += " " + FormatName(c.Person)
+	case c.Family != nil:
+		what += fmt.Sprintf(" %s & %s", idx.PersonName(c.Family.Partner1), idx.PersonName(c.Family.Partner2))
+	case familydata.RecordType(c.RecordType) == familydata.RecordTypePerson:
+		what += " " + idx.PersonName(c.RecordID)
+	}
+	return fmt.Sprintf("%s (seq %d)", what, c.SeqNum)
+}
+
+func cmdConflicts(idx *Index, asJSON bool) error {
+	if asJSON {
+		if idx.Conflicts == nil {
+			return printJSON([]index.Conflict{})
+		}
+		return printJSON(idx.Conflicts)
+	}
+	if len(idx.Conflicts) == 0 {
+		fmt.Println("No conflicts")
+		return nil
+	}
+
+	const width = 32
+	for i, cf := range idx.Conflicts {
+		if i > 0 {
+			fmt.Println()
+		}
+		what := fmt.Sprintf("%s #%d", familydata.RecordType(cf.RecordType), cf.RecordID)
+		if familydata.RecordType(cf.RecordType) == familydata.RecordTypePerson {
+			what += " " + idx.PersonName(cf.RecordID)
+		}
+		fmt.Printf("%s (seq %d), changed by %d members:\n", what, cf.SeqNum, len(cf.Versions))
+		var labels []string
+		values := make(map[string][]string)
+		for j, v := range cf.Versions {
+			for _, f := range versionFields(idx, v) {
+				if _, ok := values[f[0]]; !ok {
+					labels = append(labels, f[0])
+					values[f[0]] = make([]string, len(cf.Versions))
+				}
+				values[f[0]][j] = f[1]
+			}
+		}
+		for _, label := range labels {
+			line := fmt.Sprintf("  %-10s", label)
+			for _, v := range values[label] {
+				if len(v) > width-2 {
+					v = v[:width-5] + "..."
+				}
+				line += fmt.Sprintf("  %-*s", width, v)
+			}
+			fmt.Println(strings.TrimRight(line, " "))
+		}
+	}
+	return nil
+}
+
+// versionFields returns label and value pairs describing one version of a
+// conflicting record.
+func versionFields(idx *Index, c model.ChangeRecord) [][2]string {
+	when := "-"
+	if !c.Time.IsZero() {
+		when = c.Time.Local().Format("2006-01-02 15:04:05")
+	}
+	fields := [][2]string{{"device", c.Device}, {"time", when}, {"operation", c.Operation.String()}}
+	switch {
+	case c.Person != nil:
+		p := c.Person
+		fields = append(fields,
+			[2]string{"name", FormatName(p)},
+			[2]string{"sex", p.Sex.String()},
+			[2]string{"events", strconv.Itoa(len(p.Events))},
+			[2]string{"sources", strconv.Itoa(len(p.SourceCitations))},
+			[2]string{"notes", strconv.Itoa(len(p.NoteRefs))},
+		)
+	case c.Family != nil:
+		f := c.Family
+		fields = append(fields,
+			[2]string{"partner 1", fmt.Sprintf("#%d %s", f.Partner1, idx.PersonName(f.Partner1))},
+			[2]string{"partner 2", fmt.Sprintf("#%d %s", f.Partner2, idx.PersonName(f.Partner2))},
+			[2]string{"children", strconv.Itoa(len(f.Children))},
+			[2]string{"events", strconv.Itoa(len(f.Events))},
+		)
+	}
+	return append(fields, [2]string{"size", fmt.Sprintf("%d bytes", c.Size)})
+}
+
+func cmdDiff(nameA, nameB string, a, b *Index, unified, asJSON bool) error {
+	res := diff.Compare(a, b)
+	switch {
+	case asJSON:
+		return printJSON(res)
+	case unified:
+		return diff.WriteUnified(os.Stdout, res, nameA, nameB)
+	}
+	return diff.WriteText(os.Stdout, res)
+}
+
+func cmdDetect(res *reunion.DetectResult, asJSON bool) error {
+	if asJSON {
+		return printJSON(res)
+	}
+	fmt.Printf("Path:       %s\n", res.Path)
+	if res.Version != 0 {
+		fmt.Printf("Version:    Reunion %d\n", res.Version)
+	} else {
+		fmt.Printf("Version:    unknown\n")
+	}
+	fmt.Printf("Confidence: %s\n", res.Confidence)
+	if res.Magic != "" {
+		fmt.Printf("Magic:      %q\n", res.Magic)
+	}
+	fmt.Println("Reasons:")
+	for _, r := range res.Reasons {
+		fmt.Printf("  - %s\n", r)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kedoco/reunion-explore/edit"
+	"github.com/kedoco/reunion-explore/model"
+)
+
+// --- set ---
+
+var setCmd = &cobra.Command{
+	Use:   "set <bundle> <person-id>",
+	Short: "Change a person's names or add events, saving the bundle",
+	Example: `  reunion-explore set Family.familyfile14 4 --given Jack
+  reunion-explore set Family.familyfile14 35 --event "Birth=about 1906"`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := parseIDArg(args, 1)
+		if err != nil {
+			return err
+		}
+		e, err := edit.Open(args[0])
+		if err != nil {
+			return err
+		}
+		p, err := e.Person(id)
+		if err != nil {
+			return err
+		}
+
+		given, surname := p.GivenName, p.Surname
+		if cmd.Flags().Changed("given") {
+			given, _ = cmd.Flags().GetString("given")
+		}
+		if cmd.Flags().Changed("surname") {
+			surname, _ = cmd.Flags().GetString("surname")
+		}
+		if given != p.GivenName || surname != p.Surname {
+			if err := e.SetName(id, given, surname); err != nil {
+				return err
+			}
+		}
+
+		events, _ := cmd.Flags().GetStringArray("event")
+		for _, ev := range events {
+			schemaID, date, err := parseEventFlag(e, ev)
+			if err != nil {
+				return err
+			}
+			if err := e.AddEvent(id, schemaID, date); err != nil {
+				return err
+			}
+		}
+
+		if err := e.Save(); err != nil {
+			return err
+		}
+		p, err = e.Person(id)
+		if err != nil {
+			return err
+		}
+		if jsonFlag(cmd) {
+			return printJSON(p)
+		}
+		fmt.Printf("Saved person %d: %s %s\n", p.ID, p.GivenName, p.Surname)
+		return nil
+	},
+}
+
+func init() {
+	setCmd.Flags().String("given", "", "New given name")
+	setCmd.Flags().String("surname", "", "New surname")
+	setCmd.Flags().StringArray("event", nil, `Add an event, as TYPE=DATE (e.g. "Birth=29 May 1917"); TYPE is an event name, GEDCOM code or ID`)
+}
+
+// parseEventFlag parses a --event value of the form TYPE=DATE.
+func parseEventFlag(e *edit.Editor, s string) (uint16, model.Date, error) {
+	name, dateStr, ok := strings.Cut(s, "=")
+	if !ok {
+		return 0, model.Date{}, fmt.Errorf("--event %q: want TYPE=DATE", s)
+	}
+	name = strings.TrimSpace(name)
+	schemaID, found := e.LookupEvent(name)
+	if !found {
+		n, err := strconv.ParseUint(name, 10, 16)
+		if err != nil {
+			return 0, model.Date{}, fmt.Errorf("--event %q: unknown event type %q", s, name)
+		}
+		schemaID = uint16(n)
+	}
+	date, err := model.ParseDate(dateStr)
+	if err != nil {
+		return 0, model.Date{}, fmt.Errorf("--event %q: %w", s, err)
+	}
+	return schemaID, date, nil
+}
+
+// --- link ---
+
+var linkCmd = &cobra.Command{
+	Use:   "link",
+	Short: "Link persons into families, saving the bundle",
+}
+
+var linkChildCmd = &cobra.Command{
+	Use:   "child <bundle> <family-id> <person-id>",
+	Short: "Add a person to a family's children",
+	Args:  cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		familyID, err := parseIDArg(args, 1)
+		if err != nil {
+			return err
+		}
+		childID, err := parseIDArg(args, 2)
+		if err != nil {
+			return err
+		}
+		e, err := edit.Open(args[0])
+		if err != nil {
+			return err
+		}
+		if err := e.LinkChild(familyID, childID); err != nil {
+			return err
+		}
+		if err := e.Save(); err != nil {
+			return err
+		}
+		return printFamily(cmd, e, familyID)
+	},
+}
+
+func init() {
+	linkCmd.AddCommand(linkChildCmd)
+}
+
+// printFamily prints a saved family's partners and children.
+func printFamily(cmd *cobra.Command, e *edit.Editor, id uint32) error {
+	f, err := e.Family(id)
+	if err != nil {
+		return err
+	}
+	if jsonFlag(cmd) {
+		return printJSON(f)
+	}
+	fmt.Printf("Saved family %d: partners %d, %d; children %v\n", f.ID, f.Partner1, f.Partner2, f.Children)
+	return nil
+}
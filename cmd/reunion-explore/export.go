@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kedoco/reunion-explore/bundle"
+	"github.com/kedoco/reunion-explore/gedcom"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the family file to other formats",
+}
+
+var exportGEDCOMCmd = &cobra.Command{
+	Use:     "gedcom <bundle>",
+	Short:   "Export as GEDCOM 5.5.1, GEDCOM 7.0 or GEDZIP",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: loadBundleFromArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		version, _ := cmd.Flags().GetString("gedcom-version")
+		gedzip, _ := cmd.Flags().GetBool("gedzip")
+
+		if gedzip {
+			if output == "" {
+				return fmt.Errorf("--gedzip requires --output")
+			}
+			media, closeMedia, err := bundleMedia(args[0])
+			if err != nil {
+				return err
+			}
+			defer closeMedia()
+			return writeOutput(output, func(w io.Writer) error {
+				return gedcom.WriteGEDZIP(w, ff, media)
+			})
+		}
+
+		switch version {
+		case "5.5.1":
+			return writeOutput(output, func(w io.Writer) error {
+				return gedcom.Write551(w, ff)
+			})
+		case "7.0", "7":
+			media, closeMedia, err := bundleMedia(args[0])
+			if err != nil {
+				return err
+			}
+			defer closeMedia()
+			// Outside a GEDZIP, FILE paths point at the bundle's own files,
+			// relative to where the .ged is written. Media in a zip archive
+			// keep their GEDZIP paths.
+			dir := "."
+			if output != "" {
+				dir = filepath.Dir(output)
+			}
+			for i := range media {
+				if media[i].FS == nil {
+					media[i].Path = relativeMediaPath(dir, media[i].Source)
+				}
+			}
+			return writeOutput(output, func(w io.Writer) error {
+				return gedcom.Write7(w, ff, media)
+			})
+		default:
+			return fmt.Errorf("unsupported GEDCOM version %q (want 5.5.1 or 7.0)", version)
+		}
+	},
+}
+
+func init() {
+	exportGEDCOMCmd.Flags().StringP("output", "o", "", "Output file (default stdout)")
+	exportGEDCOMCmd.Flags().String("gedcom-version", "5.5.1", "GEDCOM version: 5.5.1 or 7.0")
+	exportGEDCOMCmd.Flags().Bool("gedzip", false, "Write a GEDZIP archive (GEDCOM 7.0 with media)")
+	exportCmd.AddCommand(exportGEDCOMCmd)
+}
+
+// bundleMedia inventories the thumbnails and media files of a bundle, or
+// of a bundle in a zip archive. The returned function closes the archive
+// once the media have been read.
+func bundleMedia(path string) ([]gedcom.Media, func() error, error) {
+	if !isZip(path) {
+		b, err := bundle.OpenBundle(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening bundle: %w", err)
+		}
+		return gedcom.CollectMedia(b), func() error { return nil }, nil
+	}
+	zr, root, err := openZip(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := bundle.OpenBundleFS(zr, root)
+	if err != nil {
+		zr.Close()
+		return nil, nil, fmt.Errorf("opening bundle: %w", err)
+	}
+	return gedcom.CollectMedia(b), zr.Close, nil
+}
+
+// relativeMediaPath returns src relative to dir in slash form, or the
+// absolute path if no relative path exists.
+func relativeMediaPath(dir, src string) string {
+	absDir, err1 := filepath.Abs(dir)
+	absSrc, err2 := filepath.Abs(src)
+	if err1 == nil && err2 == nil {
+		if rel, err := filepath.Rel(absDir, absSrc); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(absSrc)
+}
+
+// writeOutput runs write against the named file, or stdout if path is empty.
+func writeOutput(path string, write func(io.Writer) error) error {
+	if path == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
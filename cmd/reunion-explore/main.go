@@ -1,13 +1,17 @@
 package main
 
 import (
+	"archive/zip"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	reunion "github.com/kedoco/reunion-explore"
+	"github.com/kedoco/reunion-explore/bundle"
 	"github.com/kedoco/reunion-explore/model"
 	_ "github.com/kedoco/reunion-explore/parser" // register v14 parser
 )
@@ -47,7 +51,19 @@ func init() {
 	rootCmd.AddCommand(descendantsCmd)
 	rootCmd.AddCommand(summaryCmd)
 	rootCmd.AddCommand(treetopsCmd)
+	rootCmd.AddCommand(timelineCmd)
+	rootCmd.AddCommand(recentCmd)
 	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(setCmd)
+	rootCmd.AddCommand(linkCmd)
+	rootCmd.AddCommand(detectCmd)
+	rootCmd.AddCommand(cachesCmd)
+	rootCmd.AddCommand(bookmarksCmd)
+	rootCmd.AddCommand(noteboardCmd)
+	rootCmd.AddCommand(changesCmd)
+	rootCmd.AddCommand(conflictsCmd)
+	rootCmd.AddCommand(diffCmd)
 }
 
 func jsonFlag(cmd *cobra.Command) bool {
@@ -58,7 +74,7 @@ func jsonFlag(cmd *cobra.Command) bool {
 func loadBundleFromArgs(cmd *cobra.Command, args []string) error {
 	path := args[0]
 	var err error
-	ff, err = reunion.Open(path, nil)
+	ff, err = openFamilyFile(path)
 	if err != nil {
 		return fmt.Errorf("opening bundle: %w", err)
 	}
@@ -66,6 +82,50 @@ func loadBundleFromArgs(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// openFamilyFile opens the family file at path, which may also be a zip
+// archive of a bundle.
+func openFamilyFile(path string) (*model.FamilyFile, error) {
+	if !isZip(path) {
+		return reunion.Open(path, nil)
+	}
+	zr, root, err := openZip(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return reunion.OpenFS(zr, root, nil)
+}
+
+// isZip reports whether path names a zip archive.
+func isZip(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".zip")
+}
+
+// openZip opens a zip archive and returns it with the location of the
+// bundle inside it.
+func openZip(path string) (*zip.ReadCloser, string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, "", err
+	}
+	root, err := bundle.Find(zr)
+	if err != nil {
+		zr.Close()
+		return nil, "", fmt.Errorf("%s: %w", path, err)
+	}
+	return zr, root, nil
+}
+
+// dateFlag parses a date-valued flag; an unset flag yields the zero Date.
+func dateFlag(cmd *cobra.Command, name string) (model.Date, error) {
+	v, _ := cmd.Flags().GetString(name)
+	d, err := model.ParseDate(v)
+	if err != nil {
+		return model.Date{}, fmt.Errorf("--%s: %w", name, err)
+	}
+	return d, nil
+}
+
 func parseIDArg(args []string, pos int) (uint32, error) {
 	if pos >= len(args) {
 		return 0, fmt.Errorf("missing person ID argument")
@@ -109,13 +169,27 @@ var personsCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	PreRunE: loadBundleFromArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		surname, _ := cmd.Flags().GetString("surname")
-		return cmdPersons(ff, idx, surname, jsonFlag(cmd))
+		var opts personsOptions
+		var err error
+		opts.Surname, _ = cmd.Flags().GetString("surname")
+		opts.Sort, _ = cmd.Flags().GetString("sort")
+		opts.Color, _ = cmd.Flags().GetString("color")
+		if opts.BornFrom, err = dateFlag(cmd, "born-from"); err != nil {
+			return err
+		}
+		if opts.BornTo, err = dateFlag(cmd, "born-to"); err != nil {
+			return err
+		}
+		return cmdPersons(ff, idx, opts, jsonFlag(cmd))
 	},
 }
 
 func init() {
 	personsCmd.Flags().String("surname", "", "Filter by surname")
+	personsCmd.Flags().String("sort", "id", "Sort order: id, name, birth or death")
+	personsCmd.Flags().String("born-from", "", "Only persons born on or after this date (e.g. 1900, \"May 1917\", 1917-05-29)")
+	personsCmd.Flags().String("born-to", "", "Only persons born on or before this date")
+	personsCmd.Flags().String("color", "", "Only persons with this color tag (number, #rrggbb color or label)")
 }
 
 // --- person ---
@@ -255,3 +329,167 @@ var treetopsCmd = &cobra.Command{
 		return cmdTreetops(idx, id, jsonFlag(cmd))
 	},
 }
+
+// --- timeline ---
+
+var timelineCmd = &cobra.Command{
+	Use:     "timeline <bundle>",
+	Short:   "List dated events chronologically",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: loadBundleFromArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, err := dateFlag(cmd, "from")
+		if err != nil {
+			return err
+		}
+		to, err := dateFlag(cmd, "to")
+		if err != nil {
+			return err
+		}
+		return cmdTimeline(idx, from, to, jsonFlag(cmd))
+	},
+}
+
+func init() {
+	timelineCmd.Flags().String("from", "", "Only events on or after this date")
+	timelineCmd.Flags().String("to", "", "Only events on or before this date")
+}
+
+// --- recent ---
+
+var recentCmd = &cobra.Command{
+	Use:     "recent <bundle>",
+	Short:   "List the most recently modified persons and families",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: loadBundleFromArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		v, _ := cmd.Flags().GetString("since")
+		since, err := model.ParseTime(v)
+		if err != nil {
+			return fmt.Errorf("--since: %w", err)
+		}
+		limit, _ := cmd.Flags().GetInt("limit")
+		return cmdRecent(idx, since, limit, jsonFlag(cmd))
+	},
+}
+
+func init() {
+	recentCmd.Flags().String("since", "", "Only records modified at or after this time (e.g. 2024-02-20, 2024-02-20T19:29:17Z)")
+	recentCmd.Flags().IntP("limit", "n", 20, "Max records to list (0 for all)")
+}
+
+// --- caches ---
+
+var cachesCmd = &cobra.Command{
+	Use:   "caches <bundle>",
+	Short: "List cache files with their magic, size, records and parse status",
+	Args:  cobra.ExactArgs(1),
+	PreRunE: loadBundleFromArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmdCaches(ff, jsonFlag(cmd))
+	},
+}
+
+// --- bookmarks ---
+
+var bookmarksCmd = &cobra.Command{
+	Use:   "bookmarks <bundle>",
+	Short: "List bookmark sets and the persons they bookmark",
+	Args:  cobra.ExactArgs(1),
+	PreRunE: loadBundleFromArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmdBookmarks(ff, idx, jsonFlag(cmd))
+	},
+}
+
+// --- noteboard ---
+
+var noteboardCmd = &cobra.Command{
+	Use:   "noteboard <bundle>",
+	Short: "List noteboards and how many notes each holds",
+	Args:  cobra.ExactArgs(1),
+	PreRunE: loadBundleFromArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmdNoteboard(ff, jsonFlag(cmd))
+	},
+}
+
+// --- changes ---
+
+var changesCmd = &cobra.Command{
+	Use:     "changes <bundle>",
+	Short:   "Print the sync log of each member directory as an audit log (experimental)",
+	Long:    "Print the sync log of each member directory as an audit log.\n\nExperimental: the layout of .changes files and how operations are told apart are inferred from familydata records, not checked against a real sync log. Use -j to see the raw bytes of each change.",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: loadBundleFromArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmdChanges(ff, idx, jsonFlag(cmd))
+	},
+}
+
+// --- conflicts ---
+
+var conflictsCmd = &cobra.Command{
+	Use:     "conflicts <bundle>",
+	Short:   "List records that sync members changed concurrently, with their versions side by side (experimental)",
+	Long:    "List records that sync members changed concurrently, with their versions side by side.\n\nExperimental: conflicts are found from the decoded .changes sync logs, whose layout is inferred rather than confirmed (see the changes command).",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: loadBundleFromArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmdConflicts(idx, jsonFlag(cmd))
+	},
+}
+
+// --- diff ---
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <bundleA> <bundleB>",
+	Short: "Compare two bundles record by record",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := openFamilyFile(args[0])
+		if err != nil {
+			return fmt.Errorf("opening %s: %w", args[0], err)
+		}
+		b, err := openFamilyFile(args[1])
+		if err != nil {
+			return fmt.Errorf("opening %s: %w", args[1], err)
+		}
+		unified, _ := cmd.Flags().GetBool("unified")
+		return cmdDiff(args[0], args[1], BuildIndex(a), BuildIndex(b), unified, jsonFlag(cmd))
+	},
+}
+
+func init() {
+	diffCmd.Flags().BoolP("unified", "u", false, "Output in unified diff style")
+}
+
+// --- detect ---
+
+var detectCmd = &cobra.Command{
+	Use:   "detect <path>",
+	Short: "Identify the Reunion version of a bundle or file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := args[0]
+		if !isZip(path) {
+			res, err := reunion.Detect(path)
+			if err != nil {
+				return err
+			}
+			return cmdDetect(res, jsonFlag(cmd))
+		}
+		zr, root, err := openZip(path)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		res, err := reunion.DetectFS(zr, root)
+		if err != nil {
+			return err
+		}
+		res.Path = path
+		res.Reasons = append([]string{fmt.Sprintf("zip archive: bundle at %q", root)}, res.Reasons...)
+		return cmdDetect(res, jsonFlag(cmd))
+	},
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/spf13/cobra"
 
-	reunion "github.com/kedoco/reunion-explore"
 	"github.com/kedoco/reunion-explore/web"
 )
 
@@ -19,7 +18,7 @@ var serveCmd = &cobra.Command{
 		addr, _ := cmd.Flags().GetString("addr")
 
 		path := args[0]
-		familyFile, err := reunion.Open(path, nil)
+		familyFile, err := openFamilyFile(path)
 		if err != nil {
 			return fmt.Errorf("opening bundle: %w", err)
 		}
@@ -38,12 +37,15 @@ var serveCmd = &cobra.Command{
 			"notes", len(familyFile.Notes),
 		)
 
-		// Watch for bundle changes and reload automatically.
-		go func() {
-			if err := srv.Watch(path); err != nil {
-				logger.Error("file watcher stopped", "err", err)
-			}
-		}()
+		// Watch for bundle changes and reload automatically. Reunion
+		// does not write to zip archives, so those are not watched.
+		if !isZip(path) {
+			go func() {
+				if err := srv.Watch(path); err != nil {
+					logger.Error("file watcher stopped", "err", err)
+				}
+			}()
+		}
 
 		fmt.Fprintf(os.Stderr, "Reunion Explorer running at http://localhost%s\n", addr)
 		return srv.ListenAndServe(addr)
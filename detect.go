@@ -0,0 +1,253 @@
+package reunion
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Confidence is how sure Detect is of the version it reports.
+type Confidence int
+
+const (
+	// ConfidenceNone means no version could be determined.
+	ConfidenceNone Confidence = iota
+	// ConfidenceLow means only the name suggests a version; the content
+	// could not confirm it.
+	ConfidenceLow
+	// ConfidenceMedium means the content identifies the format, but not
+	// which version wrote it, or the name disagrees with the content.
+	ConfidenceMedium
+	// ConfidenceHigh means the content identifies the version and nothing
+	// contradicts it.
+	ConfidenceHigh
+)
+
+func (c Confidence) String() string {
+	switch c {
+	case ConfidenceLow:
+		return "low"
+	case ConfidenceMedium:
+		return "medium"
+	case ConfidenceHigh:
+		return "high"
+	default:
+		return "none"
+	}
+}
+
+// MarshalText encodes the confidence as its name.
+func (c Confidence) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// DetectResult describes what Detect found out about a path.
+type DetectResult struct {
+	Path       string     `json:"path"`
+	Version    Version    `json:"version,omitempty"` // 0 if undetermined
+	Confidence Confidence `json:"confidence"`
+	Magic      string     `json:"magic,omitempty"`
+	// Reasons lists the evidence, in the order it was gathered.
+	Reasons []string `json:"reasons"`
+}
+
+func (r *DetectResult) reason(format string, args ...any) {
+	r.Reasons = append(r.Reasons, fmt.Sprintf(format, args...))
+}
+
+// headerVersionOffset is where the bundle familydata header holds a
+// uint16 that appears to be the major version of the Reunion that wrote
+// the file (14 in Reunion 14 files).
+const headerVersionOffset = 0x0C
+
+// zipMagic starts a ZIP archive, such as a bundle zipped by Dropbox.
+const zipMagic = "PK\x03\x04"
+
+// Detect works out which Reunion version wrote the bundle directory or
+// single family file at path. It reads the magic and header of the family
+// data, notes the version named by a ".familyfileNN" extension, and asks
+// each registered VersionParser whether it can parse path. The content
+// outranks the extension, so renamed bundles are detected. A version the
+// content names is reported even when no parser is registered for it.
+//
+// An error is returned only if path cannot be accessed; a path that is not
+// a family file gives a result with Version 0 and ConfidenceNone.
+func Detect(path string) (*DetectResult, error) {
+	dir, root := splitPath(path)
+	res, err := DetectFS(os.DirFS(dir), root)
+	if err != nil {
+		return nil, err
+	}
+	res.Path = path
+	return res, nil
+}
+
+// DetectFS is Detect for the bundle directory or single family file at
+// root in fsys.
+func DetectFS(fsys fs.FS, root string) (*DetectResult, error) {
+	info, err := fs.Stat(fsys, root)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotABundle, err)
+	}
+	res := &DetectResult{Path: root}
+
+	dataPath := root
+	if info.IsDir() {
+		dataPath = path.Join(root, "familyfile.familydata")
+		res.reason("directory: reading familyfile.familydata")
+	} else {
+		res.reason("single file")
+	}
+
+	extVersion := extensionVersion(root)
+	if extVersion != 0 {
+		res.reason("extension %q names Reunion %d", path.Ext(root), extVersion)
+	}
+
+	header, err := readHeader(fsys, dataPath)
+	var magicCandidates []Version
+	switch {
+	case err != nil:
+		res.reason("cannot read family data: %v", err)
+	case len(header) < len(MagicBundle):
+		res.reason("family data is only %d bytes", len(header))
+	default:
+		res.Magic = string(header[:len(MagicBundle)])
+		magicCandidates = magicVersions[res.Magic]
+		switch {
+		case magicCandidates != nil:
+			res.reason("magic %q is written by Reunion %s", res.Magic, versionList(magicCandidates))
+		case res.Magic[:len(zipMagic)] == zipMagic:
+			res.reason("file is a ZIP archive, not a family file")
+		default:
+			res.reason("magic %q is not a known Reunion magic", res.Magic)
+		}
+	}
+
+	var headerVersion Version
+	if res.Magic == MagicBundle && len(header) >= headerVersionOffset+2 {
+		headerVersion = Version(binary.LittleEndian.Uint16(header[headerVersionOffset:]))
+		if slices.Contains(magicCandidates, headerVersion) {
+			res.reason("header version field is %d", headerVersion)
+		} else {
+			res.reason("header version field %d is not a known version", headerVersion)
+			headerVersion = 0
+		}
+	}
+
+	// Versions the content and the registered parsers agree on, newest
+	// first.
+	var candidates []Version
+	for _, v := range magicCandidates {
+		vp, ok := registry[v]
+		if !ok {
+			continue
+		}
+		if ok, err := vp.CanParse(fsys, root); err == nil && ok {
+			candidates = append(candidates, v)
+		}
+	}
+	if len(magicCandidates) > 0 {
+		if len(candidates) == 0 {
+			res.reason("no registered parser accepts it")
+		} else {
+			res.reason("parsers for Reunion %s accept it", versionList(candidates))
+		}
+	}
+
+	// A version the content names but no parser reads is still reported,
+	// so that OpenFS fails with ErrUnsupportedVer.
+	named := headerVersion
+	if named == 0 && len(magicCandidates) == 1 {
+		named = magicCandidates[0]
+	}
+	if _, ok := registry[named]; named != 0 && !ok {
+		res.reason("Reunion %d is not supported", named)
+		candidates = []Version{named}
+	}
+
+	switch {
+	case len(candidates) == 0:
+		if _, ok := registry[extVersion]; ok && res.Magic == "" {
+			res.Version, res.Confidence = extVersion, ConfidenceLow
+			res.reason("going by the extension alone")
+		}
+		return res, nil
+	case slices.Contains(candidates, headerVersion):
+		res.Version = headerVersion
+	case slices.Contains(candidates, extVersion):
+		res.Version = extVersion
+	default:
+		res.Version = candidates[0]
+	}
+
+	res.Confidence = ConfidenceMedium
+	switch {
+	case extVersion != 0 && extVersion != res.Version:
+		res.reason("extension names Reunion %d, but the content is Reunion %d", extVersion, res.Version)
+	case res.Version == headerVersion, res.Version == extVersion, len(magicCandidates) == 1:
+		res.Confidence = ConfidenceHigh
+	default:
+		res.reason("content does not say which of Reunion %s wrote it; assuming %d", versionList(magicCandidates), res.Version)
+	}
+	return res, nil
+}
+
+// extensionVersion returns the version named by a ".familyfileNN"
+// extension, or 0.
+func extensionVersion(name string) Version {
+	numStr, ok := strings.CutPrefix(path.Ext(name), ".familyfile")
+	if !ok {
+		return 0
+	}
+	n, err := strconv.Atoi(numStr)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return Version(n)
+}
+
+// readHeader returns up to the first 80 bytes of the file name in fsys,
+// the fixed part of the familydata header.
+func readHeader(fsys fs.FS, name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	buf := make([]byte, 0x50)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// splitPath splits a path on disk into the directory to open with
+// os.DirFS and the name of the file or bundle in it.
+func splitPath(p string) (dir, name string) {
+	p = filepath.Clean(p)
+	if !fs.ValidPath(filepath.Base(p)) {
+		// "..", or a volume root.
+		if abs, err := filepath.Abs(p); err == nil {
+			p = abs
+		}
+	}
+	return filepath.Dir(p), filepath.Base(p)
+}
+
+// versionList formats versions as "14, 13, 12".
+func versionList(vs []Version) string {
+	s := make([]string, len(vs))
+	for i, v := range vs {
+		s[i] = strconv.Itoa(int(v))
+	}
+	return strings.Join(s, ", ")
+}
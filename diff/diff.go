@@ -0,0 +1,478 @@
+// Package diff compares two family files record by record.
+//
+// Persons, families, places, sources, notes and event definitions are
+// matched by ID. Each record is flattened into named fields, such as
+// "given_name", "events[Birth].date" or "children[#57]", and a matched
+// record is modified when any field differs. Sequence numbers and
+// timestamps are not compared, since Reunion changes them on every save.
+package diff
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kedoco/reunion-explore/index"
+	"github.com/kedoco/reunion-explore/model"
+	"github.com/kedoco/reunion-explore/parser/familydata"
+)
+
+// Status is what happened to a record between the two files.
+type Status uint8
+
+const (
+	Added Status = iota
+	Removed
+	Modified
+)
+
+var statusNames = [...]string{"added", "removed", "modified"}
+
+func (s Status) String() string {
+	if int(s) < len(statusNames) {
+		return statusNames[s]
+	}
+	return fmt.Sprintf("status(%d)", uint8(s))
+}
+
+// MarshalText encodes the status by name.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a status name.
+func (s *Status) UnmarshalText(b []byte) error {
+	for i, name := range statusNames {
+		if string(b) == name {
+			*s = Status(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown diff status %q", b)
+}
+
+// FieldChange is one field that differs. Old is empty for a field only in
+// the second file, and New for a field only in the first.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// RecordDiff is a record that was added, removed or modified. An added
+// record lists all its fields as new and a removed one all as old.
+type RecordDiff struct {
+	Type    string        `json:"type"` // "person", "family", "place", "source", "note" or "event definition"
+	ID      uint32        `json:"id"`
+	Name    string        `json:"name,omitempty"` // from the second file, or the first for a removed record
+	Status  Status        `json:"status"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// Result is the difference between two family files, ordered by record
+// type and then ID.
+type Result struct {
+	Records  []RecordDiff `json:"records"`
+	Added    int          `json:"added"`
+	Removed  int          `json:"removed"`
+	Modified int          `json:"modified"`
+}
+
+// field is one flattened field of a record. A field referring to other
+// records compares their IDs, kept in ref, rather than value, so that
+// renaming a person does not also change every family they belong to.
+type field struct {
+	name, value string
+	ref         string
+}
+
+func (f field) same(o field) bool {
+	if f.ref != "" || o.ref != "" {
+		return f.ref == o.ref
+	}
+	return f.value == o.value
+}
+
+// Compare returns the records that differ between a and b.
+func Compare(a, b *index.Index) *Result {
+	r := &Result{Records: []RecordDiff{}}
+	compareMap(r, familydata.RecordTypePerson, a, b, a.Persons, b.Persons, personFields, personEvents, personName)
+	compareMap(r, familydata.RecordTypeFamily, a, b, a.Families, b.Families, familyFields, familyEvents, familyName)
+	compareMap(r, familydata.RecordTypePlace, a, b, a.Places, b.Places, placeFields, nil,
+		func(_ *index.Index, p *model.Place) string { return p.Name })
+	compareMap(r, familydata.RecordTypeSource, a, b, a.Sources, b.Sources, sourceFields, nil,
+		func(_ *index.Index, s *model.Source) string { return s.Title })
+	compareMap(r, familydata.RecordTypeNote, a, b, a.Notes, b.Notes, noteFields, nil,
+		func(_ *index.Index, n *model.Note) string { return excerpt(n.DisplayText) })
+	compareMap(r, familydata.RecordTypeSchema, a, b, a.Schemas, b.Schemas, eventDefinitionFields, nil,
+		func(_ *index.Index, d *model.EventDefinition) string { return d.DisplayName })
+	return r
+}
+
+// compareMap diffs one record type, flattening each record with fields
+// against the index of the file it came from. For a record type with
+// events, events returns them so that both versions of a record can be
+// given matching event keys; it is nil for the others.
+func compareMap[T any](r *Result, typ familydata.RecordType, a, b *index.Index,
+	am, bm map[uint32]*T, fields func(*index.Index, *T, []string) []field,
+	events func(*T) []event, name func(*index.Index, *T) string) {
+	ids := slices.Sorted(maps.Keys(am))
+	for id := range bm {
+		if _, ok := am[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		ra, inA := am[id]
+		rb, inB := bm[id]
+		var ea, eb []event
+		if events != nil && inA {
+			ea = events(ra)
+		}
+		if events != nil && inB {
+			eb = events(rb)
+		}
+		ka, kb := eventKeys(a, ea, b, eb)
+
+		d := RecordDiff{Type: typ.String(), ID: id}
+		switch {
+		case !inA:
+			d.Status, d.Name = Added, name(b, rb)
+			d.Changes = diffFields(nil, fields(b, rb, kb))
+			r.Added++
+		case !inB:
+			d.Status, d.Name = Removed, name(a, ra)
+			d.Changes = diffFields(fields(a, ra, ka), nil)
+			r.Removed++
+		default:
+			d.Changes = diffFields(fields(a, ra, ka), fields(b, rb, kb))
+			if len(d.Changes) == 0 {
+				continue
+			}
+			d.Status, d.Name = Modified, name(b, rb)
+			r.Modified++
+		}
+		r.Records = append(r.Records, d)
+	}
+}
+
+// diffFields returns the fields that differ between a and b: those of a in
+// order, then those only in b.
+func diffFields(a, b []field) []FieldChange {
+	inB := make(map[string]field, len(b))
+	for _, f := range b {
+		inB[f.name] = f
+	}
+	inA := make(map[string]bool, len(a))
+	changes := []FieldChange{}
+	for _, f := range a {
+		inA[f.name] = true
+		if g := inB[f.name]; !f.same(g) {
+			changes = append(changes, FieldChange{Field: f.name, Old: f.value, New: g.value})
+		}
+	}
+	for _, f := range b {
+		if !inA[f.name] {
+			changes = append(changes, FieldChange{Field: f.name, New: f.value})
+		}
+	}
+	return changes
+}
+
+// appendField appends a field unless its value is empty, so that a field
+// present in only one file reads as added or removed.
+func appendField(fs []field, name, value string) []field {
+	if value == "" {
+		return fs
+	}
+	return append(fs, field{name: name, value: value})
+}
+
+func personName(_ *index.Index, p *model.Person) string {
+	return index.FormatName(p)
+}
+
+func familyName(idx *index.Index, f *model.Family) string {
+	return idx.PersonName(f.Partner1) + " & " + idx.PersonName(f.Partner2)
+}
+
+func personFields(idx *index.Index, p *model.Person, keys []string) []field {
+	var fs []field
+	fs = appendField(fs, "given_name", p.GivenName)
+	fs = appendField(fs, "surname", p.Surname)
+	fs = appendField(fs, "prefix_title", p.PrefixTitle)
+	fs = appendField(fs, "suffix_title", p.SuffixTitle)
+	fs = appendField(fs, "user_id", p.UserID)
+	fs = append(fs, field{name: "sex", value: p.Sex.String()})
+	fs = appendEvents(fs, idx, personEvents(p), keys)
+	fs = appendCitations(fs, idx, "sources", p.SourceCitations)
+	seen := make(map[uint32]int)
+	for _, n := range p.NoteRefs {
+		fs = append(fs, field{name: refKey(seen, "notes", n.NoteID), value: noteText(idx, n.NoteID), ref: fmt.Sprint(n.NoteID)})
+	}
+	return fs
+}
+
+func familyFields(idx *index.Index, f *model.Family, keys []string) []field {
+	var fs []field
+	fs = appendPerson(fs, idx, "partner1", f.Partner1)
+	fs = appendPerson(fs, idx, "partner2", f.Partner2)
+	for _, c := range f.Children {
+		fs = appendPerson(fs, idx, fmt.Sprintf("children[#%d]", c), c)
+	}
+	return appendEvents(fs, idx, familyEvents(f), keys)
+}
+
+func personEvents(p *model.Person) []event {
+	events := make([]event, len(p.Events))
+	for i, e := range p.Events {
+		events[i] = event{e.Tag, e.SchemaID, e.PlaceRefs, e.Date, e.Text, e.SourceCitations}
+	}
+	return events
+}
+
+func familyEvents(f *model.Family) []event {
+	events := make([]event, len(f.Events))
+	for i, e := range f.Events {
+		events[i] = event{e.Tag, e.SchemaID, e.PlaceRefs, e.Date, e.Text, e.SourceCitations}
+	}
+	return events
+}
+
+func placeFields(_ *index.Index, p *model.Place, _ []string) []field {
+	fs := appendField(nil, "name", p.Name)
+	if c := p.Coordinates; c != nil {
+		fs = append(fs, field{name: "coordinates", value: fmt.Sprintf("%.6f, %.6f", c.Latitude, c.Longitude)})
+	}
+	return fs
+}
+
+func sourceFields(idx *index.Index, s *model.Source, _ []string) []field {
+	fs := appendField(nil, "title", s.Title)
+	fs = appendField(fs, "template", s.Template)
+	for _, f := range s.Fields {
+		label := cmp.Or(f.Label, fmt.Sprintf("0x%04X", f.Tag))
+		fs = appendField(fs, "fields["+label+"]", f.Value)
+	}
+	if s.NoteID != 0 {
+		fs = append(fs, field{name: "note", value: noteText(idx, s.NoteID), ref: fmt.Sprint(s.NoteID)})
+	}
+	return fs
+}
+
+func noteFields(_ *index.Index, n *model.Note, _ []string) []field {
+	return appendField(nil, "text", n.DisplayText)
+}
+
+func eventDefinitionFields(_ *index.Index, d *model.EventDefinition, _ []string) []field {
+	var fs []field
+	fs = appendField(fs, "display_name", d.DisplayName)
+	fs = appendField(fs, "gedcom_code", d.GEDCOMCode)
+	fs = appendField(fs, "short_label", d.ShortLabel)
+	fs = appendField(fs, "abbreviation", d.Abbreviation)
+	fs = appendField(fs, "sentence_form", d.SentenceForm)
+	fs = appendField(fs, "preposition", d.Preposition)
+	return fs
+}
+
+// event holds the compared parts of a person or family event.
+type event struct {
+	tag, schemaID uint16
+	placeRefs     []int
+	date          model.Date
+	text          string
+	citations     []model.SourceCitation
+}
+
+// eventKeys names the events of one record in files a and b, giving an
+// event the same "events[<type>]" key in both. A type that occurs at most
+// once in each file is keyed by the type alone, so a changed date reads as
+// a modified event. Repeated types are matched on date and place and keyed
+// by them as well ("events[Residence 1920 Boston, MA]"), so inserting one
+// shows only that event as added; a repeated event whose date or place
+// changed shows as removed and added.
+func eventKeys(ia *index.Index, a []event, ib *index.Index, b []event) (ka, kb []string) {
+	ka, kb = make([]string, len(a)), make([]string, len(b))
+	type group struct{ a, b []int }
+	groups := make(map[string]*group)
+	var types []string
+	add := func(typ string) *group {
+		g, ok := groups[typ]
+		if !ok {
+			g = &group{}
+			groups[typ] = g
+			types = append(types, typ)
+		}
+		return g
+	}
+	for i, e := range a {
+		g := add(eventType(ia, e))
+		g.a = append(g.a, i)
+	}
+	for i, e := range b {
+		g := add(eventType(ib, e))
+		g.b = append(g.b, i)
+	}
+
+	// name returns an unused key in file a, file b or both, numbering
+	// events that share a date and place.
+	usedA, usedB := make(map[string]bool), make(map[string]bool)
+	name := func(typ, label string, inA, inB bool) string {
+		base := strings.TrimSpace(typ + " " + label)
+		key := "events[" + base + "]"
+		for n := 2; inA && usedA[key] || inB && usedB[key]; n++ {
+			key = fmt.Sprintf("events[%s %d]", base, n)
+		}
+		usedA[key] = usedA[key] || inA
+		usedB[key] = usedB[key] || inB
+		return key
+	}
+	for _, typ := range types {
+		g := groups[typ]
+		if len(g.a) <= 1 && len(g.b) <= 1 {
+			key := name(typ, "", true, true)
+			for _, i := range g.a {
+				ka[i] = key
+			}
+			for _, i := range g.b {
+				kb[i] = key
+			}
+			continue
+		}
+		matched := make([]bool, len(g.b))
+	nextA:
+		for _, i := range g.a {
+			for j, k := range g.b {
+				if !matched[j] && sameOccasion(a[i], b[k]) {
+					matched[j] = true
+					ka[i] = name(typ, eventLabel(ib, b[k]), true, true)
+					kb[k] = ka[i]
+					continue nextA
+				}
+			}
+			ka[i] = name(typ, eventLabel(ia, a[i]), true, false)
+		}
+		for j, i := range g.b {
+			if !matched[j] {
+				kb[i] = name(typ, eventLabel(ib, b[i]), false, true)
+			}
+		}
+	}
+	return ka, kb
+}
+
+// eventType names an event by its event definition, or by its tag when
+// the definition is missing.
+func eventType(idx *index.Index, e event) string {
+	return cmp.Or(idx.SchemaName(e.schemaID), fmt.Sprintf("tag 0x%04X", e.tag))
+}
+
+// sameOccasion reports whether two events of the same type have the same
+// date and places.
+func sameOccasion(e, o event) bool {
+	return e.date.String() == o.date.String() && slices.Equal(e.placeRefs, o.placeRefs)
+}
+
+// eventLabel describes an event by its date and place names.
+func eventLabel(idx *index.Index, e event) string {
+	var parts []string
+	if !e.date.IsZero() {
+		parts = append(parts, e.date.String())
+	}
+	if len(e.placeRefs) > 0 {
+		parts = append(parts, placeNames(idx, e.placeRefs))
+	}
+	return strings.Join(parts, " ")
+}
+
+func placeNames(idx *index.Index, ids []int) string {
+	var names []string
+	for _, id := range ids {
+		names = append(names, cmp.Or(idx.PlaceName(id), fmt.Sprintf("#%d", id)))
+	}
+	return strings.Join(names, "; ")
+}
+
+// appendEvents flattens events under the keys given by eventKeys. Each
+// event has a field of its own, so an event without a date or place still
+// shows as added.
+func appendEvents(fs []field, idx *index.Index, events []event, keys []string) []field {
+	for i, e := range events {
+		key := keys[i]
+		fs = append(fs, field{name: key, value: eventType(idx, e)})
+		if !e.date.IsZero() {
+			fs = append(fs, field{name: key + ".date", value: e.date.String()})
+		}
+		if len(e.placeRefs) > 0 {
+			ids := make([]string, len(e.placeRefs))
+			for i, id := range e.placeRefs {
+				ids[i] = strconv.Itoa(id)
+			}
+			fs = append(fs, field{name: key + ".place", value: placeNames(idx, e.placeRefs), ref: strings.Join(ids, ",")})
+		}
+		fs = appendField(fs, key+".text", e.text)
+		fs = appendCitations(fs, idx, key+".sources", e.citations)
+	}
+	return fs
+}
+
+// appendCitations flattens source citations as "<prefix>[#<source ID>]",
+// numbering further citations of the same source (see refKey).
+func appendCitations(fs []field, idx *index.Index, prefix string, cites []model.SourceCitation) []field {
+	seen := make(map[uint32]int)
+	for _, c := range cites {
+		v := fmt.Sprintf("#%d", c.SourceID)
+		if s, ok := idx.Sources[c.SourceID]; ok && s.Title != "" {
+			v = s.Title
+		}
+		if c.Detail != "" {
+			v += "; " + c.Detail
+		}
+		ref := fmt.Sprintf("%d;%s", c.SourceID, c.Detail)
+		fs = append(fs, field{name: refKey(seen, prefix, c.SourceID), value: v, ref: ref})
+	}
+	return fs
+}
+
+// refKey names a reference to record id as "<prefix>[#<id>]". A record
+// referred to more than once is numbered in order from its second
+// reference ("sources[#3 2]"), so that every field has its own name.
+func refKey(seen map[uint32]int, prefix string, id uint32) string {
+	seen[id]++
+	if n := seen[id]; n > 1 {
+		return fmt.Sprintf("%s[#%d %d]", prefix, id, n)
+	}
+	return fmt.Sprintf("%s[#%d]", prefix, id)
+}
+
+// appendPerson appends a reference to a person, shown as "#ID Name",
+// unless id is zero.
+func appendPerson(fs []field, idx *index.Index, name string, id uint32) []field {
+	if id == 0 {
+		return fs
+	}
+	return append(fs, field{name: name, value: fmt.Sprintf("#%d %s", id, idx.PersonName(id)), ref: fmt.Sprint(id)})
+}
+
+// noteText returns an excerpt of a note's text, or its ID if it is not in
+// the file.
+func noteText(idx *index.Index, id uint32) string {
+	if n, ok := idx.Notes[id]; ok && n.DisplayText != "" {
+		return excerpt(n.DisplayText)
+	}
+	return fmt.Sprintf("#%d", id)
+}
+
+// excerpt returns the first line of s, cut to 60 characters.
+func excerpt(s string) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	if r := []rune(s); len(r) > 60 {
+		return string(r[:59]) + "…"
+	}
+	return s
+}
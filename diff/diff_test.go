@@ -0,0 +1,185 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	reunion "github.com/kedoco/reunion-explore"
+	"github.com/kedoco/reunion-explore/index"
+	"github.com/kedoco/reunion-explore/model"
+	_ "github.com/kedoco/reunion-explore/parser" // register v14 parser
+)
+
+const sampleBundle = "../testdata/Sample Family 14.familyfile14"
+
+func openSample(t *testing.T) *model.FamilyFile {
+	t.Helper()
+	ff, err := reunion.Open(sampleBundle, nil)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	return ff
+}
+
+func findRecord(t *testing.T, r *Result, typ string, id uint32) RecordDiff {
+	t.Helper()
+	i := slices.IndexFunc(r.Records, func(d RecordDiff) bool { return d.Type == typ && d.ID == id })
+	if i < 0 {
+		t.Fatalf("no diff for %s #%d in %+v", typ, id, r.Records)
+	}
+	return r.Records[i]
+}
+
+func findChange(t *testing.T, d RecordDiff, field string) FieldChange {
+	t.Helper()
+	i := slices.IndexFunc(d.Changes, func(c FieldChange) bool { return c.Field == field })
+	if i < 0 {
+		t.Fatalf("%s #%d: no change to %s in %+v", d.Type, d.ID, field, d.Changes)
+	}
+	return d.Changes[i]
+}
+
+func TestCompare_Identical(t *testing.T) {
+	a := index.BuildIndex(openSample(t))
+	b := index.BuildIndex(openSample(t))
+	r := Compare(a, b)
+	if len(r.Records) != 0 {
+		t.Errorf("Records = %+v, want none", r.Records)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	a := openSample(t)
+	b := openSample(t)
+
+	i := slices.IndexFunc(b.Persons, func(p model.Person) bool { return p.ID == 4 })
+	p := &b.Persons[i]
+	p.GivenName = "Jack"
+	birth := slices.IndexFunc(p.Events, func(e model.PersonEvent) bool { return e.Date.String() == "29 May 1917" })
+	p.Events[birth].Date, _ = model.ParseDate("30 May 1917")
+
+	i = slices.IndexFunc(b.Families, func(f model.Family) bool { return f.ID == 2 })
+	b.Families[i].Children = append(b.Families[i].Children, 35)
+
+	b.Places = append(b.Places, model.Place{ID: 900, Name: "Hyannis Port, MA"})
+	removed := b.Notes[0].ID
+	b.Notes = b.Notes[1:]
+
+	r := Compare(index.BuildIndex(a), index.BuildIndex(b))
+	if r.Added != 1 || r.Removed != 1 || r.Modified != 2 {
+		t.Errorf("added, removed, modified = %d, %d, %d, want 1, 1, 2", r.Added, r.Removed, r.Modified)
+	}
+
+	person := findRecord(t, r, "person", 4)
+	if person.Status != Modified || person.Name != "Jack KENNEDY" {
+		t.Errorf("person = %s %q, want modified \"Jack KENNEDY\"", person.Status, person.Name)
+	}
+	if c := findChange(t, person, "given_name"); c.Old != "John Fitzgerald" || c.New != "Jack" {
+		t.Errorf("given_name change = %+v", c)
+	}
+	if c := findChange(t, person, "events[Birth].date"); c.Old != "29 May 1917" || c.New != "30 May 1917" {
+		t.Errorf("birth date change = %+v", c)
+	}
+
+	family := findRecord(t, r, "family", 2)
+	if len(family.Changes) != 1 {
+		t.Errorf("family changes = %+v, want only the new child", family.Changes)
+	}
+	if c := findChange(t, family, "children[#35]"); c.Old != "" || !strings.HasPrefix(c.New, "#35 ") {
+		t.Errorf("child change = %+v", c)
+	}
+
+	place := findRecord(t, r, "place", 900)
+	if place.Status != Added || findChange(t, place, "name").New != "Hyannis Port, MA" {
+		t.Errorf("place = %+v, want added Hyannis Port, MA", place)
+	}
+	if note := findRecord(t, r, "note", removed); note.Status != Removed {
+		t.Errorf("note status = %s, want removed", note.Status)
+	}
+}
+
+func TestCompare_RepeatedEvents(t *testing.T) {
+	a := openSample(t)
+	b := openSample(t)
+	i := slices.IndexFunc(a.EventDefinitions, func(d model.EventDefinition) bool { return d.DisplayName == "Residence" })
+	if i < 0 {
+		t.Fatal("no Residence event definition")
+	}
+	residence := func(date string) model.PersonEvent {
+		d, _ := model.ParseDate(date)
+		return model.PersonEvent{SchemaID: uint16(a.EventDefinitions[i].ID), Date: d}
+	}
+	pa := &a.Persons[slices.IndexFunc(a.Persons, func(p model.Person) bool { return p.ID == 4 })]
+	pb := &b.Persons[slices.IndexFunc(b.Persons, func(p model.Person) bool { return p.ID == 4 })]
+	pa.Events = append(pa.Events, residence("1920"), residence("1940"), residence("1950"))
+	pb.Events = append(pb.Events, residence("1920"), residence("1930"), residence("1940"), residence("1951"))
+
+	r := Compare(index.BuildIndex(a), index.BuildIndex(b))
+	var got []string
+	for _, c := range findRecord(t, r, "person", 4).Changes {
+		got = append(got, c.Field+": "+c.Old+" -> "+c.New)
+	}
+	want := []string{
+		"events[Residence 1950]: Residence -> ",
+		"events[Residence 1950].date: 1950 -> ",
+		"events[Residence 1930]:  -> Residence",
+		"events[Residence 1930].date:  -> 1930",
+		"events[Residence 1951]:  -> Residence",
+		"events[Residence 1951].date:  -> 1951",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("changes =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCompare_RepeatedCitations(t *testing.T) {
+	a := openSample(t)
+	b := openSample(t)
+	src := a.Sources[0].ID
+	for _, ff := range []*model.FamilyFile{a, b} {
+		p := &ff.Persons[slices.IndexFunc(ff.Persons, func(p model.Person) bool { return p.ID == 4 })]
+		p.SourceCitations = []model.SourceCitation{{SourceID: src, Detail: "p.1"}, {SourceID: src, Detail: "p.2"}}
+		p.NoteRefs = append(p.NoteRefs, model.NoteRef{NoteID: ff.Notes[0].ID}, model.NoteRef{NoteID: ff.Notes[0].ID})
+	}
+	if r := Compare(index.BuildIndex(a), index.BuildIndex(b)); len(r.Records) != 0 {
+		t.Errorf("Records = %+v, want none", r.Records)
+	}
+
+	pb := &b.Persons[slices.IndexFunc(b.Persons, func(p model.Person) bool { return p.ID == 4 })]
+	pb.SourceCitations[1].Detail = "p.3"
+	person := findRecord(t, Compare(index.BuildIndex(a), index.BuildIndex(b)), "person", 4)
+	if len(person.Changes) != 1 {
+		t.Errorf("changes = %+v, want only the second citation", person.Changes)
+	}
+	if c := findChange(t, person, fmt.Sprintf("sources[#%d 2]", src)); !strings.HasSuffix(c.Old, "; p.2") || !strings.HasSuffix(c.New, "; p.3") {
+		t.Errorf("citation change = %+v", c)
+	}
+}
+
+func TestWriteUnified(t *testing.T) {
+	r := &Result{Records: []RecordDiff{{
+		Type: "person", ID: 4, Name: "Jack KENNEDY", Status: Modified,
+		Changes: []FieldChange{
+			{Field: "given_name", Old: "John Fitzgerald", New: "Jack"},
+			{Field: "events[Birth].text", New: "line one\nline two"},
+		},
+	}}, Modified: 1}
+
+	var buf bytes.Buffer
+	if err := WriteUnified(&buf, r, "a.familyfile14", "b.familyfile14"); err != nil {
+		t.Fatal(err)
+	}
+	want := `--- a.familyfile14
++++ b.familyfile14
+@@ person #4 Jack KENNEDY (modified) @@
+-given_name: John Fitzgerald
++given_name: Jack
++events[Birth].text: line one\nline two
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteUnified() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package diff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteText writes the result as a summary: one line per record, with the
+// changed fields of modified records indented below it.
+func WriteText(w io.Writer, r *Result) error {
+	bw := bufio.NewWriter(w)
+	if len(r.Records) == 0 {
+		fmt.Fprintln(bw, "No differences")
+		return bw.Flush()
+	}
+	for _, d := range r.Records {
+		fmt.Fprintf(bw, "%-8s  %s\n", d.Status, d.title())
+		if d.Status != Modified {
+			continue
+		}
+		for _, c := range d.Changes {
+			switch {
+			case c.Old == "":
+				fmt.Fprintf(bw, "    %s: + %s\n", c.Field, oneLine(c.New))
+			case c.New == "":
+				fmt.Fprintf(bw, "    %s: - %s\n", c.Field, oneLine(c.Old))
+			default:
+				fmt.Fprintf(bw, "    %s: %s -> %s\n", c.Field, oneLine(c.Old), oneLine(c.New))
+			}
+		}
+	}
+	fmt.Fprintf(bw, "\n%d added, %d removed, %d modified\n", r.Added, r.Removed, r.Modified)
+	return bw.Flush()
+}
+
+// WriteUnified writes the result in the style of a unified diff, with a
+// hunk per record and a "-" or "+" line per changed field, so the
+// difference between two bundles can be read like a code review. nameA
+// and nameB label the two files.
+func WriteUnified(w io.Writer, r *Result, nameA, nameB string) error {
+	bw := bufio.NewWriter(w)
+	if len(r.Records) == 0 {
+		return nil
+	}
+	fmt.Fprintf(bw, "--- %s\n+++ %s\n", nameA, nameB)
+	for _, d := range r.Records {
+		fmt.Fprintf(bw, "@@ %s (%s) @@\n", d.title(), d.Status)
+		for _, c := range d.Changes {
+			if c.Old != "" {
+				fmt.Fprintf(bw, "-%s: %s\n", c.Field, oneLine(c.Old))
+			}
+			if c.New != "" {
+				fmt.Fprintf(bw, "+%s: %s\n", c.Field, oneLine(c.New))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// title describes the record as "<type> #<id> <name>".
+func (d RecordDiff) title() string {
+	s := fmt.Sprintf("%s #%d", d.Type, d.ID)
+	if d.Name != "" {
+		s += " " + d.Name
+	}
+	return s
+}
+
+// oneLine escapes line breaks so that a value fits on one output line.
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", `\r`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,338 @@
+// Package edit modifies the persons and families of a Reunion 14 bundle and
+// saves the result back to the bundle.
+//
+// Edits are applied to the familydata records in memory, re-encoding each
+// affected record with familydata.EncodePerson or EncodeFamily, so an
+// invalid edit fails when it is made and leaves the Editor unchanged. Save
+// writes every record back through familydata.Writer.
+package edit
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+
+	reunion "github.com/kedoco/reunion-explore"
+	"github.com/kedoco/reunion-explore/bundle"
+	"github.com/kedoco/reunion-explore/model"
+	"github.com/kedoco/reunion-explore/parser/familydata"
+)
+
+// ErrNotFound is returned for a person or family ID that is not in the
+// file.
+var ErrNotFound = errors.New("record not found")
+
+// Tag range of person life events; see the README's person field tags.
+const (
+	firstLifeEventTag uint16 = 0x03E8
+	firstFactTag      uint16 = 0x0BB8
+)
+
+// Editor holds a bundle's familydata records and applies edits to them.
+// Nothing is written until Save.
+type Editor struct {
+	path    string
+	prefix  []byte
+	records []*record // in file order
+
+	persons  map[uint32]*record
+	families map[uint32]*record
+	notes    map[uint32]*record
+	schemas  []model.EventDefinition
+
+	// now is the modification time for records changed by Save.
+	now func() time.Time
+}
+
+// record is one familydata record with its parsed model, if it is a
+// person or family.
+type record struct {
+	raw     familydata.RawRecord
+	person  *model.Person
+	family  *model.Family
+	changed bool
+	deleted bool
+}
+
+// Open reads the familydata of the bundle at bundlePath for editing.
+func Open(bundlePath string) (*Editor, error) {
+	e := &Editor{path: bundlePath, now: time.Now}
+	if err := e.load(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *Editor) load() error {
+	b, err := bundle.OpenBundle(e.path)
+	if err != nil {
+		return fmt.Errorf("opening bundle: %w", err)
+	}
+	if b.FamilyData == "" || b.Signature == "" {
+		return fmt.Errorf("%w: familyfile.familydata and familyfile.signature", reunion.ErrMissingFile)
+	}
+	data, err := fs.ReadFile(b.FS, b.FamilyData)
+	if err != nil {
+		return fmt.Errorf("reading familydata: %w", err)
+	}
+	if len(data) < headerLen || string(data[:len(reunion.MagicBundle)]) != reunion.MagicBundle {
+		return fmt.Errorf("%w: familydata is not in the Reunion 14 format", reunion.ErrBadMagic)
+	}
+	raws := familydata.ScanRecords(data)
+	if len(raws) == 0 {
+		return fmt.Errorf("%w: familydata has no records", reunion.ErrCorruptRecord)
+	}
+	// The last record's Data runs to the end of the file, where the others
+	// end with 4 bytes of the next record's header. Give it the same 4
+	// bytes so the encoders and Writer can treat it like the others; Save
+	// removes them again.
+	last := &raws[len(raws)-1]
+	last.Data = append(slices.Clip(last.Data), make([]byte, 4)...)
+
+	e.prefix = slices.Clone(familydata.FilePrefix(data, raws))
+	e.records = nil
+	e.persons = make(map[uint32]*record)
+	e.families = make(map[uint32]*record)
+	e.notes = make(map[uint32]*record)
+	e.schemas = nil
+
+	ec := reunion.NewErrorCollector(0)
+	for _, raw := range raws {
+		r := &record{raw: raw}
+		switch raw.Type {
+		case familydata.RecordTypePerson:
+			if r.person, err = familydata.ParsePerson(raw, ec); err != nil {
+				return fmt.Errorf("parsing person %d: %w", raw.ID, err)
+			}
+			e.persons[raw.ID] = r
+		case familydata.RecordTypeFamily:
+			if r.family, err = familydata.ParseFamily(raw, ec); err != nil {
+				return fmt.Errorf("parsing family %d: %w", raw.ID, err)
+			}
+			e.families[raw.ID] = r
+		case familydata.RecordTypeNote:
+			e.notes[raw.ID] = r
+		case familydata.RecordTypeSchema:
+			def, err := familydata.ParseSchema(raw, ec)
+			if err != nil {
+				return fmt.Errorf("parsing schema %d: %w", raw.ID, err)
+			}
+			e.schemas = append(e.schemas, *def)
+		}
+		e.records = append(e.records, r)
+	}
+	return nil
+}
+
+// Person returns a copy of the person with the given ID.
+func (e *Editor) Person(id uint32) (model.Person, error) {
+	r, err := e.person(id)
+	if err != nil {
+		return model.Person{}, err
+	}
+	return *r.person, nil
+}
+
+// Family returns a copy of the family with the given ID.
+func (e *Editor) Family(id uint32) (model.Family, error) {
+	r, err := e.family(id)
+	if err != nil {
+		return model.Family{}, err
+	}
+	return *r.family, nil
+}
+
+// EventDefinitions returns the file's event types, for AddEvent.
+func (e *Editor) EventDefinitions() []model.EventDefinition {
+	return slices.Clone(e.schemas)
+}
+
+// LookupEvent returns the ID of the event type whose display name or
+// GEDCOM code is name, ignoring case.
+func (e *Editor) LookupEvent(name string) (uint16, bool) {
+	for _, def := range e.schemas {
+		if strings.EqualFold(def.DisplayName, name) || strings.EqualFold(def.GEDCOMCode, name) {
+			return uint16(def.ID), true
+		}
+	}
+	return 0, false
+}
+
+func (e *Editor) person(id uint32) (*record, error) {
+	r, ok := e.persons[id]
+	if !ok || r.deleted {
+		return nil, fmt.Errorf("person %d: %w", id, ErrNotFound)
+	}
+	return r, nil
+}
+
+func (e *Editor) family(id uint32) (*record, error) {
+	r, ok := e.families[id]
+	if !ok || r.deleted {
+		return nil, fmt.Errorf("family %d: %w", id, ErrNotFound)
+	}
+	return r, nil
+}
+
+// updatePerson re-encodes r from p and, if that succeeds, makes p its
+// model. The model is parsed back from the new record so that new events
+// carry their encoded RawData.
+func (e *Editor) updatePerson(r *record, p *model.Person) error {
+	raw, err := familydata.EncodePerson(r.raw, p)
+	if err != nil {
+		return err
+	}
+	parsed, err := familydata.ParsePerson(raw, reunion.NewErrorCollector(0))
+	if err != nil {
+		return err
+	}
+	r.raw, r.person, r.changed = raw, parsed, true
+	return nil
+}
+
+// updateFamily is updatePerson for families.
+func (e *Editor) updateFamily(r *record, f *model.Family) error {
+	raw, err := familydata.EncodeFamily(r.raw, f)
+	if err != nil {
+		return err
+	}
+	parsed, err := familydata.ParseFamily(raw, reunion.NewErrorCollector(0))
+	if err != nil {
+		return err
+	}
+	r.raw, r.family, r.changed = raw, parsed, true
+	return nil
+}
+
+// SetName sets a person's given name and surname.
+func (e *Editor) SetName(personID uint32, given, surname string) error {
+	r, err := e.person(personID)
+	if err != nil {
+		return err
+	}
+	p := *r.person
+	p.GivenName, p.Surname = given, surname
+	return e.updatePerson(r, &p)
+}
+
+// AddEvent adds an event of the given type to a person. It takes the next
+// free tag among the person's life events (0x03E8 up to 0x0BB7). Dates
+// Reunion's encoding of which is not known, such as "before" dates, are
+// refused.
+func (e *Editor) AddEvent(personID uint32, schemaID uint16, date model.Date) error {
+	r, err := e.person(personID)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(e.schemas, func(def model.EventDefinition) bool { return def.ID == uint32(schemaID) }) {
+		return fmt.Errorf("event type %d: %w", schemaID, ErrNotFound)
+	}
+	tag := firstLifeEventTag
+	for _, ev := range r.person.Events {
+		if ev.Tag >= tag && ev.Tag < firstFactTag {
+			tag = ev.Tag + 1
+		}
+	}
+	if tag >= firstFactTag {
+		return fmt.Errorf("person %d has no free event tag", personID)
+	}
+	p := *r.person
+	p.Events = append(slices.Clip(p.Events), model.PersonEvent{Tag: tag, SchemaID: schemaID, Date: date})
+	return e.updatePerson(r, &p)
+}
+
+// LinkChild adds a person to a family's children, after any existing
+// children.
+func (e *Editor) LinkChild(familyID, childID uint32) error {
+	r, err := e.family(familyID)
+	if err != nil {
+		return err
+	}
+	if _, err := e.person(childID); err != nil {
+		return err
+	}
+	f := *r.family
+	switch {
+	case slices.Contains(f.Children, childID):
+		return fmt.Errorf("person %d is already a child of family %d", childID, familyID)
+	case f.Partner1 == childID || f.Partner2 == childID:
+		return fmt.Errorf("person %d is a partner in family %d", childID, familyID)
+	}
+	f.Children = append(slices.Clip(f.Children), childID)
+	return e.updateFamily(r, &f)
+}
+
+// DeletePerson removes a person. The person is removed from the children
+// and partners of every family; a family left with neither partners nor
+// children is removed too. Notes that only the person referred to are
+// removed with it.
+func (e *Editor) DeletePerson(personID uint32) error {
+	r, err := e.person(personID)
+	if err != nil {
+		return err
+	}
+
+	// Encode every affected family before changing anything, so that an
+	// error leaves the Editor as it was.
+	type update struct {
+		r      *record
+		raw    familydata.RawRecord
+		family *model.Family
+	}
+	var updates []update
+	for _, fr := range e.records {
+		if fr.family == nil || fr.deleted {
+			continue
+		}
+		f := *fr.family
+		if f.Partner1 != personID && f.Partner2 != personID && !slices.Contains(f.Children, personID) {
+			continue
+		}
+		if f.Partner1 == personID {
+			f.Partner1 = 0
+		}
+		if f.Partner2 == personID {
+			f.Partner2 = 0
+		}
+		f.Children = slices.DeleteFunc(slices.Clone(f.Children), func(id uint32) bool { return id == personID })
+		raw, err := familydata.EncodeFamily(fr.raw, &f)
+		if err != nil {
+			return err
+		}
+		parsed, err := familydata.ParseFamily(raw, reunion.NewErrorCollector(0))
+		if err != nil {
+			return err
+		}
+		updates = append(updates, update{fr, raw, parsed})
+	}
+
+	for _, u := range updates {
+		f := u.family
+		if f.Partner1 == 0 && f.Partner2 == 0 && len(f.Children) == 0 {
+			u.r.deleted = true
+			continue
+		}
+		u.r.raw, u.r.family, u.r.changed = u.raw, f, true
+	}
+	r.deleted = true
+
+	for _, ref := range r.person.NoteRefs {
+		if n, ok := e.notes[ref.NoteID]; ok && !e.noteReferenced(ref.NoteID) {
+			n.deleted = true
+		}
+	}
+	return nil
+}
+
+// noteReferenced reports whether a remaining person refers to a note.
+func (e *Editor) noteReferenced(noteID uint32) bool {
+	for _, r := range e.persons {
+		if !r.deleted && slices.ContainsFunc(r.person.NoteRefs, func(ref model.NoteRef) bool { return ref.NoteID == noteID }) {
+			return true
+		}
+	}
+	return false
+}
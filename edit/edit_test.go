@@ -0,0 +1,248 @@
+package edit
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	reunion "github.com/kedoco/reunion-explore"
+	"github.com/kedoco/reunion-explore/model"
+	_ "github.com/kedoco/reunion-explore/parser" // register v14 parser
+	"github.com/kedoco/reunion-explore/parser/familydata"
+)
+
+const sampleBundle = "../testdata/Sample Family 14.familyfile14"
+
+// copySample copies the sample bundle into a temporary directory and
+// returns the copy's path.
+func copySample(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), filepath.Base(sampleBundle))
+	if err := os.CopyFS(path, os.DirFS(sampleBundle)); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func openCopy(t *testing.T) (*Editor, string) {
+	t.Helper()
+	path := copySample(t)
+	e, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	e.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+	return e, path
+}
+
+// save saves e and reopens the bundle with reunion.Open.
+func save(t *testing.T, e *Editor, path string) *model.FamilyFile {
+	t.Helper()
+	if err := e.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	ff, err := reunion.Open(path, nil)
+	if err != nil {
+		t.Fatalf("reunion.Open() after Save error = %v", err)
+	}
+	return ff
+}
+
+func findPerson(ff *model.FamilyFile, id uint32) *model.Person {
+	for i := range ff.Persons {
+		if ff.Persons[i].ID == id {
+			return &ff.Persons[i]
+		}
+	}
+	return nil
+}
+
+func findFamily(ff *model.FamilyFile, id uint32) *model.Family {
+	for i := range ff.Families {
+		if ff.Families[i].ID == id {
+			return &ff.Families[i]
+		}
+	}
+	return nil
+}
+
+func TestSave_Unmodified(t *testing.T) {
+	e, path := openCopy(t)
+	orig, err := os.ReadFile(filepath.Join(sampleBundle, "familyfile.familydata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ff := save(t, e, path)
+
+	if ff.Signature != "1579321" {
+		t.Errorf("Signature = %q, want 1579321", ff.Signature)
+	}
+	data, err := os.ReadFile(filepath.Join(path, "familyfile.familydata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := binary.LittleEndian.Uint32(data[headerSignatureOffset:]); got != 1579321 {
+		t.Errorf("header signature = %d, want 1579321", got)
+	}
+	// Apart from the signature, the file is unchanged.
+	binary.LittleEndian.PutUint32(data[headerSignatureOffset:], 1579320)
+	if !slices.Equal(data, orig) {
+		t.Error("saving an unmodified file changed familydata")
+	}
+	if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".*")); len(matches) > 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
+
+func TestSave_Edits(t *testing.T) {
+	e, path := openCopy(t)
+	seq := e.persons[4].raw.SeqNum
+	birth, ok := e.LookupEvent("birt")
+	if !ok {
+		t.Fatal("LookupEvent(birt) found nothing")
+	}
+	if err := e.SetName(4, "Jack", "Kennedy"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddEvent(35, birth, model.Date{Year: 1906, Qualifier: model.DateAbout, Precision: model.PrecisionYear}); err != nil {
+		t.Fatal(err)
+	}
+	// How Reunion encodes "before" is not known, so the date is refused
+	// rather than written with guessed flags.
+	if err := e.AddEvent(35, birth, model.Date{Year: 1906, Qualifier: model.DateBefore, Precision: model.PrecisionYear}); err == nil {
+		t.Error("AddEvent() accepted a before date")
+	}
+	ff := save(t, e, path)
+
+	jfk := findPerson(ff, 4)
+	if jfk == nil || jfk.GivenName != "Jack" || jfk.Surname != "Kennedy" {
+		t.Errorf("person 4 = %+v, want Jack Kennedy", jfk)
+	}
+	p := findPerson(ff, 35)
+	if p == nil {
+		t.Fatal("person 35 missing")
+	}
+	last := p.Events[len(p.Events)-1]
+	if last.SchemaID != birth || last.Date.String() != "about 1906" {
+		t.Errorf("added event = schema %d %q, want schema %d about 1906", last.SchemaID, last.Date, birth)
+	}
+	if len(ff.Warnings) > 0 {
+		t.Errorf("warnings after Save: %v", ff.Warnings)
+	}
+
+	data, err := os.ReadFile(filepath.Join(path, "familyfile.familydata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range familydata.ScanRecords(data) {
+		if rec.Type == familydata.RecordTypePerson && rec.ID == 4 {
+			if rec.SeqNum != seq+1 {
+				t.Errorf("person 4 SeqNum = %d, want %d", rec.SeqNum, seq+1)
+			}
+			if ts := binary.LittleEndian.Uint32(rec.Data); int64(ts) != e.now().Unix() {
+				t.Errorf("person 4 timestamp = %d, want %d", ts, e.now().Unix())
+			}
+		}
+	}
+	// The header still points at the same 0x2010 record.
+	index := int(binary.LittleEndian.Uint32(data[headerIndexOffset:]))
+	if index+12 > len(data) || binary.LittleEndian.Uint16(data[index+2:]) != 0x2010 ||
+		binary.LittleEndian.Uint32(data[index+12:]) != 30 {
+		t.Errorf("header index offset 0x%X does not point at record 30", index)
+	}
+}
+
+func TestLinkChild(t *testing.T) {
+	e, path := openCopy(t)
+
+	// Family 6 has no children.
+	if err := e.LinkChild(6, 50); err != nil {
+		t.Fatalf("LinkChild() error = %v", err)
+	}
+	if err := e.LinkChild(6, 50); err == nil {
+		t.Error("LinkChild() linked the same child twice")
+	}
+	if err := e.LinkChild(9, 17); err == nil {
+		t.Error("LinkChild() made a partner their own child")
+	}
+	if err := e.LinkChild(99, 50); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LinkChild(99, ...) error = %v, want ErrNotFound", err)
+	}
+	ff := save(t, e, path)
+
+	if f6 := findFamily(ff, 6); f6 == nil || !slices.Equal(f6.Children, []uint32{50}) {
+		t.Errorf("family 6 = %+v, want child 50", f6)
+	}
+	if len(ff.Families) != 35 {
+		t.Errorf("len(Families) = %d, want 35", len(ff.Families))
+	}
+}
+
+func TestDeletePerson(t *testing.T) {
+	e, path := openCopy(t)
+	before, err := e.Person(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.DeletePerson(4); err != nil {
+		t.Fatalf("DeletePerson() error = %v", err)
+	}
+	if _, err := e.Person(4); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Person(4) after delete error = %v, want ErrNotFound", err)
+	}
+	// Family 4 has person 19 as its only member.
+	if err := e.DeletePerson(19); err != nil {
+		t.Fatal(err)
+	}
+	ff := save(t, e, path)
+
+	if findPerson(ff, 4) != nil || findPerson(ff, 19) != nil {
+		t.Error("deleted persons still present")
+	}
+	for _, f := range ff.Families {
+		if f.Partner1 == 4 || f.Partner2 == 4 || slices.Contains(f.Children, 4) {
+			t.Errorf("family %d still refers to person 4", f.ID)
+		}
+	}
+	if f := findFamily(ff, 8); f == nil || f.Partner1 != 0 || f.Partner2 != 17 {
+		t.Errorf("family 8 = %+v, want partner2 17 only", f)
+	}
+	// Reunion leaves out the field of a missing partner rather than
+	// storing ID 0.
+	data, err := os.ReadFile(filepath.Join(path, "familyfile.familydata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := 0
+	for _, rec := range familydata.ScanRecords(data) {
+		if rec.Type != familydata.RecordTypeFamily || rec.ID != 8 {
+			continue
+		}
+		found++
+		for _, field := range familydata.ParseTLVFields(rec.Data) {
+			if field.Tag == familydata.TagPartner1 {
+				t.Errorf("family 8 has a partner 1 field % x", field.Data)
+			}
+		}
+	}
+	if found != 1 {
+		t.Errorf("found %d family 8 records, want 1", found)
+	}
+	if findFamily(ff, 4) != nil {
+		t.Error("empty family 4 was kept")
+	}
+	for _, ref := range before.NoteRefs {
+		for _, n := range ff.Notes {
+			if n.ID == ref.NoteID {
+				t.Errorf("note %d of the deleted person was kept", n.ID)
+			}
+		}
+	}
+	if len(ff.Notes) != 28-len(before.NoteRefs) {
+		t.Errorf("len(Notes) = %d", len(ff.Notes))
+	}
+}
@@ -0,0 +1,122 @@
+package edit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kedoco/reunion-explore/parser/familydata"
+)
+
+// Fixed familydata header fields that Save maintains.
+const (
+	// headerIndexOffset holds the file offset of the sequence number of
+	// a 0x2010 record that appears to be Reunion's ID allocation table.
+	headerIndexOffset = 0x14
+	// headerSignatureOffset holds the same number as familyfile.signature.
+	headerSignatureOffset = 0x28
+	headerLen             = headerSignatureOffset + 4
+)
+
+// Save writes the edited file back to the bundle.
+//
+// The bundle is copied to a temporary directory beside it, the new
+// familydata and signature are written into the copy, and the copy is
+// swapped in for the bundle in one rename, so a reader sees either the old
+// or the new bundle and never a mix. Records changed since Open or the
+// last Save get a new timestamp and sequence number.
+//
+// The signature is incremented and written both to familyfile.signature
+// and to the familydata header, where Reunion keeps a copy. The .cache
+// files are copied unchanged, so until Reunion rebuilds them they still
+// describe the file as it was.
+func (e *Editor) Save() error {
+	sigPath := filepath.Join(e.path, "familyfile.signature")
+	sigData, err := os.ReadFile(sigPath)
+	if err != nil {
+		return fmt.Errorf("reading signature: %w", err)
+	}
+	sig, err := strconv.ParseUint(strings.TrimSpace(string(sigData)), 10, 32)
+	if err != nil {
+		return fmt.Errorf("parsing signature: %w", err)
+	}
+	sig++
+
+	data, err := e.encode(uint32(sig))
+	if err != nil {
+		return err
+	}
+
+	parent, name := filepath.Split(filepath.Clean(e.path))
+	tmp, err := os.MkdirTemp(parent, "."+name+"-*")
+	if err != nil {
+		return fmt.Errorf("creating temporary bundle: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	// CopyFS refuses to write over existing files, so copy into a fresh
+	// directory below tmp.
+	copyPath := filepath.Join(tmp, name)
+	if err := os.CopyFS(copyPath, os.DirFS(e.path)); err != nil {
+		return fmt.Errorf("copying bundle: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(copyPath, "familyfile.familydata"), data, 0o644); err != nil {
+		return fmt.Errorf("writing familydata: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(copyPath, "familyfile.signature"), []byte(strconv.FormatUint(sig, 10)), 0o644); err != nil {
+		return fmt.Errorf("writing signature: %w", err)
+	}
+	if err := swap(copyPath, e.path); err != nil {
+		return fmt.Errorf("replacing bundle: %w", err)
+	}
+	return e.load()
+}
+
+// encode returns the familydata file for the current records.
+func (e *Editor) encode(sig uint32) ([]byte, error) {
+	now := e.now()
+	prefix := bytes.Clone(e.prefix)
+	binary.LittleEndian.PutUint32(prefix[headerSignatureOffset:], sig)
+
+	// The header points at a record by file offset; find which one so it
+	// can be pointed at again once records have moved.
+	var index *familydata.RawRecord
+	indexOffset := int(binary.LittleEndian.Uint32(prefix[headerIndexOffset:]))
+
+	var records []familydata.RawRecord
+	for _, r := range e.records {
+		if r.deleted {
+			continue
+		}
+		if r.raw.Offset+4 == indexOffset {
+			raw := r.raw
+			index = &raw
+		}
+		if r.changed {
+			records = append(records, familydata.Touch(r.raw, now))
+		} else {
+			records = append(records, r.raw)
+		}
+	}
+	last := &records[len(records)-1]
+	last.Data = last.Data[:len(last.Data)-4]
+
+	var buf bytes.Buffer
+	if err := familydata.Write(&buf, prefix, records); err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()
+
+	if index != nil {
+		for _, rec := range familydata.ScanRecords(data) {
+			if rec.Type == index.Type && rec.ID == index.ID {
+				binary.LittleEndian.PutUint32(data[headerIndexOffset:], uint32(rec.Offset+4))
+				break
+			}
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,8 @@
+package edit
+
+import "golang.org/x/sys/unix"
+
+// swap exchanges the directories at src and dst in a single atomic rename.
+func swap(src, dst string) error {
+	return unix.Renameat2(unix.AT_FDCWD, src, unix.AT_FDCWD, dst, unix.RENAME_EXCHANGE)
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package edit
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// swap moves dst aside and src into its place, leaving the old dst in
+// src's directory. Without an exchanging rename there is a moment when
+// dst does not exist.
+func swap(src, dst string) error {
+	old := filepath.Join(filepath.Dir(src), "old")
+	if err := os.Rename(dst, old); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		os.Rename(old, dst)
+		return err
+	}
+	return nil
+}
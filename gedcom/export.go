@@ -0,0 +1,392 @@
+package gedcom
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kedoco/reunion-explore/index"
+	"github.com/kedoco/reunion-explore/model"
+)
+
+// Tag thresholds shared with the familydata person/family records.
+const (
+	firstEventTag = 0x03E8 // tags below are note references
+	firstFactTag  = 0x0BB8 // tags at or above are facts (attributes)
+	marriageTag   = 0x005F // family marriage field
+)
+
+// tagSet lists the standard event and attribute tags of a GEDCOM version.
+type tagSet struct {
+	indiEvents map[string]bool
+	indiAttrs  map[string]bool
+	famEvents  map[string]bool
+	aliases    map[string]string // Reunion code -> standard tag
+}
+
+// exporter holds the lookups shared by the GEDCOM writers.
+type exporter struct {
+	ff   *model.FamilyFile
+	idx  *index.Index
+	tags *tagSet
+	v7   bool // GEDCOM 7.0: SNOTE records and extension tags
+
+	noteXrefs   map[*model.Note]string // emitted notes -> xref
+	personNotes map[uint32][]string    // file-based notes by person ID
+	personMedia map[uint32][]string    // OBJE xrefs by person ID
+	familyMedia map[uint32][]string    // OBJE xrefs by family ID
+}
+
+func newExporter(ff *model.FamilyFile, tags *tagSet) *exporter {
+	e := &exporter{
+		ff:          ff,
+		idx:         index.BuildIndex(ff),
+		tags:        tags,
+		noteXrefs:   make(map[*model.Note]string),
+		personNotes: make(map[uint32][]string),
+		personMedia: make(map[uint32][]string),
+		familyMedia: make(map[uint32][]string),
+	}
+	// Inline notes are referenced by record ID; file-based notes have no ID
+	// and are attached to their person by filename instead.
+	fileNotes := 0
+	for i := range ff.Notes {
+		n := &ff.Notes[i]
+		if n.DisplayText == "" {
+			continue
+		}
+		if n.ID == 0 {
+			fileNotes++
+			xref := Xref("NF", uint32(fileNotes))
+			e.noteXrefs[n] = xref
+			if n.PersonID > 0 {
+				pid := uint32(n.PersonID)
+				e.personNotes[pid] = append(e.personNotes[pid], xref)
+			}
+			continue
+		}
+		if idxNote := e.idx.Notes[n.ID]; idxNote != n {
+			continue // duplicate ID; the index keeps the last one
+		}
+		e.noteXrefs[n] = Xref("N", n.ID)
+	}
+	return e
+}
+
+// noteTag is the tag used for pointers to shared note records.
+func (e *exporter) noteTag() string {
+	if e.v7 {
+		return "SNOTE"
+	}
+	return "NOTE"
+}
+
+// writeNotes writes the shared note records.
+func (e *exporter) writeNotes(lw *lineWriter) {
+	for i := range e.ff.Notes {
+		n := &e.ff.Notes[i]
+		if xref, ok := e.noteXrefs[n]; ok {
+			lw.record(xref, e.noteTag(), n.DisplayText)
+		}
+	}
+}
+
+// writeSources writes a SOUR record for each source.
+func (e *exporter) writeSources(lw *lineWriter) {
+	for i := range e.ff.Sources {
+		src := &e.ff.Sources[i]
+		lw.record(Xref("S", src.ID), "SOUR", "")
+		if src.Title != "" {
+			lw.line(1, "TITL", src.Title)
+		}
+	}
+}
+
+func (e *exporter) writeIndi(lw *lineWriter, p *model.Person) {
+	lw.record(Xref("I", p.ID), "INDI", "")
+
+	lw.line(1, "NAME", personalName(p))
+	if p.PrefixTitle != "" {
+		lw.line(2, "NPFX", p.PrefixTitle)
+	}
+	if p.GivenName != "" {
+		lw.line(2, "GIVN", p.GivenName)
+	}
+	if p.Surname != "" {
+		lw.line(2, "SURN", p.Surname)
+	}
+	if p.SuffixTitle != "" {
+		lw.line(2, "NSFX", p.SuffixTitle)
+	}
+	e.writeCitations(lw, 2, p.SourceCitations)
+
+	lw.line(1, "SEX", p.Sex.String())
+
+	for i := range p.Events {
+		evt := &p.Events[i]
+		if evt.Tag < firstEventTag {
+			continue // note references are written as NOTE pointers below
+		}
+		e.writeEvent(lw, personEvent(evt), evt.Tag >= firstFactTag, false)
+	}
+
+	if p.UserID != "" {
+		lw.line(1, "REFN", p.UserID)
+	}
+	for _, fid := range e.idx.ChildFamilies[p.ID] {
+		lw.pointer(1, "FAMC", Xref("F", fid))
+	}
+	for _, fid := range e.idx.PartnerFamilies[p.ID] {
+		lw.pointer(1, "FAMS", Xref("F", fid))
+	}
+	e.writeNoteRefs(lw, p)
+	for _, xref := range e.personMedia[p.ID] {
+		lw.pointer(1, "OBJE", xref)
+	}
+}
+
+func (e *exporter) writeFam(lw *lineWriter, f *model.Family) {
+	lw.record(Xref("F", f.ID), "FAM", "")
+
+	husb, wife := e.spouseRoles(f)
+	if husb != 0 {
+		lw.pointer(1, "HUSB", Xref("I", husb))
+	}
+	if wife != 0 {
+		lw.pointer(1, "WIFE", Xref("I", wife))
+	}
+	for _, cid := range f.Children {
+		if _, ok := e.idx.Persons[cid]; ok {
+			lw.pointer(1, "CHIL", Xref("I", cid))
+		}
+	}
+	for i := range f.Events {
+		evt := &f.Events[i]
+		if evt.Tag == marriageTag {
+			// The marriage field carries no schema ID; its presence alone
+			// records that the couple married.
+			ev := familyEvent(evt)
+			value := ""
+			if ev.empty() {
+				value = "Y"
+			}
+			e.writeEventBody(lw, "MARR", "", value, ev)
+			continue
+		}
+		if evt.Tag < firstEventTag {
+			continue
+		}
+		e.writeEvent(lw, familyEvent(evt), false, true)
+	}
+	for _, xref := range e.familyMedia[f.ID] {
+		lw.pointer(1, "OBJE", xref)
+	}
+}
+
+// spouseRoles assigns the family partners to HUSB and WIFE. Partner 1 is
+// the husband unless the partners' recorded sexes say otherwise. Partners
+// that don't resolve to a person are dropped.
+func (e *exporter) spouseRoles(f *model.Family) (husb, wife uint32) {
+	p1, ok1 := e.idx.Persons[f.Partner1]
+	p2, ok2 := e.idx.Persons[f.Partner2]
+	if ok1 {
+		husb = f.Partner1
+	}
+	if ok2 {
+		wife = f.Partner2
+	}
+	if (ok1 && p1.Sex == model.SexFemale) || (ok2 && p2.Sex == model.SexMale) {
+		husb, wife = wife, husb
+	}
+	return husb, wife
+}
+
+// event is the part of PersonEvent and FamilyEvent the writers need.
+type event struct {
+	SchemaID  uint16
+	Date      model.Date
+	Text      string
+	PlaceRefs []int
+	Citations []model.SourceCitation
+}
+
+func personEvent(evt *model.PersonEvent) event {
+	return event{evt.SchemaID, evt.Date, evt.Text, evt.PlaceRefs, evt.SourceCitations}
+}
+
+func familyEvent(evt *model.FamilyEvent) event {
+	return event{evt.SchemaID, evt.Date, evt.Text, evt.PlaceRefs, evt.SourceCitations}
+}
+
+// empty reports whether the event is an unfilled Reunion placeholder.
+func (ev event) empty() bool {
+	return ev.Date.IsZero() && ev.Text == "" && len(ev.PlaceRefs) == 0 && len(ev.Citations) == 0
+}
+
+// resolveTag returns the event's GEDCOM code (with aliases applied) and a
+// display name for use as TYPE.
+func (e *exporter) resolveTag(schemaID uint16) (code, typ string) {
+	if def := e.idx.Schemas[uint32(schemaID)]; def != nil {
+		code = strings.ToUpper(def.GEDCOMCode)
+		if alias, ok := e.tags.aliases[code]; ok {
+			code = alias
+		}
+		typ = def.DisplayName
+	}
+	if typ == "" {
+		typ = fmt.Sprintf("Event %d", schemaID)
+	}
+	return code, typ
+}
+
+// eventTag decides how an event is written: its tag, the TYPE (empty if
+// none) and the line value. Standard tags are used when the code names one;
+// otherwise GEDCOM 7.0 output uses an extension tag and 5.5.1 output falls
+// back to EVEN (or FACT for facts) with the display name as TYPE.
+func (e *exporter) eventTag(ev event, fact, family bool) (tag, typ, value string) {
+	code, typ := e.resolveTag(ev.SchemaID)
+	switch {
+	case family && e.tags.famEvents[code]:
+		return code, "", ""
+	case !family && e.tags.indiAttrs[code]:
+		return code, "", ev.Text
+	case !family && e.tags.indiEvents[code]:
+		return code, "", ""
+	case e.v7 && code != "" && !standardTags70[code]:
+		if fact {
+			return extensionTag(code), "", ev.Text
+		}
+		return extensionTag(code), "", ""
+	case fact:
+		return "FACT", typ, ev.Text
+	default:
+		return "EVEN", typ, ""
+	}
+}
+
+// writeEvent writes an event under the tag chosen by eventTag. Empty
+// placeholder events are skipped.
+func (e *exporter) writeEvent(lw *lineWriter, ev event, fact, family bool) {
+	if ev.empty() {
+		return
+	}
+	tag, typ, value := e.eventTag(ev, fact, family)
+	e.writeEventBody(lw, tag, typ, value, ev)
+}
+
+// writeEventBody writes an event or attribute structure at level 1.
+// A non-empty value goes on the tag line and the event text is then
+// considered consumed; otherwise the text is written as a NOTE.
+func (e *exporter) writeEventBody(lw *lineWriter, tag, typ, value string, ev event) {
+	lw.line(1, tag, value)
+	if typ != "" {
+		lw.line(2, "TYPE", typ)
+	}
+	if !ev.Date.IsZero() {
+		lw.line(2, "DATE", formatDate(ev.Date, e.v7))
+	}
+	if len(ev.PlaceRefs) > 0 {
+		if name := e.idx.PlaceName(ev.PlaceRefs[0]); name != "" {
+			lw.line(2, "PLAC", name)
+		}
+	}
+	if value == "" && ev.Text != "" {
+		lw.line(2, "NOTE", ev.Text)
+	}
+	e.writeCitations(lw, 2, ev.Citations)
+}
+
+// writeCitations writes SOUR pointers with PAGE detail at the given level.
+// Citations of unknown sources are dropped.
+func (e *exporter) writeCitations(lw *lineWriter, level int, cites []model.SourceCitation) {
+	for _, c := range cites {
+		if _, ok := e.idx.Sources[c.SourceID]; !ok {
+			continue
+		}
+		lw.pointer(level, "SOUR", Xref("S", c.SourceID))
+		if c.Detail != "" {
+			lw.line(level+1, "PAGE", c.Detail)
+		}
+	}
+}
+
+// writeNoteRefs writes note pointers for a person's inline and file notes.
+func (e *exporter) writeNoteRefs(lw *lineWriter, p *model.Person) {
+	seen := make(map[string]bool)
+	for _, ref := range p.NoteRefs {
+		n := e.idx.Notes[ref.NoteID]
+		xref, ok := e.noteXrefs[n]
+		if n == nil || !ok || seen[xref] {
+			continue
+		}
+		seen[xref] = true
+		lw.pointer(1, e.noteTag(), xref)
+	}
+	for _, xref := range e.personNotes[p.ID] {
+		lw.pointer(1, e.noteTag(), xref)
+	}
+}
+
+// personalName formats a NAME value as "Given /Surname/ Suffix".
+func personalName(p *model.Person) string {
+	var parts []string
+	if p.PrefixTitle != "" {
+		parts = append(parts, p.PrefixTitle)
+	}
+	if p.GivenName != "" {
+		parts = append(parts, p.GivenName)
+	}
+	parts = append(parts, "/"+p.Surname+"/")
+	if p.SuffixTitle != "" {
+		parts = append(parts, p.SuffixTitle)
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatDate renders a date in GEDCOM form, e.g. "29 MAY 1917",
+// "ABT 1850", "AFT JAN 1900", "BET 1850 AND 1855" or "FROM 1914 TO 1918".
+// Julian dates carry the calendar escape ("@#DJULIAN@" in 5.5.1, "JULIAN"
+// in 7.0). GEDCOM 7.0 has no dual years, so those are written as the
+// new-style year.
+func formatDate(d model.Date, v7 bool) string {
+	v := calendarDate(d, v7)
+	switch d.Qualifier {
+	case model.DateAbout:
+		return "ABT " + v
+	case model.DateAfter:
+		return "AFT " + v
+	case model.DateBefore:
+		return "BEF " + v
+	case model.DateCalculated:
+		return "CAL " + v
+	case model.DateEstimated:
+		return "EST " + v
+	case model.DateBetween:
+		if d.End != nil && !d.End.IsZero() {
+			return "BET " + v + " AND " + calendarDate(*d.End, v7)
+		}
+	case model.DateFromTo:
+		if d.End != nil && !d.End.IsZero() {
+			return "FROM " + v + " TO " + calendarDate(*d.End, v7)
+		}
+		return "FROM " + v
+	}
+	return v
+}
+
+// calendarDate renders the unqualified part of d, e.g. "7 OCT 1914" or
+// "@#DJULIAN@ 11 FEB 1731/32".
+func calendarDate(d model.Date, v7 bool) string {
+	plain := model.Date{Year: d.Year, Month: d.Month, Day: d.Day, Precision: d.Precision, DualYear: d.DualYear}
+	if v7 && plain.DualYear {
+		plain.Year++
+		plain.DualYear = false
+	}
+	v := strings.ToUpper(plain.String())
+	switch {
+	case d.Julian && v7:
+		return "JULIAN " + v
+	case d.Julian:
+		return "@#DJULIAN@ " + v
+	}
+	return v
+}
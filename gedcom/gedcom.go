@@ -0,0 +1,365 @@
+// Package gedcom exports a parsed FamilyFile as GEDCOM.
+//
+// Write551 produces lineage-linked GEDCOM 5.5.1 and Write7 produces
+// FamilySearch GEDCOM 7.0, which WriteGEDZIP packages together with media
+// files. ReadLines and ReadLines7 parse GEDCOM back into lines while
+// enforcing the respective line grammar, which is what the round-trip tests
+// use to validate exporter output.
+package gedcom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxLineLen is the maximum length of a GEDCOM 5.5.1 line in characters,
+// excluding the terminator.
+const MaxLineLen = 255
+
+// maxValueLen is the longest value written on a single line before the
+// writer splits it with CONC. It leaves room for the level, xref and tag.
+const maxValueLen = 200
+
+// Line is a single GEDCOM line.
+type Line struct {
+	Num   int    // 1-based line number in the input
+	Level int    // hierarchy level (0 for records)
+	Xref  string // cross-reference ID including the @ delimiters, if any
+	Tag   string
+	Value string // line value exactly as written (pointers keep their @s, @@ is not unescaped)
+}
+
+// IsPointer reports whether the line value is a cross-reference pointer.
+func (l Line) IsPointer() bool {
+	return dialect551.xref.MatchString(l.Value)
+}
+
+// SyntaxError describes a line that violates the GEDCOM line grammar.
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("gedcom line %d: %s", e.Line, e.Msg)
+}
+
+// escapePattern matches a GEDCOM 5.5.1 escape sequence such as @#DJULIAN@.
+var escapePattern = regexp.MustCompile(`@#[^@]*@`)
+
+// dialect captures the line-level differences between GEDCOM versions.
+type dialect struct {
+	maxLineLen int            // 0 means unlimited
+	conc       bool           // long values are split with CONC
+	xref       *regexp.Regexp // valid xref / pointer
+	tag        *regexp.Regexp // valid tag
+}
+
+var (
+	// GEDCOM 5.5.1: xref is @ + alphanum + up to 19 non-@ chars + @; every @
+	// in a line item is doubled; lines are limited to 255 characters.
+	dialect551 = &dialect{
+		maxLineLen: MaxLineLen,
+		conc:       true,
+		xref:       regexp.MustCompile(`^@[A-Za-z0-9_][^@]{0,19}@$`),
+		tag:        regexp.MustCompile(`^[A-Za-z0-9_]{1,31}$`),
+	}
+	// GEDCOM 7.0: xrefs and tags are upper case, extension tags start with
+	// an underscore, only a leading @ is doubled and CONC no longer exists.
+	dialect70 = &dialect{
+		xref: regexp.MustCompile(`^@[A-Z0-9_]+@$`),
+		tag:  regexp.MustCompile(`^(_[A-Z0-9_]+|[A-Z][A-Z0-9_]*)$`),
+	}
+)
+
+// ReadLines reads GEDCOM from r and returns its lines. It rejects any input
+// that does not conform to the GEDCOM 5.5.1 line grammar: levels without
+// leading zeros that increase by at most one, single-space delimiters,
+// well-formed xrefs and tags, doubled @ characters in line items, no
+// control characters, and lines of at most MaxLineLen characters. The
+// first record must be HEAD and the last TRLR.
+func ReadLines(r io.Reader) ([]Line, error) {
+	return dialect551.readLines(r)
+}
+
+// ReadLines7 is like ReadLines but enforces the GEDCOM 7.0 line grammar:
+// upper-case xrefs, standard or underscore-prefixed extension tags, no
+// line length limit, no CONC, and a doubled @ only at the start of a
+// non-pointer line value.
+func ReadLines7(r io.Reader) ([]Line, error) {
+	return dialect70.readLines(r)
+}
+
+func (d *dialect) readLines(r io.Reader) ([]Line, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	sc.Split(scanGEDCOMLines)
+
+	var lines []Line
+	prevLevel := -1
+	num := 0
+	for sc.Scan() {
+		num++
+		text := sc.Text()
+		if num == 1 {
+			text = strings.TrimPrefix(text, "\uFEFF")
+		}
+		l, err := d.parseLine(num, text)
+		if err != nil {
+			return nil, err
+		}
+		if prevLevel == -1 && l.Level != 0 {
+			return nil, &SyntaxError{num, "first line must be level 0"}
+		}
+		if l.Level > prevLevel+1 {
+			return nil, &SyntaxError{num, fmt.Sprintf("level %d follows level %d", l.Level, prevLevel)}
+		}
+		if l.Xref != "" && l.Level != 0 {
+			return nil, &SyntaxError{num, "xref on a non-record line"}
+		}
+		prevLevel = l.Level
+		lines = append(lines, l)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading gedcom: %w", err)
+	}
+	if len(lines) == 0 {
+		return nil, &SyntaxError{0, "empty input"}
+	}
+	if lines[0].Tag != "HEAD" {
+		return nil, &SyntaxError{1, "first record must be HEAD"}
+	}
+	last := lines[len(lines)-1]
+	if last.Level != 0 || last.Tag != "TRLR" {
+		return nil, &SyntaxError{last.Num, "last record must be TRLR"}
+	}
+	return lines, nil
+}
+
+// scanGEDCOMLines splits on any of the 5.5.1 terminators: CR, LF, CR LF or LF CR.
+func scanGEDCOMLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	for i, b := range data {
+		if b != '\r' && b != '\n' {
+			continue
+		}
+		if i+1 < len(data) {
+			if n := data[i+1]; (n == '\r' || n == '\n') && n != b {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil // need more data to see a possible two-byte terminator
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func (d *dialect) parseLine(num int, text string) (Line, error) {
+	l := Line{Num: num}
+	if !utf8.ValidString(text) {
+		return l, &SyntaxError{num, "invalid UTF-8"}
+	}
+	if n := utf8.RuneCountInString(text); d.maxLineLen > 0 && n > d.maxLineLen {
+		return l, &SyntaxError{num, fmt.Sprintf("line is %d characters, max %d", n, d.maxLineLen)}
+	}
+
+	rest := text
+	levelStr, rest, ok := strings.Cut(rest, " ")
+	if !ok {
+		return l, &SyntaxError{num, "missing delimiter after level"}
+	}
+	if levelStr == "" || len(levelStr) > 2 || (len(levelStr) > 1 && levelStr[0] == '0') {
+		return l, &SyntaxError{num, fmt.Sprintf("invalid level %q", levelStr)}
+	}
+	level, err := strconv.Atoi(levelStr)
+	if err != nil || level < 0 {
+		return l, &SyntaxError{num, fmt.Sprintf("invalid level %q", levelStr)}
+	}
+	l.Level = level
+
+	if strings.HasPrefix(rest, "@") {
+		xref, after, ok := strings.Cut(rest, " ")
+		if !ok || !d.xref.MatchString(xref) {
+			return l, &SyntaxError{num, fmt.Sprintf("invalid xref %q", xref)}
+		}
+		l.Xref = xref
+		rest = after
+	}
+
+	tag, value, hasValue := strings.Cut(rest, " ")
+	if !d.tag.MatchString(tag) || (!d.conc && tag == "CONC") {
+		return l, &SyntaxError{num, fmt.Sprintf("invalid tag %q", tag)}
+	}
+	l.Tag = tag
+	if hasValue {
+		if value == "" {
+			return l, &SyntaxError{num, "trailing delimiter without a line value"}
+		}
+		if err := d.checkValue(value); err != "" {
+			return l, &SyntaxError{num, err}
+		}
+		l.Value = value
+	}
+	return l, nil
+}
+
+// checkValue validates a line value and returns a message describing the
+// first problem, or "" if the value is well formed.
+func (d *dialect) checkValue(v string) string {
+	for _, r := range v {
+		if r < 0x20 && r != '\t' || r == 0x7F {
+			return fmt.Sprintf("control character U+%04X in line value", r)
+		}
+	}
+	if d.xref.MatchString(v) {
+		return ""
+	}
+	if !d.conc {
+		// 7.0: a line item starting with @ must start with @@.
+		if strings.HasPrefix(v, "@") && !strings.HasPrefix(v, "@@") {
+			return "unescaped leading @ in line value"
+		}
+		return ""
+	}
+	// Outside of escapes, every @ in a line item must be doubled.
+	rest := escapePattern.ReplaceAllString(v, "")
+	rest = strings.ReplaceAll(rest, "@@", "")
+	if strings.Contains(rest, "@") {
+		return "unescaped @ in line value"
+	}
+	return ""
+}
+
+// lineWriter emits GEDCOM lines, splitting multi-line values with CONT and
+// (for 5.5.1) long values with CONC. The first write error is retained and
+// later writes are skipped.
+type lineWriter struct {
+	d   *dialect
+	w   *bufio.Writer
+	err error
+}
+
+func newLineWriter(w io.Writer, d *dialect) *lineWriter {
+	return &lineWriter{d: d, w: bufio.NewWriter(w)}
+}
+
+// record writes a level-0 record line with an xref.
+func (lw *lineWriter) record(xref, tag, value string) {
+	lw.write(0, xref, tag, value)
+}
+
+// pointer writes a line whose value is a cross-reference pointer.
+func (lw *lineWriter) pointer(level int, tag, xref string) {
+	lw.raw(level, "", tag, xref)
+}
+
+// line writes a line with a text value, escaping @ and splitting the value
+// over CONT/CONC continuation lines as needed.
+func (lw *lineWriter) line(level int, tag, value string) {
+	lw.write(level, "", tag, value)
+}
+
+func (lw *lineWriter) write(level int, xref, tag, value string) {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	value = strings.ReplaceAll(value, "\r", "\n")
+	for i, para := range strings.Split(value, "\n") {
+		para = sanitize(para)
+		chunks := []string{para}
+		if lw.d.conc {
+			para = strings.ReplaceAll(para, "@", "@@")
+			chunks = splitValue(para, maxValueLen)
+		} else if strings.HasPrefix(para, "@") {
+			chunks = []string{"@" + para}
+		}
+		for j, chunk := range chunks {
+			switch {
+			case i == 0 && j == 0:
+				lw.raw(level, xref, tag, chunk)
+			case j == 0:
+				lw.raw(level+1, "", "CONT", chunk)
+			default:
+				lw.raw(level+1, "", "CONC", chunk)
+			}
+		}
+	}
+}
+
+func (lw *lineWriter) raw(level int, xref, tag, value string) {
+	if lw.err != nil {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(level))
+	if xref != "" {
+		b.WriteByte(' ')
+		b.WriteString(xref)
+	}
+	b.WriteByte(' ')
+	b.WriteString(tag)
+	if value != "" {
+		b.WriteByte(' ')
+		b.WriteString(value)
+	}
+	b.WriteByte('\n')
+	_, lw.err = lw.w.WriteString(b.String())
+}
+
+func (lw *lineWriter) flush() error {
+	if lw.err != nil {
+		return lw.err
+	}
+	return lw.w.Flush()
+}
+
+// sanitize replaces control characters (other than tab) with spaces.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' || r == 0x7F {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// splitValue breaks s into chunks of at most max bytes for CONC
+// continuation. Splits fall on rune boundaries, never next to a space
+// (readers may trim leading/trailing spaces) and never inside an @@ escape.
+// An empty s yields a single empty chunk.
+func splitValue(s string, max int) []string {
+	var chunks []string
+	for len(s) > max {
+		cut := max
+		for cut > 1 && !goodSplit(s, cut) {
+			cut--
+		}
+		if cut <= 1 {
+			// No clean split point; fall back to the nearest rune boundary.
+			cut = max
+			for cut > 1 && !utf8.RuneStart(s[cut]) {
+				cut--
+			}
+		}
+		chunks = append(chunks, s[:cut])
+		s = s[cut:]
+	}
+	return append(chunks, s)
+}
+
+func goodSplit(s string, i int) bool {
+	return utf8.RuneStart(s[i]) && s[i] != ' ' && s[i-1] != ' ' && s[i-1] != '@'
+}
+
+// Xref formats a cross-reference ID such as @I12@ from a prefix and record ID.
+func Xref(prefix string, id uint32) string {
+	return "@" + prefix + strconv.FormatUint(uint64(id), 10) + "@"
+}
@@ -0,0 +1,71 @@
+package gedcom
+
+import (
+	"io"
+
+	"github.com/kedoco/reunion-explore/model"
+)
+
+// tags551 lists the standard GEDCOM 5.5.1 event and attribute tags.
+var tags551 = &tagSet{
+	indiEvents: map[string]bool{
+		"BIRT": true, "CHR": true, "DEAT": true, "BURI": true, "CREM": true,
+		"ADOP": true, "BAPM": true, "BARM": true, "BASM": true, "BLES": true,
+		"CHRA": true, "CONF": true, "FCOM": true, "ORDN": true, "NATU": true,
+		"EMIG": true, "IMMI": true, "CENS": true, "PROB": true, "WILL": true,
+		"GRAD": true, "RETI": true,
+		"BAPL": true, "CONL": true, "ENDL": true, "SLGC": true, // LDS ordinances
+	},
+	indiAttrs: map[string]bool{
+		"CAST": true, "DSCR": true, "EDUC": true, "IDNO": true, "NATI": true,
+		"NCHI": true, "NMR": true, "OCCU": true, "PROP": true, "RELI": true,
+		"RESI": true, "SSN": true, "TITL": true,
+	},
+	famEvents: map[string]bool{
+		"ANUL": true, "CENS": true, "DIV": true, "DIVF": true, "ENGA": true,
+		"MARB": true, "MARC": true, "MARR": true, "MARL": true, "MARS": true,
+		"RESI": true, "SLGS": true,
+	},
+	// Reunion's GEDCOM codes that differ from the 5.5.1 spelling.
+	aliases: map[string]string{
+		"BLESS": "BLES",
+		"DESC":  "DSCR",
+	},
+}
+
+// Write551 writes ff to w as lineage-linked GEDCOM 5.5.1 in UTF-8.
+//
+// Persons become INDI records, families FAM, sources SOUR and notes NOTE.
+// Event tags come from each event's EventDefinition.GEDCOMCode; codes that
+// are not standard 5.5.1 tags are written as EVEN (or FACT for facts) with
+// the definition's display name as TYPE. Places are written as PLAC
+// substructures of the events that reference them, since 5.5.1 has no
+// top-level place record. Citation detail text is written as PAGE.
+func Write551(w io.Writer, ff *model.FamilyFile) error {
+	e := newExporter(ff, tags551)
+	lw := newLineWriter(w, dialect551)
+
+	lw.line(0, "HEAD", "")
+	lw.line(1, "SOUR", "REUNION_EXPLORE")
+	lw.line(2, "NAME", "reunion-explore")
+	lw.pointer(1, "SUBM", "@U1@")
+	lw.line(1, "GEDC", "")
+	lw.line(2, "VERS", "5.5.1")
+	lw.line(2, "FORM", "LINEAGE-LINKED")
+	lw.line(1, "CHAR", "UTF-8")
+
+	lw.record("@U1@", "SUBM", "")
+	lw.line(1, "NAME", "Unknown")
+
+	for i := range ff.Persons {
+		e.writeIndi(lw, &ff.Persons[i])
+	}
+	for i := range ff.Families {
+		e.writeFam(lw, &ff.Families[i])
+	}
+	e.writeSources(lw)
+	e.writeNotes(lw)
+
+	lw.line(0, "TRLR", "")
+	return lw.flush()
+}
@@ -0,0 +1,197 @@
+package gedcom
+
+import (
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/kedoco/reunion-explore/model"
+)
+
+// ExtensionURI is the base of the URIs that the SCHMA header of GEDCOM 7.0
+// output declares for extension tags; the tag name without its leading
+// underscore is appended.
+const ExtensionURI = "https://github.com/kedoco/reunion-explore/gedcom/"
+
+// tags70 lists the standard GEDCOM 7.0 event and attribute tags.
+var tags70 = &tagSet{
+	indiEvents: map[string]bool{
+		"BIRT": true, "CHR": true, "DEAT": true, "BURI": true, "CREM": true,
+		"ADOP": true, "BAPM": true, "BARM": true, "BASM": true, "BLES": true,
+		"CHRA": true, "CONF": true, "FCOM": true, "ORDN": true, "NATU": true,
+		"EMIG": true, "IMMI": true, "CENS": true, "PROB": true, "WILL": true,
+		"GRAD": true, "RETI": true,
+		"BAPL": true, "CONL": true, "ENDL": true, "INIL": true, "SLGC": true, // LDS ordinances
+	},
+	indiAttrs: map[string]bool{
+		"CAST": true, "DSCR": true, "EDUC": true, "IDNO": true, "NATI": true,
+		"NCHI": true, "NMR": true, "OCCU": true, "PROP": true, "RELI": true,
+		"RESI": true, "SSN": true, "TITL": true,
+	},
+	famEvents: map[string]bool{
+		"ANUL": true, "CENS": true, "DIV": true, "DIVF": true, "ENGA": true,
+		"MARB": true, "MARC": true, "MARR": true, "MARL": true, "MARS": true,
+		"RESI": true, "NCHI": true, "SLGS": true,
+	},
+	// Reunion's GEDCOM codes that differ from the 7.0 spelling. Reunion
+	// still uses the pre-7.0 WAC code for the initiatory ordinance.
+	aliases: map[string]string{
+		"BLESS": "BLES",
+		"DESC":  "DSCR",
+		"WAC":   "INIL",
+	},
+}
+
+// structuralTags70 are standard GEDCOM 7.0 tags that are not events.
+// Reunion event definitions that carry one of these codes (NOTE, EVEN, ...)
+// fall back to EVEN/FACT with a TYPE instead of becoming extension tags.
+var structuralTags70 = map[string]bool{
+	"ADDR": true, "AGE": true, "ALIA": true, "ANCI": true, "ASSO": true,
+	"AUTH": true, "CALN": true, "CAUS": true, "CHAN": true, "CHIL": true,
+	"CONT": true, "COPR": true, "CREA": true, "DATA": true, "DATE": true,
+	"DESI": true, "DEST": true, "EMAIL": true, "EVEN": true, "EXID": true,
+	"FACT": true, "FAM": true, "FAMC": true, "FAMS": true, "FILE": true,
+	"FORM": true, "GEDC": true, "GIVN": true, "HEAD": true, "HUSB": true,
+	"INDI": true, "LANG": true, "LATI": true, "LONG": true, "MAP": true,
+	"MEDI": true, "MIME": true, "NAME": true, "NICK": true, "NO": true,
+	"NOTE": true, "NPFX": true, "NSFX": true, "OBJE": true, "PAGE": true,
+	"PEDI": true, "PHON": true, "PHRASE": true, "PLAC": true, "PUBL": true,
+	"QUAY": true, "REFN": true, "REPO": true, "RESN": true, "ROLE": true,
+	"SCHMA": true, "SDATE": true, "SEX": true, "SNOTE": true, "SOUR": true,
+	"SPFX": true, "STAT": true, "SUBM": true, "SURN": true, "TAG": true,
+	"TEMP": true, "TEXT": true, "TIME": true, "TRAN": true, "TRLR": true,
+	"TYPE": true, "UID": true, "VERS": true, "WIFE": true, "WWW": true,
+}
+
+// standardTags70 is the set of all standard GEDCOM 7.0 tags.
+var standardTags70 = func() map[string]bool {
+	m := make(map[string]bool)
+	for _, set := range []map[string]bool{structuralTags70, tags70.indiEvents, tags70.indiAttrs, tags70.famEvents} {
+		for tag := range set {
+			m[tag] = true
+		}
+	}
+	return m
+}()
+
+// extensionTag turns a Reunion GEDCOM code into a GEDCOM 7.0 extension tag:
+// an underscore followed by upper-case letters, digits and underscores.
+func extensionTag(code string) string {
+	code = strings.TrimPrefix(strings.ToUpper(code), "_")
+	return "_" + strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, code)
+}
+
+// Write7 writes ff to w as FamilySearch GEDCOM 7.0.
+//
+// The record structure follows Write551, with these differences: notes are
+// SNOTE records, codes that are not standard 7.0 tags become extension tags
+// (declared in the HEAD.SCHMA with ExtensionURI), and each media file
+// becomes an OBJE record linked from the person or family it belongs to.
+// There is no SUBM or CHAR.
+func Write7(w io.Writer, ff *model.FamilyFile, media []Media) error {
+	e := newExporter(ff, tags70)
+	e.v7 = true
+	lw := newLineWriter(w, dialect70)
+
+	for i, m := range media {
+		xref := Xref("O", uint32(i+1))
+		if _, ok := e.idx.Persons[m.PersonID]; ok && m.PersonID != 0 {
+			e.personMedia[m.PersonID] = append(e.personMedia[m.PersonID], xref)
+		}
+		if _, ok := e.idx.Families[m.FamilyID]; ok && m.FamilyID != 0 {
+			e.familyMedia[m.FamilyID] = append(e.familyMedia[m.FamilyID], xref)
+		}
+	}
+
+	lw.line(0, "HEAD", "")
+	lw.line(1, "GEDC", "")
+	lw.line(2, "VERS", "7.0")
+	if ext := e.extensionTags(); len(ext) > 0 {
+		lw.line(1, "SCHMA", "")
+		for _, tag := range ext {
+			lw.line(2, "TAG", tag+" "+ExtensionURI+strings.TrimPrefix(tag, "_"))
+		}
+	}
+	lw.line(1, "SOUR", "REUNION_EXPLORE")
+	lw.line(2, "NAME", "reunion-explore")
+
+	for i := range ff.Persons {
+		e.writeIndi(lw, &ff.Persons[i])
+	}
+	for i := range ff.Families {
+		e.writeFam(lw, &ff.Families[i])
+	}
+	e.writeSources(lw)
+	e.writeNotes(lw)
+	for i, m := range media {
+		lw.record(Xref("O", uint32(i+1)), "OBJE", "")
+		lw.line(1, "FILE", mediaURI(m.Path))
+		lw.line(2, "FORM", mediaType(m.Path))
+	}
+
+	lw.line(0, "TRLR", "")
+	return lw.flush()
+}
+
+// extensionTags returns the sorted extension tags the events of the file
+// will be written with.
+func (e *exporter) extensionTags() []string {
+	seen := make(map[string]bool)
+	add := func(ev event, fact, family bool) {
+		if ev.empty() {
+			return
+		}
+		if tag, _, _ := e.eventTag(ev, fact, family); strings.HasPrefix(tag, "_") {
+			seen[tag] = true
+		}
+	}
+	for i := range e.ff.Persons {
+		for j := range e.ff.Persons[i].Events {
+			evt := &e.ff.Persons[i].Events[j]
+			if evt.Tag >= firstEventTag {
+				add(personEvent(evt), evt.Tag >= firstFactTag, false)
+			}
+		}
+	}
+	for i := range e.ff.Families {
+		for j := range e.ff.Families[i].Events {
+			evt := &e.ff.Families[i].Events[j]
+			if evt.Tag >= firstEventTag {
+				add(familyEvent(evt), false, true)
+			}
+		}
+	}
+	tags := make([]string, 0, len(seen))
+	for tag := range seen {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// mediaURI percent-escapes each segment of a slash-separated relative path
+// so it can be written as a FILE URI reference.
+func mediaURI(p string) string {
+	segs := strings.Split(p, "/")
+	for i, s := range segs {
+		segs[i] = url.PathEscape(s)
+	}
+	return strings.Join(segs, "/")
+}
+
+// mediaType returns the FORM media type for a file, based on its extension.
+func mediaType(p string) string {
+	if t := mime.TypeByExtension(strings.ToLower(path.Ext(p))); t != "" {
+		t, _, _ = strings.Cut(t, ";")
+		return t
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,440 @@
+package gedcom
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	reunion "github.com/kedoco/reunion-explore"
+	"github.com/kedoco/reunion-explore/bundle"
+	"github.com/kedoco/reunion-explore/model"
+	_ "github.com/kedoco/reunion-explore/parser" // register v14 parser
+)
+
+const sampleBundle = "../testdata/Sample Family 14.familyfile14"
+
+// node is a GEDCOM line with its substructure, for test assertions.
+type node struct {
+	Line
+	children []*node
+}
+
+func (n *node) child(tag string) *node {
+	for _, c := range n.children {
+		if c.Tag == tag {
+			return c
+		}
+	}
+	return nil
+}
+
+func (n *node) all(tag string) []*node {
+	var out []*node
+	for _, c := range n.children {
+		if c.Tag == tag {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// buildTree nests lines by level and returns the level-0 records.
+func buildTree(lines []Line) []*node {
+	var roots []*node
+	var stack []*node
+	for _, l := range lines {
+		n := &node{Line: l}
+		stack = stack[:l.Level]
+		if l.Level == 0 {
+			roots = append(roots, n)
+		} else {
+			parent := stack[l.Level-1]
+			parent.children = append(parent.children, n)
+		}
+		stack = append(stack, n)
+	}
+	return roots
+}
+
+func openSample(t *testing.T) *model.FamilyFile {
+	t.Helper()
+	ff, err := reunion.Open(sampleBundle, nil)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	return ff
+}
+
+func TestWrite551_RoundTrip(t *testing.T) {
+	ff := openSample(t)
+
+	var buf bytes.Buffer
+	if err := Write551(&buf, ff); err != nil {
+		t.Fatalf("Write551() error: %v", err)
+	}
+
+	lines, err := ReadLines(&buf)
+	if err != nil {
+		t.Fatalf("output fails GEDCOM 5.5.1 line grammar: %v", err)
+	}
+	records := buildTree(lines)
+
+	byXref := make(map[string]*node)
+	counts := make(map[string]int)
+	for _, r := range records {
+		counts[r.Tag]++
+		if r.Xref != "" {
+			if _, dup := byXref[r.Xref]; dup {
+				t.Errorf("duplicate xref %s", r.Xref)
+			}
+			byXref[r.Xref] = r
+		}
+	}
+
+	notes := 0
+	for _, n := range ff.Notes {
+		if n.DisplayText != "" {
+			notes++
+		}
+	}
+	for tag, want := range map[string]int{
+		"HEAD": 1,
+		"INDI": len(ff.Persons),
+		"FAM":  len(ff.Families),
+		"SOUR": len(ff.Sources),
+		"NOTE": notes,
+		"TRLR": 1,
+	} {
+		if counts[tag] != want {
+			t.Errorf("%s records = %d, want %d", tag, counts[tag], want)
+		}
+	}
+
+	// Every pointer must resolve to a record.
+	for _, l := range lines {
+		if l.IsPointer() {
+			if _, ok := byXref[l.Value]; !ok {
+				t.Errorf("line %d: %s pointer %s does not resolve", l.Num, l.Tag, l.Value)
+			}
+		}
+	}
+
+	head := records[0]
+	if v := head.child("GEDC").child("VERS"); v == nil || v.Value != "5.5.1" {
+		t.Errorf("HEAD.GEDC.VERS missing or wrong")
+	}
+
+	// JFK: name, sex, birth with date, place and citation detail as PAGE.
+	jfk := byXref["@I4@"]
+	if jfk == nil {
+		t.Fatal("INDI @I4@ not found")
+	}
+	if name := jfk.child("NAME"); name == nil || name.Value != "John Fitzgerald /KENNEDY/" {
+		t.Errorf("JFK NAME = %+v", name)
+	}
+	if sex := jfk.child("SEX"); sex == nil || sex.Value != "M" {
+		t.Errorf("JFK SEX = %+v", sex)
+	}
+	birt := jfk.child("BIRT")
+	if birt == nil {
+		t.Fatal("JFK BIRT not found")
+	}
+	if d := birt.child("DATE"); d == nil || d.Value != "29 MAY 1917" {
+		t.Errorf("JFK BIRT.DATE = %+v, want 29 MAY 1917", d)
+	}
+	if p := birt.child("PLAC"); p == nil || p.Value == "" {
+		t.Error("JFK BIRT.PLAC missing")
+	}
+	sour := birt.child("SOUR")
+	if sour == nil || sour.Value != "@S6@" {
+		t.Fatalf("JFK BIRT.SOUR = %+v, want @S6@", sour)
+	}
+	if page := sour.child("PAGE"); page == nil || page.Value != "274" {
+		t.Errorf("JFK BIRT.SOUR.PAGE = %+v, want 274", page)
+	}
+
+	// Family links must agree in both directions.
+	for _, r := range records {
+		if r.Tag != "FAM" {
+			continue
+		}
+		for _, role := range []string{"HUSB", "WIFE"} {
+			for _, p := range r.all(role) {
+				if !hasPointer(byXref[p.Value], "FAMS", r.Xref) {
+					t.Errorf("%s %s has no FAMS %s", role, p.Value, r.Xref)
+				}
+			}
+		}
+		for _, c := range r.all("CHIL") {
+			if !hasPointer(byXref[c.Value], "FAMC", r.Xref) {
+				t.Errorf("CHIL %s has no FAMC %s", c.Value, r.Xref)
+			}
+		}
+	}
+
+	// Family 1 (Joseph & Rose Kennedy) married 7 Oct 1914.
+	marr := byXref["@F1@"].child("MARR")
+	if marr == nil {
+		t.Fatal("F1 MARR not found")
+	}
+	if d := marr.child("DATE"); d == nil || d.Value != "7 OCT 1914" {
+		t.Errorf("F1 MARR.DATE = %+v, want 7 OCT 1914", d)
+	}
+}
+
+func hasPointer(n *node, tag, xref string) bool {
+	if n == nil {
+		return false
+	}
+	for _, c := range n.all(tag) {
+		if c.Value == xref {
+			return true
+		}
+	}
+	return false
+}
+
+func TestReadLines_RejectsBadGrammar(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"leading zero level", "0 HEAD\n01 SOUR x\n0 TRLR\n"},
+		{"level jump", "0 HEAD\n2 SOUR x\n0 TRLR\n"},
+		{"double space", "0 HEAD\n1  SOUR x\n0 TRLR\n"},
+		{"bad tag", "0 HEAD\n1 SO-UR x\n0 TRLR\n"},
+		{"unescaped at", "0 HEAD\n1 NOTE a@b\n0 TRLR\n"},
+		{"xref below level 0", "0 HEAD\n1 @X1@ NOTE x\n0 TRLR\n"},
+		{"trailing delimiter", "0 HEAD\n1 NOTE \n0 TRLR\n"},
+		{"missing trailer", "0 HEAD\n1 NOTE x\n"},
+		{"long line", "0 HEAD\n1 NOTE " + strings.Repeat("x", 250) + "\n0 TRLR\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadLines(strings.NewReader(tt.input)); err == nil {
+				t.Error("ReadLines() accepted invalid input")
+			}
+		})
+	}
+}
+
+func TestLineWriter_SplitsAndEscapes(t *testing.T) {
+	var buf bytes.Buffer
+	lw := newLineWriter(&buf, dialect551)
+	lw.line(0, "HEAD", "")
+	lw.record("@N1@", "NOTE", "mail me@example.com\n"+strings.Repeat("word ", 100))
+	lw.line(0, "TRLR", "")
+	if err := lw.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines, err := ReadLines(&buf)
+	if err != nil {
+		t.Fatalf("ReadLines() error: %v", err)
+	}
+	var text strings.Builder
+	for _, l := range lines[1 : len(lines)-1] {
+		switch l.Tag {
+		case "CONT":
+			text.WriteString("\n" + l.Value)
+		default:
+			text.WriteString(l.Value)
+		}
+	}
+	want := "mail me@@example.com\n" + strings.TrimSpace(strings.Repeat("word ", 100))
+	if got := strings.TrimSpace(text.String()); got != want {
+		t.Errorf("reassembled note = %q, want %q", got, want)
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	end := model.Date{Year: 1855, Precision: model.PrecisionYear}
+	dual := model.Date{Year: 1731, Month: 2, Day: 11, Precision: model.PrecisionDay, Julian: true, DualYear: true}
+	tests := []struct {
+		date model.Date
+		v7   bool
+		want string
+	}{
+		{model.Date{Year: 1917, Month: 5, Day: 29, Precision: model.PrecisionDay}, false, "29 MAY 1917"},
+		{model.Date{Year: 1850, Qualifier: model.DateAbout, Precision: model.PrecisionYear}, false, "ABT 1850"},
+		{model.Date{Year: 1900, Month: 1, Qualifier: model.DateAfter, Precision: model.PrecisionMonth}, false, "AFT JAN 1900"},
+		{model.Date{Year: 1900, Precision: model.PrecisionYear}, false, "1900"},
+		{model.Date{Year: 1850, Qualifier: model.DateBetween, Precision: model.PrecisionYear, End: &end}, false, "BET 1850 AND 1855"},
+		{model.Date{Year: 1790, Qualifier: model.DateEstimated, Precision: model.PrecisionYear}, false, "EST 1790"},
+		{model.Date{Year: 1850, Qualifier: model.DateFromTo, Precision: model.PrecisionYear, End: &end}, false, "FROM 1850 TO 1855"},
+		{model.Date{Year: 1850, Qualifier: model.DateFromTo, Precision: model.PrecisionYear}, false, "FROM 1850"},
+		{dual, false, "@#DJULIAN@ 11 FEB 1731/32"},
+		{dual, true, "JULIAN 11 FEB 1732"},
+	}
+	for _, tt := range tests {
+		if got := formatDate(tt.date, tt.v7); got != tt.want {
+			t.Errorf("formatDate(%v, %v) = %q, want %q", tt.date, tt.v7, got, tt.want)
+		}
+	}
+}
+
+func openSampleMedia(t *testing.T) []Media {
+	t.Helper()
+	b, err := bundle.OpenBundle(sampleBundle)
+	if err != nil {
+		t.Fatalf("OpenBundle() error: %v", err)
+	}
+	return CollectMedia(b)
+}
+
+func TestWrite7_RoundTrip(t *testing.T) {
+	ff := openSample(t)
+	media := openSampleMedia(t)
+	if len(media) == 0 {
+		t.Fatal("CollectMedia() found no media in sample bundle")
+	}
+
+	var buf bytes.Buffer
+	if err := Write7(&buf, ff, media); err != nil {
+		t.Fatalf("Write7() error: %v", err)
+	}
+	lines, err := ReadLines7(&buf)
+	if err != nil {
+		t.Fatalf("output fails GEDCOM 7.0 line grammar: %v", err)
+	}
+	records := buildTree(lines)
+
+	byXref := make(map[string]*node)
+	counts := make(map[string]int)
+	for _, r := range records {
+		counts[r.Tag]++
+		if r.Xref != "" {
+			byXref[r.Xref] = r
+		}
+	}
+	if counts["OBJE"] != len(media) {
+		t.Errorf("OBJE records = %d, want %d", counts["OBJE"], len(media))
+	}
+	if counts["NOTE"] != 0 || counts["SNOTE"] == 0 {
+		t.Errorf("notes written as NOTE=%d SNOTE=%d, want shared notes as SNOTE", counts["NOTE"], counts["SNOTE"])
+	}
+	if counts["SUBM"] != 0 {
+		t.Error("GEDCOM 7.0 output should not need a SUBM record")
+	}
+
+	head := records[0]
+	if v := head.child("GEDC").child("VERS"); v == nil || v.Value != "7.0" {
+		t.Errorf("HEAD.GEDC.VERS missing or wrong")
+	}
+	declared := make(map[string]bool)
+	if schma := head.child("SCHMA"); schma != nil {
+		for _, tag := range schma.all("TAG") {
+			name, uri, _ := strings.Cut(tag.Value, " ")
+			if !strings.HasPrefix(uri, ExtensionURI) {
+				t.Errorf("SCHMA TAG %s has URI %q", name, uri)
+			}
+			declared[name] = true
+		}
+	}
+	for _, l := range lines {
+		if strings.HasPrefix(l.Tag, "_") && !declared[l.Tag] {
+			t.Errorf("line %d: extension tag %s not declared in SCHMA", l.Num, l.Tag)
+		}
+		if l.IsPointer() {
+			if _, ok := byXref[l.Value]; !ok {
+				t.Errorf("line %d: %s pointer %s does not resolve", l.Num, l.Tag, l.Value)
+			}
+		}
+	}
+
+	// JFK has three thumbnails; each links to an OBJE with a JPEG FILE.
+	jfk := byXref["@I4@"]
+	if jfk == nil {
+		t.Fatal("INDI @I4@ not found")
+	}
+	objes := jfk.all("OBJE")
+	if len(objes) != 3 {
+		t.Errorf("JFK OBJE links = %d, want 3", len(objes))
+	}
+	for _, o := range objes {
+		file := byXref[o.Value].child("FILE")
+		if file == nil || !strings.HasPrefix(file.Value, "media/thumbnails/p4-") {
+			t.Errorf("%s FILE = %+v", o.Value, file)
+			continue
+		}
+		if form := file.child("FORM"); form == nil || form.Value != "image/jpeg" {
+			t.Errorf("%s FORM = %+v, want image/jpeg", o.Value, form)
+		}
+	}
+	if d := jfk.child("BIRT").child("DATE"); d == nil || d.Value != "29 MAY 1917" {
+		t.Errorf("JFK BIRT.DATE = %+v, want 29 MAY 1917", d)
+	}
+}
+
+func TestWriteGEDZIP(t *testing.T) {
+	ff := openSample(t)
+	media := openSampleMedia(t)
+
+	var buf bytes.Buffer
+	if err := WriteGEDZIP(&buf, ff, media); err != nil {
+		t.Fatalf("WriteGEDZIP() error: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("output is not a zip archive: %v", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	ged, ok := files["gedcom.ged"]
+	if !ok {
+		t.Fatal("archive has no gedcom.ged")
+	}
+	rc, err := ged.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	lines, err := ReadLines7(rc)
+	if err != nil {
+		t.Fatalf("gedcom.ged fails GEDCOM 7.0 line grammar: %v", err)
+	}
+	nfile := 0
+	for _, l := range lines {
+		if l.Tag != "FILE" {
+			continue
+		}
+		nfile++
+		if _, ok := files[l.Value]; !ok {
+			t.Errorf("FILE %s is not in the archive", l.Value)
+		}
+	}
+	if nfile != len(media) {
+		t.Errorf("FILE lines = %d, want %d", nfile, len(media))
+	}
+}
+
+func TestCollectMedia_LinksByFilename(t *testing.T) {
+	b := &bundle.Bundle{
+		Thumbnails: []string{"/x/p12-abc-1000.jpg", "/x/f3-def-1000.jpg", "/x/other.jpg"},
+		Members: []bundle.MemberDir{{
+			Name:       "Laptop",
+			MediaFiles: []string{"/x/Laptop.media/p7-scan 1.png"},
+		}},
+	}
+	got := CollectMedia(b)
+	want := []Media{
+		{Path: "media/thumbnails/p12-abc-1000.jpg", Source: "/x/p12-abc-1000.jpg", PersonID: 12},
+		{Path: "media/thumbnails/f3-def-1000.jpg", Source: "/x/f3-def-1000.jpg", FamilyID: 3},
+		{Path: "media/thumbnails/other.jpg", Source: "/x/other.jpg"},
+		{Path: "media/Laptop/p7-scan 1.png", Source: "/x/Laptop.media/p7-scan 1.png", PersonID: 7},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("CollectMedia() = %d items, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("media[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if uri := mediaURI(got[3].Path); uri != "media/Laptop/p7-scan%201.png" {
+		t.Errorf("mediaURI() = %q", uri)
+	}
+}
@@ -0,0 +1,119 @@
+package gedcom
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+
+	"github.com/kedoco/reunion-explore/bundle"
+	"github.com/kedoco/reunion-explore/model"
+)
+
+// Media is a file exported as a GEDCOM 7.0 OBJE record.
+type Media struct {
+	Path     string // slash-separated path relative to the GEDCOM file
+	Source   string // file on disk, or in FS
+	FS       fs.FS  // file system holding Source; nil for a file on disk
+	PersonID uint32 // linked person, 0 if none
+	FamilyID uint32 // linked family, 0 if none
+}
+
+// mediaOwner matches the record prefix of Reunion media and thumbnail
+// filenames: p{personID}-... or f{familyID}-...
+var mediaOwner = regexp.MustCompile(`^([pf])(\d+)-`)
+
+// CollectMedia lists a bundle's thumbnails and member media files, linked to
+// their person or family by filename. Paths are laid out for a GEDZIP
+// archive: media/thumbnails/<file> and media/<member>/<file>. Sources are
+// files on disk when the bundle was opened from disk.
+func CollectMedia(b *bundle.Bundle) []Media {
+	var media []Media
+	for _, src := range b.Thumbnails {
+		media = append(media, newMedia(b, path.Join("media", "thumbnails", path.Base(src)), src))
+	}
+	for _, md := range b.Members {
+		for _, src := range md.MediaFiles {
+			media = append(media, newMedia(b, path.Join("media", md.Name, path.Base(src)), src))
+		}
+	}
+	return media
+}
+
+func newMedia(b *bundle.Bundle, p, src string) Media {
+	m := Media{Path: p, Source: src, FS: b.FS}
+	if disk := b.DiskPath(src); disk != "" {
+		m.Source, m.FS = disk, nil
+	}
+	if sm := mediaOwner.FindStringSubmatch(path.Base(src)); sm != nil {
+		id, err := strconv.ParseUint(sm[2], 10, 32)
+		if err == nil {
+			if sm[1] == "p" {
+				m.PersonID = uint32(id)
+			} else {
+				m.FamilyID = uint32(id)
+			}
+		}
+	}
+	return m
+}
+
+// WriteGEDZIP writes ff and its media to w as a GEDZIP archive: the GEDCOM
+// 7.0 dataset as gedcom.ged at the archive root, with each media file
+// stored at its Path.
+func WriteGEDZIP(w io.Writer, ff *model.FamilyFile, media []Media) error {
+	zw := zip.NewWriter(w)
+
+	gw, err := zw.Create("gedcom.ged")
+	if err != nil {
+		return fmt.Errorf("creating gedcom.ged: %w", err)
+	}
+	if err := Write7(gw, ff, media); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range media {
+		if seen[m.Path] {
+			continue
+		}
+		seen[m.Path] = true
+		if err := addZipFile(zw, m); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// open opens the media file for reading.
+func (m Media) open() (fs.File, error) {
+	if m.FS != nil {
+		return m.FS.Open(m.Source)
+	}
+	return os.Open(m.Source)
+}
+
+func addZipFile(zw *zip.Writer, m Media) error {
+	f, err := m.open()
+	if err != nil {
+		return fmt.Errorf("reading media: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("reading media: %w", err)
+	}
+	// Media files are mostly already-compressed images; store them as-is.
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: m.Path, Method: zip.Store, Modified: info.ModTime()})
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", m.Path, err)
+	}
+	if _, err := io.Copy(fw, f); err != nil {
+		return fmt.Errorf("writing %s: %w", m.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package index
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Color returns the color of the first color tag marking personID, as
+// "#rrggbb", or "" if none does.
+func (idx *Index) Color(personID uint32) string {
+	for _, t := range idx.ColorTags[personID] {
+		if t.Color != "" {
+			return t.Color
+		}
+	}
+	return ""
+}
+
+// HasColor reports whether a color tag matching color marks personID. A
+// tag matches by number, by "#rrggbb" color or by label, ignoring case.
+func (idx *Index) HasColor(personID uint32, color string) bool {
+	for _, t := range idx.ColorTags[personID] {
+		if strconv.Itoa(t.ID) == color || strings.EqualFold(t.Color, color) || strings.EqualFold(t.Label, color) {
+			return true
+		}
+	}
+	return false
+}
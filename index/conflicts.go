@@ -0,0 +1,78 @@
+package index
+
+import (
+	"bytes"
+	"cmp"
+	"slices"
+
+	"github.com/kedoco/reunion-explore/model"
+)
+
+// Conflict is a record that two or more members changed concurrently:
+// each member's latest change to it has the same sequence number, so none
+// of them saw the others, but they differ.
+type Conflict struct {
+	RecordType uint16 `json:"record_type"` // familydata record type code, e.g. 0x20C4 for a person
+	RecordID   uint32 `json:"record_id"`
+	SeqNum     uint16 `json:"seq_num"`
+	// Versions holds each member's change, in member order.
+	Versions []model.ChangeRecord `json:"versions"`
+}
+
+// findConflicts returns the records changed concurrently in the .changes
+// logs of members, by record type and then ID.
+func findConflicts(members []model.Member) []Conflict {
+	type key struct {
+		typ uint16
+		id  uint32
+	}
+	latest := make(map[key][]model.ChangeRecord)
+	for _, m := range members {
+		last := make(map[key]model.ChangeRecord)
+		var order []key
+		for _, c := range m.Changes {
+			if c.Operation == model.ChangeUnknown {
+				continue
+			}
+			k := key{c.RecordType, c.RecordID}
+			prev, seen := last[k]
+			if !seen {
+				order = append(order, k)
+			}
+			if !seen || c.SeqNum >= prev.SeqNum {
+				last[k] = c
+			}
+		}
+		for _, k := range order {
+			latest[k] = append(latest[k], last[k])
+		}
+	}
+
+	var conflicts []Conflict
+	for k, versions := range latest {
+		if len(versions) < 2 {
+			continue
+		}
+		top := slices.MaxFunc(versions, func(a, b model.ChangeRecord) int { return cmp.Compare(a.SeqNum, b.SeqNum) }).SeqNum
+		versions = slices.DeleteFunc(versions, func(c model.ChangeRecord) bool { return c.SeqNum != top })
+		if len(versions) < 2 || allSame(versions) {
+			continue
+		}
+		conflicts = append(conflicts, Conflict{RecordType: k.typ, RecordID: k.id, SeqNum: top, Versions: versions})
+	}
+	slices.SortFunc(conflicts, func(a, b Conflict) int {
+		return cmp.Or(cmp.Compare(a.RecordType, b.RecordType), cmp.Compare(a.RecordID, b.RecordID))
+	})
+	return conflicts
+}
+
+// allSame reports whether the changes are the same saved record, as when
+// one member's change has already synced to another.
+func allSame(versions []model.ChangeRecord) bool {
+	for _, v := range versions[1:] {
+		if v.Operation != versions[0].Operation || !bytes.Equal(v.Data, versions[0].Data) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package index
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kedoco/reunion-explore/model"
+)
+
+func change(device string, op model.ChangeOp, id uint32, seq uint16, data string) model.ChangeRecord {
+	return model.ChangeRecord{Device: device, Operation: op, RecordType: 0x20C4, RecordID: id, SeqNum: seq, Data: []byte(data)}
+}
+
+func TestFindConflicts(t *testing.T) {
+	type want struct {
+		id      uint32
+		seq     uint16
+		devices []string
+	}
+	tests := []struct {
+		name    string
+		members []model.Member
+		want    []want
+	}{
+		{"no members", nil, nil},
+		{"empty logs", []model.Member{{Name: "Laptop"}, {Name: "iPad"}}, nil},
+		{
+			"one member",
+			[]model.Member{{Name: "Laptop", Changes: []model.ChangeRecord{
+				change("Laptop", model.ChangeUpdate, 4, 3, "a"),
+				change("Laptop", model.ChangeUpdate, 4, 4, "b"),
+			}}},
+			nil,
+		},
+		{
+			"already synced",
+			[]model.Member{
+				{Name: "Laptop", Changes: []model.ChangeRecord{change("Laptop", model.ChangeUpdate, 4, 3, "same")}},
+				{Name: "iPad", Changes: []model.ChangeRecord{change("iPad", model.ChangeUpdate, 4, 3, "same")}},
+			},
+			nil,
+		},
+		{
+			"later change wins",
+			[]model.Member{
+				{Name: "Laptop", Changes: []model.ChangeRecord{change("Laptop", model.ChangeUpdate, 4, 3, "a")}},
+				{Name: "iPad", Changes: []model.ChangeRecord{change("iPad", model.ChangeUpdate, 4, 4, "b")}},
+			},
+			nil,
+		},
+		{
+			"delete against update",
+			[]model.Member{
+				{Name: "Laptop", Changes: []model.ChangeRecord{change("Laptop", model.ChangeDelete, 4, 3, "")}},
+				{Name: "iPad", Changes: []model.ChangeRecord{change("iPad", model.ChangeUpdate, 4, 3, "b")}},
+			},
+			[]want{{4, 3, []string{"Laptop", "iPad"}}},
+		},
+		{
+			"concurrent updates",
+			[]model.Member{
+				{Name: "Laptop", Changes: []model.ChangeRecord{
+					change("Laptop", model.ChangeUpdate, 9, 2, "x"),
+					change("Laptop", model.ChangeUpdate, 4, 3, "a"),
+				}},
+				{Name: "iPad", Changes: []model.ChangeRecord{
+					change("iPad", model.ChangeUnknown, 0, 0, "junk"),
+					change("iPad", model.ChangeUpdate, 9, 2, "y"),
+					change("iPad", model.ChangeUpdate, 4, 3, "b"),
+				}},
+				{Name: "Desktop", Changes: []model.ChangeRecord{change("Desktop", model.ChangeUpdate, 4, 2, "old")}},
+			},
+			[]want{{4, 3, []string{"Laptop", "iPad"}}, {9, 2, []string{"Laptop", "iPad"}}},
+		},
+	}
+	for _, tt := range tests {
+		got := findConflicts(tt.members)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: findConflicts() = %+v, want %d conflicts", tt.name, got, len(tt.want))
+			continue
+		}
+		for i, c := range got {
+			var devices []string
+			for _, v := range c.Versions {
+				devices = append(devices, v.Device)
+			}
+			w := tt.want[i]
+			if c.RecordType != 0x20C4 || c.RecordID != w.id || c.SeqNum != w.seq || !slices.Equal(devices, w.devices) {
+				t.Errorf("%s: conflict %d = record %d seq %d from %v, want record %d seq %d from %v",
+					tt.name, i, c.RecordID, c.SeqNum, devices, w.id, w.seq, w.devices)
+			}
+		}
+	}
+}
@@ -2,6 +2,8 @@
 package index
 
 import (
+	"cmp"
+	"slices"
 	"strings"
 
 	"github.com/kedoco/reunion-explore/model"
@@ -13,6 +15,15 @@ type TreeEntry struct {
 	Person     *model.Person `json:"person"`
 }
 
+// DatedEvent is a person or family event that has a date.
+type DatedEvent struct {
+	PersonID uint32     `json:"person_id,omitempty"` // 0 for family events
+	FamilyID uint32     `json:"family_id,omitempty"` // 0 for person events
+	SchemaID uint16     `json:"schema_id,omitempty"`
+	Tag      uint16     `json:"tag"`
+	Date     model.Date `json:"date"`
+}
+
 // Index provides fast lookups into a parsed FamilyFile.
 type Index struct {
 	Persons         map[uint32]*model.Person
@@ -21,11 +32,16 @@ type Index struct {
 	Schemas         map[uint32]*model.EventDefinition
 	Sources         map[uint32]*model.Source
 	Notes           map[uint32]*model.Note
-	ChildFamilies   map[uint32][]uint32 // personID -> familyIDs where they're a child
-	PartnerFamilies map[uint32][]uint32   // personID -> familyIDs where they're a partner
-	SurnameIndex    map[string][]uint32   // lowercase surname -> personIDs
-	PlacePersons    map[uint32][]uint32   // placeID -> personIDs with events at that place
-	SchemaPersons   map[uint32][]uint32   // schemaID -> personIDs with that event type
+	ChildFamilies   map[uint32][]uint32          // personID -> familyIDs where they're a child
+	PartnerFamilies map[uint32][]uint32          // personID -> familyIDs where they're a partner
+	SurnameIndex    map[string][]uint32          // lowercase surname -> personIDs
+	PlacePersons    map[uint32][]uint32          // placeID -> personIDs with events at that place
+	SchemaPersons   map[uint32][]uint32          // schemaID -> personIDs with that event type
+	Births          map[uint32]model.Date        // personID -> date of first dated birth event
+	Deaths          map[uint32]model.Date        // personID -> date of first dated death event
+	Timeline        []DatedEvent                 // all dated events, chronologically
+	ColorTags       map[uint32][]*model.ColorTag // personID -> color tags marking them
+	Conflicts       []Conflict                   // records changed concurrently by sync members
 }
 
 // BuildIndex creates lookup indexes from a parsed FamilyFile.
@@ -42,6 +58,9 @@ func BuildIndex(ff *model.FamilyFile) *Index {
 		SurnameIndex:    make(map[string][]uint32),
 		PlacePersons:    make(map[uint32][]uint32),
 		SchemaPersons:   make(map[uint32][]uint32),
+		Births:          make(map[uint32]model.Date),
+		Deaths:          make(map[uint32]model.Date),
+		ColorTags:       make(map[uint32][]*model.ColorTag),
 	}
 
 	for i := range ff.Persons {
@@ -96,9 +115,65 @@ func BuildIndex(ff *model.FamilyFile) *Index {
 		idx.Notes[ff.Notes[i].ID] = &ff.Notes[i]
 	}
 
+	for i := range ff.ColorTags {
+		t := &ff.ColorTags[i]
+		for _, id := range t.PersonIDs {
+			idx.ColorTags[id] = append(idx.ColorTags[id], t)
+		}
+	}
+
+	idx.buildTimeline(ff)
+	idx.Conflicts = findConflicts(ff.Members)
+
 	return idx
 }
 
+// buildTimeline collects dated events into Timeline and the Births and
+// Deaths lookups. It runs after the schemas are indexed, since birth and
+// death events are recognised by their GEDCOM code.
+func (idx *Index) buildTimeline(ff *model.FamilyFile) {
+	for i := range ff.Persons {
+		p := &ff.Persons[i]
+		for _, evt := range p.Events {
+			if evt.Date.IsZero() {
+				continue
+			}
+			idx.Timeline = append(idx.Timeline, DatedEvent{PersonID: p.ID, SchemaID: evt.SchemaID, Tag: evt.Tag, Date: evt.Date})
+			var dates map[uint32]model.Date
+			switch idx.gedcomCode(evt.SchemaID) {
+			case "BIRT":
+				dates = idx.Births
+			case "DEAT":
+				dates = idx.Deaths
+			default:
+				continue
+			}
+			if _, ok := dates[p.ID]; !ok {
+				dates[p.ID] = evt.Date
+			}
+		}
+	}
+	for i := range ff.Families {
+		f := &ff.Families[i]
+		for _, evt := range f.Events {
+			if !evt.Date.IsZero() {
+				idx.Timeline = append(idx.Timeline, DatedEvent{FamilyID: f.ID, SchemaID: evt.SchemaID, Tag: evt.Tag, Date: evt.Date})
+			}
+		}
+	}
+	slices.SortStableFunc(idx.Timeline, func(a, b DatedEvent) int {
+		return cmp.Or(a.Date.Compare(b.Date), cmp.Compare(a.PersonID, b.PersonID), cmp.Compare(a.FamilyID, b.FamilyID))
+	})
+}
+
+func (idx *Index) gedcomCode(schemaID uint16) string {
+	s, ok := idx.Schemas[uint32(schemaID)]
+	if !ok {
+		return ""
+	}
+	return strings.ToUpper(s.GEDCOMCode)
+}
+
 func appendUnique(slice []uint32, val uint32) []uint32 {
 	for _, v := range slice {
 		if v == val {
@@ -149,6 +224,29 @@ func (idx *Index) SchemaName(id uint16) string {
 	return s.DisplayName
 }
 
+// BirthDate returns a person's birth date, or the zero Date if unknown.
+func (idx *Index) BirthDate(personID uint32) model.Date {
+	return idx.Births[personID]
+}
+
+// DeathDate returns a person's death date, or the zero Date if unknown.
+func (idx *Index) DeathDate(personID uint32) model.Date {
+	return idx.Deaths[personID]
+}
+
+// EventsBetween returns the dated events that can fall within from..to
+// (see model.Date.Within), in chronological order. A zero from or to
+// leaves that end open.
+func (idx *Index) EventsBetween(from, to model.Date) []DatedEvent {
+	var out []DatedEvent
+	for _, e := range idx.Timeline {
+		if e.Date.Within(from, to) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
 // Parents returns the partner IDs from families where personID is a child.
 func (idx *Index) Parents(personID uint32) (parents []uint32) {
 	for _, famID := range idx.ChildFamilies[personID] {
@@ -0,0 +1,34 @@
+package index
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// RecentRecord is a person or family with when it was last modified.
+type RecentRecord struct {
+	PersonID uint32    `json:"person_id,omitempty"` // 0 for families
+	FamilyID uint32    `json:"family_id,omitempty"` // 0 for persons
+	Modified time.Time `json:"modified"`
+}
+
+// Recent returns the persons and families modified at or after since,
+// most recently modified first. A zero since returns all of them.
+func (idx *Index) Recent(since time.Time) []RecentRecord {
+	var recs []RecentRecord
+	for _, p := range idx.Persons {
+		if !p.Modified.IsZero() && !p.Modified.Before(since) {
+			recs = append(recs, RecentRecord{PersonID: p.ID, Modified: p.Modified})
+		}
+	}
+	for _, f := range idx.Families {
+		if !f.Modified.IsZero() && !f.Modified.Before(since) {
+			recs = append(recs, RecentRecord{FamilyID: f.ID, Modified: f.Modified})
+		}
+	}
+	slices.SortFunc(recs, func(a, b RecentRecord) int {
+		return cmp.Or(b.Modified.Compare(a.Modified), cmp.Compare(a.FamilyID, b.FamilyID), cmp.Compare(a.PersonID, b.PersonID))
+	})
+	return recs
+}
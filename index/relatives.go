@@ -0,0 +1,107 @@
+package index
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+
+	"github.com/kedoco/reunion-explore/model"
+)
+
+// Relatives returns how each person related to personID is related to
+// them, keyed by person ID, as Reunion records it in relatives.cache:
+// blood relatives through their closest common ancestor, then the spouses
+// of blood relatives, then the blood relatives of personID's spouses.
+func (idx *Index) Relatives(personID uint32) map[uint32]model.Relative {
+	blood := idx.bloodRelatives(personID)
+	rels := maps.Clone(blood)
+
+	for _, id := range slices.Sorted(maps.Keys(blood)) {
+		if id == personID {
+			continue
+		}
+		for _, sp := range idx.Spouses(id) {
+			if _, ok := blood[sp]; ok {
+				continue
+			}
+			r := blood[id]
+			r.PersonID, r.SpouseOf = sp, true
+			if cur, ok := rels[sp]; !ok || closer(r, cur) {
+				rels[sp] = r
+			}
+		}
+	}
+
+	for _, sp := range idx.Spouses(personID) {
+		spBlood := idx.bloodRelatives(sp)
+		for _, id := range slices.Sorted(maps.Keys(spBlood)) {
+			r := spBlood[id]
+			r.ViaSpouse = true
+			if cur, ok := rels[id]; !ok || (cur.ViaSpouse && closer(r, cur)) {
+				rels[id] = r
+			}
+		}
+	}
+	return rels
+}
+
+// bloodRelatives returns the blood relatives of personID, including
+// personID as RelationSelf, each through the closest common ancestor.
+func (idx *Index) bloodRelatives(personID uint32) map[uint32]model.Relative {
+	// Generations up from personID to each ancestor.
+	up := map[uint32]int{personID: 0}
+	queue := []uint32{personID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, p := range idx.Parents(id) {
+			if _, ok := up[p]; !ok {
+				up[p] = up[id] + 1
+				queue = append(queue, p)
+			}
+		}
+	}
+
+	rels := make(map[uint32]model.Relative, len(up))
+	for a, u := range up {
+		r := model.Relative{PersonID: a, Kind: model.RelationAncestor, Up: u}
+		if a == personID {
+			r.Kind = model.RelationSelf
+		}
+		rels[a] = r
+	}
+	for _, a := range slices.Sorted(maps.Keys(up)) {
+		u := up[a]
+		// Generations down from the ancestor to each descendant.
+		down := map[uint32]int{a: 0}
+		queue := []uint32{a}
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+			for _, c := range idx.ChildrenOf(id) {
+				if _, ok := down[c]; ok {
+					continue
+				}
+				down[c] = down[id] + 1
+				queue = append(queue, c)
+				if _, ok := up[c]; ok {
+					continue // an ancestor of personID, or personID
+				}
+				r := model.Relative{PersonID: c, Kind: model.RelationDescendant, Down: down[c] - 1}
+				if u > 0 {
+					r.Kind, r.Up = model.RelationSibling, u-1
+				}
+				if cur, ok := rels[c]; !ok || closer(r, cur) {
+					rels[c] = r
+				}
+			}
+		}
+	}
+	return rels
+}
+
+// closer reports whether a is a closer relationship than b: fewer
+// generations apart, then fewer generations up.
+func closer(a, b model.Relative) bool {
+	return cmp.Or(cmp.Compare(a.Up+a.Down, b.Up+b.Down), cmp.Compare(a.Up, b.Up)) < 0
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // FirstNameEntry represents an entry from fmnames.cache.
 type FirstNameEntry struct {
 	Name     string `json:"name"`
@@ -19,21 +21,46 @@ type SearchName struct {
 	Name string `json:"name"`
 }
 
-// TimestampEntry represents a 20-byte record from timestamps.cache.
+// TimestampEntry represents a 20-byte record from timestamps.cache: when
+// a record was created and last modified. The sample has a single entry,
+// so which records Kind names is not known.
 type TimestampEntry struct {
-	Data []byte `json:"-"`
-	Hex  string `json:"hex"`
+	RecordID uint32    `json:"record_id"`
+	Kind     uint16    `json:"kind"`
+	Created  time.Time `json:"created,omitzero"`
+	Modified time.Time `json:"modified,omitzero"`
+	Data     []byte    `json:"-"`
+	Hex      string    `json:"hex"`
 }
 
-// Bookmark represents the bookmarks.cache content.
-type Bookmark struct {
-	RawData []byte `json:"-"`
-	Size    int    `json:"size"`
+// BookmarkSet is a named list of bookmarked persons from bookmarks.cache,
+// the persons a user is working on. Reunion starts every file with a
+// "Default bookmarks set".
+type BookmarkSet struct {
+	Label     string   `json:"label,omitempty"`
+	PersonIDs []uint32 `json:"person_ids"`
 }
 
-// ColorTag represents a color tag from colortags.cache.
+// ColorTag is a color tag: a color and label that Reunion marks persons
+// with, such as the descendants of an ancestor. Assignments come from
+// colortags.cache and the color and label from colortagsettings.cache.
 type ColorTag struct {
-	Data []byte `json:"-"`
+	ID    int    `json:"id"`
+	Color string `json:"color,omitempty"` // "#rrggbb"
+	Label string `json:"label,omitempty"`
+	// PersonID is the person the tag was applied from, e.g. the ancestor
+	// whose line it marks.
+	PersonID  uint32   `json:"person_id,omitempty"`
+	PersonIDs []uint32 `json:"person_ids"`
+}
+
+// Noteboard is a named board of free-floating research notes from
+// noteboard.cache. Reunion starts every file with a "Default noteboard
+// set". The layout of the notes is not known, so only their number is
+// kept, and the label only of empty noteboards.
+type Noteboard struct {
+	Label     string `json:"label,omitempty"`
+	NoteCount int    `json:"note_count"`
 }
 
 // Association represents an entry from associations.cache.
@@ -41,6 +68,55 @@ type Association struct {
 	Data []byte `json:"-"`
 }
 
+// PersonStats represents a 28-byte per-person record from pstats.cache.
+// Reunion fills these in only for persons whose charts it has laid out;
+// the counts cover the family graph as it stood then. The decoded bytes
+// store each count doubled (spouses and descendant generations as 2n+1),
+// which suggests chart rows; the other bytes are not understood.
+type PersonStats struct {
+	PersonID              uint32 `json:"person_id"`
+	Spouses               int    `json:"spouses"`
+	AncestorGenerations   int    `json:"ancestor_generations"`
+	DescendantGenerations int    `json:"descendant_generations"`
+	Descendants           int    `json:"descendants"`
+	Data                  []byte `json:"-"`
+}
+
+// PersonHash represents a 36-byte per-person record from phash.cache: the
+// keys Reunion matches persons on, e.g. when looking for duplicates.
+type PersonHash struct {
+	PersonID uint32 `json:"person_id"`
+	// Hash holds two words derived from the name and sex, not understood.
+	Hash [2]uint32 `json:"hash"`
+	// The name keys are order-preserving: sorting by them sorts persons
+	// alphabetically. SpouseSurnameKey is that of the surname taken in
+	// the last marriage, or the person's own.
+	SurnameKey       uint32 `json:"surname_key"`
+	GivenNameKey     uint32 `json:"given_name_key"`
+	SpouseSurnameKey uint32 `json:"spouse_surname_key"`
+	Birth            Date   `json:"birth,omitzero"`
+	Death            Date   `json:"death,omitzero"`
+	// Changed is the person's changed date, as in person field 0x001A.
+	Changed Date `json:"changed,omitzero"`
+}
+
+// CacheFile describes a .cache file of the bundle and how it was parsed.
+type CacheFile struct {
+	Name    string `json:"name"`
+	Magic   string `json:"magic"`
+	Size    int    `json:"size"`
+	Records int    `json:"records"`
+	Status  string `json:"status"` // CacheParsed, CacheNotParsed or CacheFailed
+	Error   string `json:"error,omitempty"`
+}
+
+// Cache parse statuses.
+const (
+	CacheParsed    = "parsed"
+	CacheNotParsed = "not parsed"
+	CacheFailed    = "failed"
+)
+
 // GlobalRecordEntry represents the globalRecords.cache content.
 type GlobalRecordEntry struct {
 	RawData []byte `json:"-"`
@@ -0,0 +1,468 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateQualifier describes how a recorded date relates to the actual date.
+type DateQualifier uint8
+
+const (
+	DateExact      DateQualifier = iota // on the date
+	DateAbout                           // approximately on the date
+	DateAfter                           // after the date
+	DateBefore                          // before the date
+	DateBetween                         // between the date and Date.End
+	DateCalculated                      // computed from other information
+	DateEstimated                       // estimated from other information
+	DateFromTo                          // a period from the date to Date.End
+)
+
+var dateQualifierNames = [...]string{"exact", "about", "after", "before", "between", "calculated", "estimated", "from"}
+
+func (q DateQualifier) String() string {
+	if int(q) < len(dateQualifierNames) {
+		return dateQualifierNames[q]
+	}
+	return fmt.Sprintf("qualifier(%d)", uint8(q))
+}
+
+// MarshalText encodes the qualifier by name.
+func (q DateQualifier) MarshalText() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+// UnmarshalText decodes a qualifier name.
+func (q *DateQualifier) UnmarshalText(b []byte) error {
+	for i, name := range dateQualifierNames {
+		if string(b) == name {
+			*q = DateQualifier(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown date qualifier %q", b)
+}
+
+// DatePrecision is the finest date component that is known.
+type DatePrecision uint8
+
+const (
+	PrecisionNone  DatePrecision = iota // no date
+	PrecisionYear                       // year only
+	PrecisionMonth                      // month and year
+	PrecisionDay                        // day, month and year
+)
+
+var datePrecisionNames = [...]string{"none", "year", "month", "day"}
+
+func (p DatePrecision) String() string {
+	if int(p) < len(datePrecisionNames) {
+		return datePrecisionNames[p]
+	}
+	return fmt.Sprintf("precision(%d)", uint8(p))
+}
+
+// MarshalText encodes the precision by name.
+func (p DatePrecision) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText decodes a precision name.
+func (p *DatePrecision) UnmarshalText(b []byte) error {
+	for i, name := range datePrecisionNames {
+		if string(b) == name {
+			*p = DatePrecision(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown date precision %q", b)
+}
+
+// Date is a genealogical date: a possibly partial calendar date with a
+// qualifier. The zero Date means "no date".
+//
+// A dual-dated year ("1731/32") comes from the years when the legal year
+// still began on 25 March; Year holds the old-style year and the date falls
+// in Year+1 by modern reckoning.
+type Date struct {
+	Year      int           `json:"year,omitempty"`
+	Month     int           `json:"month,omitempty"` // 1-12, 0 if not known
+	Day       int           `json:"day,omitempty"`   // 1-31, 0 if not known
+	Qualifier DateQualifier `json:"qualifier"`
+	Precision DatePrecision `json:"precision"`
+	Julian    bool          `json:"julian,omitempty"`    // recorded in the Julian calendar
+	DualYear  bool          `json:"dual_year,omitempty"` // recorded as "Year/Year+1"
+	End       *Date         `json:"end,omitempty"`       // second date of a between or from/to range
+}
+
+// IsZero reports whether d holds no date.
+func (d Date) IsZero() bool {
+	return d.Precision == PrecisionNone
+}
+
+var monthNames = [13]string{"", "Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+
+// String renders the date the way Reunion displays it, e.g. "29 May 1917",
+// "about 1850", "after Jan 1900", "between 1850 and 1855", "from 1914 to
+// 1918" or "11 Feb 1731/32 (Julian)". The zero Date renders as "".
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	s := d.qualified()
+	if d.Julian {
+		s += " (Julian)"
+	}
+	return s
+}
+
+// qualified renders the date and its qualifier, without the calendar.
+func (d Date) qualified() string {
+	s := d.calendar()
+	hasEnd := d.End != nil && !d.End.IsZero()
+	switch d.Qualifier {
+	case DateExact:
+		return s
+	case DateBetween:
+		if hasEnd {
+			return "between " + s + " and " + d.End.calendar()
+		}
+	case DateFromTo:
+		if hasEnd {
+			return "from " + s + " to " + d.End.calendar()
+		}
+	}
+	return d.Qualifier.String() + " " + s
+}
+
+// calendar renders the unqualified calendar date.
+func (d Date) calendar() string {
+	year := strconv.Itoa(d.Year)
+	if d.DualYear {
+		year = fmt.Sprintf("%d/%02d", d.Year, (d.Year+1)%100)
+	}
+	switch {
+	case d.Precision == PrecisionYear || d.Month < 1 || d.Month > 12:
+		return year
+	case d.Precision == PrecisionMonth || d.Day == 0:
+		return monthNames[d.Month] + " " + year
+	default:
+		return fmt.Sprintf("%d %s %s", d.Day, monthNames[d.Month], year)
+	}
+}
+
+// MarshalJSON encodes the date's fields along with its String rendering as
+// "text", so JSON consumers can display dates without formatting them.
+func (d Date) MarshalJSON() ([]byte, error) {
+	type plain Date
+	return json.Marshal(struct {
+		Text string `json:"text,omitempty"`
+		plain
+	}{d.String(), plain(d)})
+}
+
+// Sort keys are yyyymmdd integers; open ends of after/before ranges use
+// these sentinels.
+const (
+	minDateKey = 0
+	maxDateKey = 99991231
+)
+
+func dateKey(y, m, d int) int {
+	return y*10000 + m*100 + d
+}
+
+// span returns the first and last day covered by the unqualified date.
+// Dual-dated years count as the later, new-style year. Julian dates are not
+// converted; the shift of 10-13 days is below what the keys need to order
+// genealogical dates sensibly.
+func (d Date) span() (lo, hi int) {
+	year := d.Year
+	if d.DualYear {
+		year++
+	}
+	switch {
+	case d.Precision == PrecisionYear || d.Month < 1 || d.Month > 12:
+		return dateKey(year, 1, 1), dateKey(year, 12, 31)
+	case d.Precision == PrecisionMonth || d.Day == 0:
+		return dateKey(year, d.Month, 1), dateKey(year, d.Month, 31)
+	default:
+		k := dateKey(year, d.Month, d.Day)
+		return k, k
+	}
+}
+
+// bounds returns the earliest and latest day the actual date can fall on,
+// as yyyymmdd keys. "About", "calculated" and "estimated" dates are treated
+// like exact ones; "after" and "before" are open-ended.
+func (d Date) bounds() (lo, hi int) {
+	lo, hi = d.span()
+	switch d.Qualifier {
+	case DateAfter:
+		hi = maxDateKey
+	case DateBefore:
+		lo = minDateKey
+	case DateBetween, DateFromTo:
+		if d.End != nil && !d.End.IsZero() {
+			_, hi = d.End.span()
+		}
+	}
+	return lo, hi
+}
+
+// Compare orders dates chronologically: by earliest possible day, then by
+// latest possible day, then by qualifier. It returns -1, 0 or +1. Zero
+// Dates sort after all others, so undated entries go last.
+func (d Date) Compare(o Date) int {
+	switch {
+	case d.IsZero() && o.IsZero():
+		return 0
+	case d.IsZero():
+		return 1
+	case o.IsZero():
+		return -1
+	}
+	dlo, dhi := d.bounds()
+	olo, ohi := o.bounds()
+	switch {
+	case dlo != olo:
+		return cmpInt(dlo, olo)
+	case dhi != ohi:
+		return cmpInt(dhi, ohi)
+	default:
+		return cmpInt(int(d.Qualifier), int(o.Qualifier))
+	}
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Within reports whether d can fall in the range from..to, inclusive. A
+// zero from or to leaves that end of the range open. Partial dates match
+// if any day they cover is in range, so "1917" is within "May 1917".."1920".
+// A zero d is never within a range.
+func (d Date) Within(from, to Date) bool {
+	if d.IsZero() {
+		return false
+	}
+	lo, hi := d.bounds()
+	if !from.IsZero() {
+		if flo, _ := from.span(); hi < flo {
+			return false
+		}
+	}
+	if !to.IsZero() {
+		if _, thi := to.span(); lo > thi {
+			return false
+		}
+	}
+	return true
+}
+
+var qualifierWords = map[string]DateQualifier{
+	"about": DateAbout, "abt": DateAbout, "circa": DateAbout, "c.": DateAbout,
+	"after": DateAfter, "aft": DateAfter,
+	"before": DateBefore, "bef": DateBefore,
+	"calculated": DateCalculated, "cal": DateCalculated,
+	"estimated": DateEstimated, "est": DateEstimated,
+}
+
+// ParseDate parses a date written as by Date.String ("29 May 1917",
+// "about 1850", "between 1850 and 1855", "from 1914 to 1918",
+// "1731/32 (Julian)") or in ISO form ("1917-05-29", "1917-05", "1917").
+// Month names may be abbreviated or spelled out and are case-insensitive.
+// An empty string yields the zero Date.
+func ParseDate(s string) (Date, error) {
+	words := strings.Fields(strings.ToLower(s))
+	if len(words) == 0 {
+		return Date{}, nil
+	}
+	julian := false
+	if words[len(words)-1] == "(julian)" {
+		julian = true
+		words = words[:len(words)-1]
+	}
+	var d Date
+	var err error
+	switch {
+	case len(words) == 0:
+		err = fmt.Errorf("missing date")
+	case words[0] == "between" || words[0] == "bet":
+		d, err = parseRange(words[1:], "and", DateBetween, true)
+	case words[0] == "from":
+		d, err = parseRange(words[1:], "to", DateFromTo, false)
+	default:
+		q := DateExact
+		if qw, ok := qualifierWords[words[0]]; ok {
+			q = qw
+			words = words[1:]
+		}
+		d, err = parseCalendar(words)
+		d.Qualifier = q
+	}
+	if err != nil {
+		return Date{}, fmt.Errorf("parsing date %q: %w", s, err)
+	}
+	if julian {
+		d.Julian = true
+		if d.End != nil {
+			d.End.Julian = true
+		}
+	}
+	return d, nil
+}
+
+// parseRange parses "start sep end" into a ranged date with qualifier q.
+// The end is optional unless required is set.
+func parseRange(words []string, sep string, q DateQualifier, required bool) (Date, error) {
+	i := slices.Index(words, sep)
+	if i < 0 {
+		if required {
+			return Date{}, fmt.Errorf("%s without %s", q, sep)
+		}
+		i = len(words)
+	}
+	start, err := parseCalendar(words[:i])
+	if err != nil {
+		return Date{}, err
+	}
+	start.Qualifier = q
+	if i < len(words) {
+		end, err := parseCalendar(words[i+1:])
+		if err != nil {
+			return Date{}, err
+		}
+		start.End = &end
+	}
+	return start, nil
+}
+
+// parseCalendar parses an unqualified date from lower-cased words.
+func parseCalendar(words []string) (Date, error) {
+	if len(words) == 1 && strings.Contains(words[0], "-") {
+		return parseISODate(words[0])
+	}
+	var d Date
+	switch len(words) {
+	case 1:
+		d.Precision = PrecisionYear
+	case 2:
+		d.Precision = PrecisionMonth
+	case 3:
+		d.Precision = PrecisionDay
+	default:
+		return Date{}, fmt.Errorf("expected [day] [month] year")
+	}
+	year, dual, err := parseYear(words[len(words)-1])
+	if err != nil {
+		return Date{}, err
+	}
+	d.Year, d.DualYear = year, dual
+	if len(words) >= 2 {
+		m := monthNumber(words[len(words)-2])
+		if m == 0 {
+			return Date{}, fmt.Errorf("invalid month %q", words[len(words)-2])
+		}
+		d.Month = m
+	}
+	if len(words) == 3 {
+		day, err := strconv.Atoi(words[0])
+		if err != nil || day < 1 || day > 31 {
+			return Date{}, fmt.Errorf("invalid day %q", words[0])
+		}
+		d.Day = day
+	}
+	return d, nil
+}
+
+// parseYear parses a year, or a dual year such as "1731/32" or "1731/1732".
+func parseYear(s string) (year int, dual bool, err error) {
+	old, next, dual := strings.Cut(s, "/")
+	year, err = strconv.Atoi(old)
+	if err != nil || year < 1 || year > 9999 {
+		return 0, false, fmt.Errorf("invalid year %q", s)
+	}
+	if dual {
+		n, err := strconv.Atoi(next)
+		if err != nil || (n != year+1 && n != (year+1)%100) {
+			return 0, false, fmt.Errorf("invalid dual year %q", s)
+		}
+	}
+	return year, dual, nil
+}
+
+// parseISODate parses yyyy-mm or yyyy-mm-dd.
+func parseISODate(s string) (Date, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) > 3 {
+		return Date{}, fmt.Errorf("invalid date %q", s)
+	}
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return Date{}, fmt.Errorf("invalid date %q", s)
+		}
+		nums[i] = n
+	}
+	d := Date{Year: nums[0], Month: nums[1], Precision: PrecisionMonth}
+	if len(nums) == 3 {
+		d.Day = nums[2]
+		d.Precision = PrecisionDay
+	}
+	if d.Year < 1 || d.Year > 9999 || d.Month < 1 || d.Month > 12 || d.Day < 0 || d.Day > 31 {
+		return Date{}, fmt.Errorf("invalid date %q", s)
+	}
+	return d, nil
+}
+
+// monthNumber returns 1-12 for an English month name or its first three
+// letters, or 0 if s is not a month.
+func monthNumber(s string) int {
+	if len(s) < 3 {
+		return 0
+	}
+	for i, name := range monthNames[1:] {
+		if strings.EqualFold(s[:3], name) && strings.HasPrefix(strings.ToLower(fullMonthNames[i]), s) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+var fullMonthNames = [12]string{
+	"January", "February", "March", "April", "May", "June",
+	"July", "August", "September", "October", "November", "December",
+}
+
+// timeLayouts are the forms ParseTime accepts, most precise first.
+var timeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// ParseTime parses a point in time, such as a record's modification time,
+// in RFC 3339 form ("2024-02-20T19:29:17Z") or as a UTC date and optional
+// time ("2024-02-20", "2024-02-20 19:29"). An empty string yields the zero
+// time.
+func ParseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: want e.g. 2024-02-20 or 2024-02-20T19:29:17Z", s)
+}
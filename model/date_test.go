@@ -0,0 +1,155 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate_RoundTripsString(t *testing.T) {
+	for _, s := range []string{
+		"29 May 1917",
+		"Jun 2000",
+		"1850",
+		"about 1850",
+		"after 1 Jan 1900",
+		"before Dec 1800",
+		"calculated 1790",
+		"between 1850 and Mar 1855",
+		"estimated 1790",
+		"from 1914 to 11 Nov 1918",
+		"from Jun 1914",
+		"11 Feb 1731/32",
+		"1 Jan 1700 (Julian)",
+		"between 1700 and 1710 (Julian)",
+	} {
+		d, err := ParseDate(s)
+		if err != nil {
+			t.Errorf("ParseDate(%q) error: %v", s, err)
+			continue
+		}
+		if got := d.String(); got != s {
+			t.Errorf("ParseDate(%q).String() = %q", s, got)
+		}
+	}
+}
+
+func TestParseDate_Forms(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Date
+	}{
+		{"", Date{}},
+		{"1917-05-29", Date{Year: 1917, Month: 5, Day: 29, Precision: PrecisionDay}},
+		{"1917-05", Date{Year: 1917, Month: 5, Precision: PrecisionMonth}},
+		{"29 may 1917", Date{Year: 1917, Month: 5, Day: 29, Precision: PrecisionDay}},
+		{"September 1939", Date{Year: 1939, Month: 9, Precision: PrecisionMonth}},
+		{"abt 1850", Date{Year: 1850, Qualifier: DateAbout, Precision: PrecisionYear}},
+		{"est 1850", Date{Year: 1850, Qualifier: DateEstimated, Precision: PrecisionYear}},
+		{"1799/1800", Date{Year: 1799, Precision: PrecisionYear, DualYear: true}},
+		{"1799/00", Date{Year: 1799, Precision: PrecisionYear, DualYear: true}},
+	}
+	for _, tt := range tests {
+		got, err := ParseDate(tt.in)
+		if err != nil {
+			t.Errorf("ParseDate(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDate(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+	for _, bad := range []string{"xx", "32 May 1917", "1917-13", "Smarch 1917", "between 1850", "1731/35", "(julian)"} {
+		if _, err := ParseDate(bad); err == nil {
+			t.Errorf("ParseDate(%q) accepted invalid date", bad)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"", time.Time{}},
+		{"2024-02-20", time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC)},
+		{"2024-02-20 19:29", time.Date(2024, 2, 20, 19, 29, 0, 0, time.UTC)},
+		{"2024-02-20T19:29:17Z", time.Date(2024, 2, 20, 19, 29, 17, 0, time.UTC)},
+		{"2024-02-20T20:29:17+01:00", time.Date(2024, 2, 20, 19, 29, 17, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.in)
+		if err != nil {
+			t.Errorf("ParseTime(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	for _, bad := range []string{"yesterday", "20 Feb 2024", "2024-02-30"} {
+		if _, err := ParseTime(bad); err == nil {
+			t.Errorf("ParseTime(%q) accepted invalid time", bad)
+		}
+	}
+}
+
+func mustDate(t *testing.T, s string) Date {
+	t.Helper()
+	d, err := ParseDate(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDate_Compare(t *testing.T) {
+	// Each date sorts strictly before the next.
+	ordered := []string{
+		"before 1800",
+		"1850",
+		"about 1850",
+		"between 1850 and 1855",
+		"1 Mar 1850",
+		"Mar 1850", // same start as 1 Mar, but ends later
+		"2 Mar 1850",
+		"after 2 Mar 1850",
+		"Dec 1850",
+		"Feb 1850/51", // new-style 1851
+		"1900",
+		"", // undated goes last
+	}
+	for i := 0; i+1 < len(ordered); i++ {
+		a, b := mustDate(t, ordered[i]), mustDate(t, ordered[i+1])
+		if a.Compare(b) >= 0 || b.Compare(a) <= 0 {
+			t.Errorf("%q should sort before %q", ordered[i], ordered[i+1])
+		}
+	}
+	if d := mustDate(t, "29 May 1917"); d.Compare(d) != 0 {
+		t.Error("date does not compare equal to itself")
+	}
+}
+
+func TestDate_Within(t *testing.T) {
+	tests := []struct {
+		date, from, to string
+		want           bool
+	}{
+		{"29 May 1917", "1900", "1920", true},
+		{"29 May 1917", "1918", "", false},
+		{"29 May 1917", "", "May 1917", true},
+		{"1917", "May 1917", "1920", true}, // partial dates overlap
+		{"after 1950", "", "1960", true},
+		{"after 1950", "", "1940", false},
+		{"before 1800", "1850", "", false},
+		{"between 1850 and 1855", "1854", "1860", true},
+		{"from 1914 to 1918", "1916", "1916", true},
+		{"from 1914 to 1918", "1919", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		d := mustDate(t, tt.date)
+		if got := d.Within(mustDate(t, tt.from), mustDate(t, tt.to)); got != tt.want {
+			t.Errorf("%q.Within(%q, %q) = %v, want %v", tt.date, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package model
+
+// Kinds of Document and ReportDefinition content, told apart by shape.
+const (
+	RecordKindList     = "list"     // named entries, most naming other records
+	RecordKindCriteria = "criteria" // saved find criteria
+	RecordKindFields   = "fields"   // TLV fields, as in person records
+	RecordKindArchive  = "archive"  // Cocoa typedstream or binary plist
+	RecordKindBinary   = "binary"   // layout not understood
+)
+
+// Document represents a 0x2108 document record from the familydata. These
+// hold Reunion's saved lists, find criteria, sort orders and settings. Only
+// lists, criteria and TLV settings are decoded, and no target persons.
+type Document struct {
+	ID     uint32 `json:"id"`
+	SeqNum uint16 `json:"seq_num"`
+	Kind   string `json:"kind"`
+	// Title is the name given to the record by an entry in list ListID.
+	Title     string        `json:"title,omitempty"`
+	ListID    uint32        `json:"list_id,omitempty"`
+	Entries   []ListEntry   `json:"entries,omitempty"`
+	Criteria  *FindCriteria `json:"criteria,omitempty"`
+	Settings  []Setting     `json:"settings,omitempty"`
+	RawFields []RawField    `json:"raw_fields,omitempty"`
+	RawData   []byte        `json:"raw_data,omitempty"` // content of archive and binary records
+}
+
+// ReportDefinition represents a 0x210C report record from the familydata:
+// a list of a report's saved configurations, or one configuration. Most
+// configurations are binary or archived and kept raw; the person a report
+// starts from is not decoded.
+type ReportDefinition struct {
+	ID        uint32      `json:"id"`
+	SeqNum    uint16      `json:"seq_num"`
+	Kind      string      `json:"kind"`
+	Title     string      `json:"title,omitempty"`
+	ListID    uint32      `json:"list_id,omitempty"`
+	Entries   []ListEntry `json:"entries,omitempty"`
+	Settings  []Setting   `json:"settings,omitempty"`
+	RawFields []RawField  `json:"raw_fields,omitempty"`
+	RawData   []byte      `json:"raw_data,omitempty"`
+}
+
+// ListEntry is one entry of a list record. ID is the record of the same
+// type that the entry names; entries that name nothing have ID 0.
+type ListEntry struct {
+	ID   uint32 `json:"id"`
+	Name string `json:"name"`
+}
+
+// FindCriteria is a saved find: the records searched and the conditions
+// they must meet.
+type FindCriteria struct {
+	Target     string          `json:"target"` // "P" persons, "F" families
+	Conditions []FindCondition `json:"conditions,omitempty"`
+}
+
+// FindCondition is one line of a saved find. Field is a Reunion field code
+// (e.g. 0x180A for the birth date) and Op a comparison code; observed
+// values suggest 2 is "less than", 3 "greater than" and 4 "contains".
+type FindCondition struct {
+	Field uint16 `json:"field"`
+	Op    int    `json:"op"`
+	Value string `json:"value,omitempty"`
+}
+
+// Setting is a text-valued TLV field of a document or report record.
+type Setting struct {
+	Tag   uint16 `json:"tag"`
+	Value string `json:"value"`
+}
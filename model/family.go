@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // Family represents a family unit linking partners and children.
 type Family struct {
 	ID        uint32        `json:"id"`
@@ -9,6 +11,8 @@ type Family struct {
 	Children  []uint32      `json:"children,omitempty"`
 	Events    []FamilyEvent `json:"events,omitempty"`
 	RawFields []RawField    `json:"raw_fields,omitempty"`
+	// Modified is when the record was last saved, from its preamble.
+	Modified time.Time `json:"modified,omitzero"`
 }
 
 // FamilyEvent represents an event associated with a family (marriage, etc.).
@@ -17,7 +21,7 @@ type FamilyEvent struct {
 	TypeCode        uint16           `json:"type_code,omitempty"`
 	SchemaID        uint16           `json:"schema_id,omitempty"`
 	PlaceRefs       []int            `json:"place_refs,omitempty"`
-	Date            string           `json:"date,omitempty"`
+	Date            Date             `json:"date,omitzero"`
 	Text            string           `json:"text,omitempty"`
 	SourceCitations []SourceCitation `json:"source_citations,omitempty"`
 	RawData         []byte           `json:"-"`
@@ -1,8 +1,38 @@
 package model
 
-// MediaRef represents a media/attachment record from the familydata.
+// MediaRef is a multimedia file attached to a person or family: one of the
+// media fields of its familydata record.
 type MediaRef struct {
-	ID        uint32     `json:"id"`
-	SeqNum    uint16     `json:"seq_num"`
+	Tag      uint16 `json:"tag"`
+	PersonID uint32 `json:"person_id,omitempty"`
+	FamilyID uint32 `json:"family_id,omitempty"`
+	Caption  string `json:"caption,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	// Path is the file's location on the Mac that linked it, from the
+	// bookmark (alias) Reunion stores instead of a filename.
+	Path    string `json:"path,omitempty"`
+	Comment string `json:"comment,omitempty"`
+	Type    string `json:"type,omitempty"` // MIME type, from the file extension
+	// Key identifies the file; thumbnails are named after it in hex.
+	Key    uint32 `json:"key"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	// Crop is the part of the image shown in charts and thumbnails, as
+	// left, top, right and bottom in pixels.
+	Crop      [4]float32 `json:"crop"`
+	Thumbnail string     `json:"thumbnail,omitempty"`  // path of the bundle's thumbnail JPEG
+	MediaFile string     `json:"media_file,omitempty"` // path of the copy in a member .media directory
+	RawData   []byte     `json:"-"`
+}
+
+// ViewState represents a 0x20D4 record from the familydata. These were
+// first taken for media records, but hold the saved frames and settings of
+// Reunion's windows and views.
+type ViewState struct {
+	ID     uint32 `json:"id"`
+	SeqNum uint16 `json:"seq_num"`
+	// Frames are window frames as "x y width height" followed by the
+	// frame of the screen they were on.
+	Frames    []string   `json:"frames,omitempty"`
 	RawFields []RawField `json:"raw_fields,omitempty"`
 }
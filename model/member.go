@@ -1,5 +1,10 @@
 package model
 
+import (
+	"fmt"
+	"time"
+)
+
 // Member represents a sync member directory within the bundle.
 type Member struct {
 	Name       string         `json:"name"`
@@ -10,9 +15,60 @@ type Member struct {
 	Changes    []ChangeRecord `json:"changes,omitempty"`
 }
 
-// ChangeRecord represents a single entry from a .changes file.
+// ChangeOp is what a change did to its record.
+type ChangeOp uint8
+
+const (
+	ChangeUnknown ChangeOp = iota // no record could be decoded
+	ChangeInsert
+	ChangeUpdate
+	ChangeDelete
+)
+
+var changeOpNames = [...]string{"unknown", "insert", "update", "delete"}
+
+func (op ChangeOp) String() string {
+	if int(op) < len(changeOpNames) {
+		return changeOpNames[op]
+	}
+	return fmt.Sprintf("op(%d)", uint8(op))
+}
+
+// MarshalText encodes the operation by name.
+func (op ChangeOp) MarshalText() ([]byte, error) {
+	return []byte(op.String()), nil
+}
+
+// UnmarshalText decodes an operation name.
+func (op *ChangeOp) UnmarshalText(b []byte) error {
+	for i, name := range changeOpNames {
+		if string(b) == name {
+			*op = ChangeOp(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown change operation %q", b)
+}
+
+// ChangeRecord represents a single entry from a .changes file: a
+// familydata record as one device saved it, for other devices to pick up.
+// The decoded record is in the field for its type, if any. The decoding is
+// experimental: no real .changes file has been available to confirm it.
 type ChangeRecord struct {
-	Offset int    `json:"offset"`
-	Size   int    `json:"size"`
-	Data   []byte `json:"-"`
+	Offset     int       `json:"offset"`
+	Size       int       `json:"size"`
+	Device     string    `json:"device,omitempty"` // name of the member the change came from
+	Operation  ChangeOp  `json:"operation"`
+	RecordType uint16    `json:"record_type,omitempty"` // familydata record type code, e.g. 0x20C4 for a person
+	RecordID   uint32    `json:"record_id,omitempty"`
+	SeqNum     uint16    `json:"seq_num,omitempty"`
+	Time       time.Time `json:"time,omitzero"`
+	Data       []byte    `json:"-"`
+
+	Person          *Person          `json:"person,omitempty"`
+	Family          *Family          `json:"family,omitempty"`
+	EventDefinition *EventDefinition `json:"event_definition,omitempty"`
+	Place           *Place           `json:"place,omitempty"`
+	Source          *Source          `json:"source,omitempty"`
+	Note            *Note            `json:"note,omitempty"`
 }
@@ -14,17 +14,27 @@ type FamilyFile struct {
 	Sources          []Source           `json:"sources,omitempty"`
 	Notes            []Note             `json:"notes,omitempty"`
 	MediaRefs        []MediaRef         `json:"media_refs,omitempty"`
+	ViewStates       []ViewState        `json:"view_states,omitempty"`
+	Documents        []Document         `json:"documents,omitempty"`
+	Reports          []ReportDefinition `json:"reports,omitempty"`
 	FirstNames       []FirstNameEntry   `json:"first_names,omitempty"`
 	Surnames         []SurnameEntry     `json:"surnames,omitempty"`
 	SearchNames      []SearchName       `json:"search_names,omitempty"`
+	SearchTerms      []string           `json:"search_terms,omitempty"` // general search index terms from shGeneral.cache
 	Timestamps       []TimestampEntry   `json:"timestamps,omitempty"`
-	Bookmarks        *Bookmark          `json:"bookmarks,omitempty"`
+	Bookmarks        []BookmarkSet      `json:"bookmarks,omitempty"`
+	Noteboards       []Noteboard        `json:"noteboards,omitempty"`
 	ColorTags        []ColorTag         `json:"color_tags,omitempty"`
 	Associations     []Association      `json:"associations,omitempty"`
+	Relatives        *RelativesCache    `json:"relatives,omitempty"`
+	PersonStats      []PersonStats      `json:"person_stats,omitempty"`
+	PersonHashes     []PersonHash       `json:"person_hashes,omitempty"`
+	PersonIndex      []uint32           `json:"person_index,omitempty"` // person IDs in index.cache order
 	FindText         string             `json:"find_text,omitempty"`
 	Description      string             `json:"description,omitempty"`
 	GlobalRecords    *GlobalRecordEntry `json:"global_records,omitempty"`
 	Members          []Member           `json:"members,omitempty"`
+	Caches           []CacheFile        `json:"caches,omitempty"`
 	Warnings         []string           `json:"warnings,omitempty"`
 }
 
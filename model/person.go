@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // Sex represents a person's sex.
 type Sex int
 
@@ -34,6 +36,8 @@ type Person struct {
 	NoteRefs        []NoteRef        `json:"note_refs,omitempty"`
 	SourceCitations []SourceCitation `json:"source_citations,omitempty"`
 	RawFields       []RawField       `json:"raw_fields,omitempty"`
+	// Modified is when the record was last saved, from its preamble.
+	Modified time.Time `json:"modified,omitzero"`
 }
 
 // PersonEvent represents an event associated with a person (birth, death, etc.).
@@ -42,7 +46,7 @@ type PersonEvent struct {
 	TypeCode        uint16           `json:"type_code,omitempty"`
 	SchemaID        uint16           `json:"schema_id,omitempty"`
 	PlaceRefs       []int            `json:"place_refs,omitempty"`
-	Date            string           `json:"date,omitempty"`
+	Date            Date             `json:"date,omitzero"`
 	Text            string           `json:"text,omitempty"`
 	SourceCitations []SourceCitation `json:"source_citations,omitempty"`
 	RawData         []byte           `json:"-"`
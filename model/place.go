@@ -2,9 +2,21 @@ package model
 
 // Place represents a place from the places cache or familydata.
 type Place struct {
-	ID   uint32 `json:"id"`
-	Name string `json:"name"`
-	Ref  []byte `json:"-"`
+	ID          uint32       `json:"id"`
+	SeqNum      uint16       `json:"seq_num,omitempty"`
+	Name        string       `json:"name"`
+	Coordinates *Coordinates `json:"coordinates,omitempty"`
+	// UsageCount is the number of events and records using the place,
+	// from placeUsage.cache.
+	UsageCount int        `json:"usage_count"`
+	RawFields  []RawField `json:"raw_fields,omitempty"`
+	Ref        []byte     `json:"-"`
+}
+
+// Coordinates is a location in decimal degrees.
+type Coordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
 }
 
 // PlaceUsage links a place to entities that reference it.
@@ -0,0 +1,162 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RelativesCache is the content of relatives.cache: how each person is
+// related to one person, the file's home person.
+type RelativesCache struct {
+	PersonID uint32 `json:"person_id"`
+	// Relatives lists the related persons by ID. Persons not listed are
+	// not related.
+	Relatives []Relative `json:"relatives"`
+}
+
+// RelationKind is the line through which a person is related.
+type RelationKind uint8
+
+const (
+	RelationNone       RelationKind = iota // not related
+	RelationAncestor                       // a parent, grandparent, ...
+	RelationSelf                           // the person themselves
+	RelationSibling                        // a sibling of the person or of an ancestor, or their descendant
+	RelationDescendant                     // a child, grandchild, ...
+)
+
+var relationKindNames = [...]string{"none", "ancestor", "self", "sibling", "descendant"}
+
+func (k RelationKind) String() string {
+	if int(k) < len(relationKindNames) {
+		return relationKindNames[k]
+	}
+	return fmt.Sprintf("relation(%d)", uint8(k))
+}
+
+// MarshalText encodes the kind by name.
+func (k RelationKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText decodes a kind name.
+func (k *RelationKind) UnmarshalText(b []byte) error {
+	for i, name := range relationKindNames {
+		if string(b) == name {
+			*k = RelationKind(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown relation kind %q", b)
+}
+
+// Relative is how a person is related to another. Up counts the
+// generations from the other person to the ancestor the relationship goes
+// through, and Down the generations below that ancestor beyond the first:
+// a first cousin is a Sibling with Up 1 and Down 1.
+type Relative struct {
+	PersonID uint32       `json:"person_id"`
+	Kind     RelationKind `json:"kind"`
+	Up       int          `json:"up,omitempty"`
+	Down     int          `json:"down,omitempty"`
+	// SpouseOf marks the spouse of the relative the rest describes, and
+	// ViaSpouse a relative of the other person's spouse.
+	SpouseOf  bool `json:"spouse_of,omitempty"`
+	ViaSpouse bool `json:"via_spouse,omitempty"`
+}
+
+// Relationship code flags, in the low byte along with the kind.
+const (
+	relationSpouseOf  = 0x20
+	relationViaSpouse = 0x40
+	relationKindMask  = 0x0F
+)
+
+// RelativeFromCode decodes a relatives.cache relationship code: the kind
+// and flags in the low byte, Down in the second byte and Up in the high
+// byte.
+func RelativeFromCode(personID, code uint32) Relative {
+	return Relative{
+		PersonID:  personID,
+		Kind:      RelationKind(code & relationKindMask),
+		Up:        int(code >> 24),
+		Down:      int(code >> 8 & 0xFF),
+		SpouseOf:  code&relationSpouseOf != 0,
+		ViaSpouse: code&relationViaSpouse != 0,
+	}
+}
+
+// Code returns the relatives.cache relationship code of r.
+func (r Relative) Code() uint32 {
+	code := uint32(r.Kind) | uint32(r.Down&0xFF)<<8 | uint32(r.Up&0xFF)<<24
+	if r.SpouseOf {
+		code |= relationSpouseOf
+	}
+	if r.ViaSpouse {
+		code |= relationViaSpouse
+	}
+	return code
+}
+
+// String describes the relationship in words, e.g. "aunt/uncle" or
+// "spouse's parent".
+func (r Relative) String() string {
+	var s string
+	switch r.Kind {
+	case RelationNone:
+		return "unrelated"
+	case RelationSelf:
+		s = "self"
+		if r.ViaSpouse {
+			return "spouse"
+		}
+	case RelationAncestor:
+		s = "parent"
+		if r.Up > 1 {
+			s = strings.Repeat("great-", r.Up-2) + "grandparent"
+		}
+	case RelationDescendant:
+		s = "child"
+		if r.Down > 0 {
+			s = strings.Repeat("great-", r.Down-1) + "grandchild"
+		}
+	case RelationSibling:
+		switch {
+		case r.Up == 0 && r.Down == 0:
+			s = "sibling"
+		case r.Up == 0:
+			s = strings.Repeat("great-", r.Down-1) + "nephew/niece"
+		case r.Down == 0:
+			s = strings.Repeat("great-", r.Up-1) + "aunt/uncle"
+		default:
+			s = ordinal(min(r.Up, r.Down)) + " cousin"
+			if removed := max(r.Up, r.Down) - min(r.Up, r.Down); removed > 0 {
+				s += fmt.Sprintf(" %d× removed", removed)
+			}
+		}
+	default:
+		s = r.Kind.String()
+	}
+	if r.SpouseOf {
+		s += "'s spouse"
+	}
+	if r.ViaSpouse {
+		s = "spouse's " + s
+	}
+	return s
+}
+
+// ordinal returns n as "1st", "2nd", "3rd", "4th", ...
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
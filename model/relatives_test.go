@@ -0,0 +1,37 @@
+package model
+
+import "testing"
+
+func TestRelativeFromCode(t *testing.T) {
+	tests := []struct {
+		code uint32
+		want string
+	}{
+		{0x00000002, "self"},
+		{0x00000042, "spouse"},
+		{0x01000001, "parent"},
+		{0x02000001, "grandparent"},
+		{0x03000001, "great-grandparent"},
+		{0x01000041, "spouse's parent"},
+		{0x00000003, "sibling"},
+		{0x00000023, "sibling's spouse"},
+		{0x00000103, "nephew/niece"},
+		{0x00000203, "great-nephew/niece"},
+		{0x00000123, "nephew/niece's spouse"},
+		{0x01000003, "aunt/uncle"},
+		{0x01000103, "1st cousin"},
+		{0x02000103, "1st cousin 1× removed"},
+		{0x00000004, "child"},
+		{0x00000024, "child's spouse"},
+		{0x00000104, "grandchild"},
+	}
+	for _, tt := range tests {
+		r := RelativeFromCode(7, tt.code)
+		if got := r.String(); got != tt.want {
+			t.Errorf("RelativeFromCode(%#08x) = %q, want %q", tt.code, got, tt.want)
+		}
+		if got := r.Code(); got != tt.code {
+			t.Errorf("RelativeFromCode(%#08x).Code() = %#08x", tt.code, got)
+		}
+	}
+}
@@ -2,8 +2,24 @@ package model
 
 // Source represents a source record from the familydata.
 type Source struct {
-	ID        uint32     `json:"id"`
-	SeqNum    uint16     `json:"seq_num"`
-	Title     string     `json:"title,omitempty"`
-	RawFields []RawField `json:"raw_fields,omitempty"`
+	ID     uint32 `json:"id"`
+	SeqNum uint16 `json:"seq_num"`
+	Title  string `json:"title,omitempty"`
+	// TemplateID is the schema record of the source's template (Book, Web
+	// Site, ...), and Template its name.
+	TemplateID uint32        `json:"template_id,omitempty"`
+	Template   string        `json:"template,omitempty"`
+	Fields     []SourceField `json:"fields,omitempty"`
+	NoteID     uint32        `json:"note_id,omitempty"` // note holding the source's free-form text
+	RawFields  []RawField    `json:"raw_fields,omitempty"`
+}
+
+// SourceField is one field of a source, such as its author or URL. Fields
+// are listed in the template's order.
+type SourceField struct {
+	Tag uint16 `json:"tag"`
+	// TypeID is the schema record defining the field, and Label its name.
+	TypeID uint32 `json:"type_id"`
+	Label  string `json:"label,omitempty"`
+	Value  string `json:"value,omitempty"`
 }
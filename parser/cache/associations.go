@@ -2,15 +2,15 @@ package cache
 
 import (
 	"fmt"
-	"os"
+	"io/fs"
 
 	"github.com/kedoco/reunion-explore/model"
 )
 
 // ParseAssociations parses the associations.cache file.
 // Format: size(4) + "cosa"(4) + count(4) + data
-func ParseAssociations(path string) ([]model.Association, error) {
-	data, err := os.ReadFile(path)
+func ParseAssociations(fsys fs.FS, name string) ([]model.Association, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return nil, fmt.Errorf("reading associations.cache: %w", err)
 	}
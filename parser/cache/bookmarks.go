@@ -2,21 +2,42 @@ package cache
 
 import (
 	"fmt"
-	"os"
+	"io/fs"
 
+	"github.com/kedoco/reunion-explore/internal/binutil"
 	"github.com/kedoco/reunion-explore/model"
 )
 
-// ParseBookmarks parses the bookmarks.cache file.
-// Format: small binary file with magic "2kmb"/"2smb".
-func ParseBookmarks(path string) (*model.Bookmark, error) {
-	data, err := os.ReadFile(path)
+// ParseBookmarks parses the bookmarks.cache file, which holds the user's
+// bookmark sets.
+// Format: a set file (see readSets) with magic "2kmb" and set magic "2smb".
+// Each set body: count * u32 person IDs + label to the end of the set.
+// The sample's only set is empty, so the position of the person IDs is
+// unconfirmed: it is inferred from the zero count word before the label.
+func ParseBookmarks(fsys fs.FS, name string) ([]model.BookmarkSet, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return nil, fmt.Errorf("reading bookmarks.cache: %w", err)
 	}
 
-	return &model.Bookmark{
-		RawData: data,
-		Size:    len(data),
-	}, nil
+	blocks, err := readSets(data, "bookmarks.cache", "2kmb", "2smb")
+	if err != nil {
+		return nil, err
+	}
+
+	sets := make([]model.BookmarkSet, 0, len(blocks))
+	for _, b := range blocks {
+		if 4*int(b.count) > len(b.body) {
+			return nil, fmt.Errorf("bookmarks.cache: set at offset %d has %d persons in %d bytes", b.offset, b.count, len(b.body))
+		}
+		set := model.BookmarkSet{PersonIDs: make([]uint32, 0, b.count)}
+		for i := range int(b.count) {
+			id, _ := binutil.U32LE(b.body, 4*i)
+			set.PersonIDs = append(set.PersonIDs, id)
+		}
+		set.Label, _ = binutil.ReadNullTermString(b.body, 4*int(b.count))
+		sets = append(sets, set)
+	}
+
+	return sets, nil
 }
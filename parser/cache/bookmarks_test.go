@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"encoding/binary"
+	"os"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+const sampleBundle = "../../testdata/Sample Family 14.familyfile14"
+
+// setFile builds a set file, as in bookmarks.cache, with one set holding
+// count items in body. The unknown words are those of the sample.
+func setFile(magic, setMagic string, count uint32, body []byte) []byte {
+	set := binary.LittleEndian.AppendUint32(nil, uint32(20+len(body)))
+	set = append(set, setMagic...)
+	set = binary.LittleEndian.AppendUint32(set, 0x95)
+	set = binary.LittleEndian.AppendUint32(set, 0x11)
+	set = binary.LittleEndian.AppendUint32(set, count)
+	set = append(set, body...)
+
+	data := binary.LittleEndian.AppendUint32(nil, uint32(24+len(set)))
+	data = append(data, magic...)
+	data = append(data, make([]byte, 12)...)
+	data = binary.LittleEndian.AppendUint32(data, 1)
+	return append(data, set...)
+}
+
+func TestParseBookmarks_Sample(t *testing.T) {
+	sets, err := ParseBookmarks(os.DirFS(sampleBundle), "bookmarks.cache")
+	if err != nil {
+		t.Fatalf("ParseBookmarks() error = %v", err)
+	}
+	if len(sets) != 1 || sets[0].Label != "Default bookmarks set" || len(sets[0].PersonIDs) != 0 {
+		t.Errorf("ParseBookmarks() = %+v, want one empty \"Default bookmarks set\"", sets)
+	}
+}
+
+// TestParseBookmarks checks the assumed place of person IDs, before the
+// label; the sample has no bookmarked persons to confirm it.
+func TestParseBookmarks(t *testing.T) {
+	body := binary.LittleEndian.AppendUint32(nil, 4)
+	body = binary.LittleEndian.AppendUint32(body, 22)
+	body = append(body, "Research"...)
+	fsys := fstest.MapFS{"bookmarks.cache": {Data: setFile("2kmb", "2smb", 2, body)}}
+
+	sets, err := ParseBookmarks(fsys, "bookmarks.cache")
+	if err != nil {
+		t.Fatalf("ParseBookmarks() error = %v", err)
+	}
+	if len(sets) != 1 || sets[0].Label != "Research" || !slices.Equal(sets[0].PersonIDs, []uint32{4, 22}) {
+		t.Errorf("ParseBookmarks() = %+v, want Research with persons 4 and 22", sets)
+	}
+}
+
+func TestParseBookmarks_Bad(t *testing.T) {
+	tests := map[string][]byte{
+		"magic":     setFile("10bn", "2smb", 0, []byte("Default")),
+		"set magic": setFile("2kmb", "1sbn", 0, []byte("Default")),
+		"count":     setFile("2kmb", "2smb", 3, []byte("Default")),
+		"short":     []byte("A\x00\x00\x002kmb"),
+	}
+	for name, data := range tests {
+		fsys := fstest.MapFS{"bookmarks.cache": {Data: data}}
+		if sets, err := ParseBookmarks(fsys, "bookmarks.cache"); err == nil {
+			t.Errorf("%s: ParseBookmarks() = %+v, want error", name, sets)
+		}
+	}
+}
@@ -56,3 +56,23 @@ func ReadOffsetTable(data []byte, start int, count int) ([]uint32, error) {
 	}
 	return offsets, nil
 }
+
+// Magic returns the magic of a cache file: up to 4 ASCII letters and
+// digits at offset 4, or, in files that put a count first, up to 8 at
+// offset 8.
+func Magic(data []byte) string {
+	for _, off := range []int{4, 8} {
+		end := off
+		for end < len(data) && end < 2*off && isMagicByte(data[end]) {
+			end++
+		}
+		if end-off >= 2 {
+			return string(data[off:end])
+		}
+	}
+	return ""
+}
+
+func isMagicByte(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+}
@@ -1,25 +1,112 @@
 package cache
 
 import (
+	"bytes"
 	"fmt"
-	"os"
+	"io/fs"
 
+	"github.com/kedoco/reunion-explore/internal/binutil"
 	"github.com/kedoco/reunion-explore/model"
 )
 
-// ParseColorTags parses the colortags.cache file.
-// Format: size(4) + "actc"(4) + count(4) + data
-func ParseColorTags(path string) ([]model.ColorTag, error) {
-	data, err := os.ReadFile(path)
+// ParseColorTags parses the colortags.cache file of color tag assignments.
+// Format: size(4) + "actc"(4) + unknown(4) + count(4) = 16-byte header
+// Then: count tag blocks, each:
+// size(4) + "ictc"(4) + tag number(1) + bitmap length(1) + zero(2)
+// + unknown(4) + person ID(4) + bitmap
+// Bit i of the bitmap, least significant bit first, marks person ID i+1.
+func ParseColorTags(fsys fs.FS, name string) ([]model.ColorTag, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return nil, fmt.Errorf("reading colortags.cache: %w", err)
 	}
 
-	if len(data) <= 16 {
-		// Empty or header-only
-		return nil, nil
+	const headerSize = 16
+	const blockHeaderSize = 20
+
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("colortags.cache too short: %d bytes", len(data))
+	}
+
+	magic := string(data[4:8])
+	if magic != "actc" {
+		return nil, fmt.Errorf("colortags.cache: unexpected magic %q", magic)
+	}
+
+	count, _ := binutil.U32LE(data, 12)
+	pos := headerSize
+	tags := make([]model.ColorTag, 0, count)
+	for i := uint32(0); i < count; i++ {
+		size, _ := binutil.U32LE(data, pos)
+		if size < blockHeaderSize || pos+int(size) > len(data) {
+			return nil, fmt.Errorf("colortags.cache: tag %d: bad block size %d at offset %d", i, size, pos)
+		}
+		block := data[pos : pos+int(size)]
+		if string(block[4:8]) != "ictc" {
+			return nil, fmt.Errorf("colortags.cache: tag %d: unexpected block magic %q", i, block[4:8])
+		}
+		bitmap := block[blockHeaderSize:]
+		if n := int(block[9]); n < len(bitmap) {
+			bitmap = bitmap[:n]
+		}
+		personID, _ := binutil.U32LE(block, 16)
+
+		tag := model.ColorTag{ID: int(block[8]), PersonID: personID, PersonIDs: []uint32{}}
+		for j, b := range bitmap {
+			for bit := range 8 {
+				if b&(1<<bit) != 0 {
+					tag.PersonIDs = append(tag.PersonIDs, uint32(j*8+bit+1))
+				}
+			}
+		}
+		tags = append(tags, tag)
+		pos += int(size)
+	}
+
+	return tags, nil
+}
+
+// ParseColorTagSettings parses the colortagsettings.cache file, which names
+// and colors a color tag. The sample has a single tag, numbered 1.
+// Format: size(4) + "gatc"(4) + unknown(4) + red(2) + green(2) + blue(2)
+// + zero(2) + person ID(4) + unknown(8) + label to the end of the file
+// The color components run from 0 to 0xFFFF. How further tags would be
+// laid out is not known, so a size that is not the file's length, or data
+// after the label's terminator, is an error rather than read as tag 1.
+func ParseColorTagSettings(fsys fs.FS, name string) ([]model.ColorTag, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("reading colortagsettings.cache: %w", err)
+	}
+
+	const headerSize = 32
+
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("colortagsettings.cache too short: %d bytes", len(data))
+	}
+
+	magic := string(data[4:8])
+	if magic != "gatc" {
+		return nil, fmt.Errorf("colortagsettings.cache: unexpected magic %q", magic)
+	}
+
+	if size, _ := binutil.U32LE(data, 0); int(size) != len(data) {
+		return nil, fmt.Errorf("colortagsettings.cache: size %d does not match the file length %d", size, len(data))
+	}
+
+	r, _ := binutil.U16LE(data, 12)
+	g, _ := binutil.U16LE(data, 14)
+	b, _ := binutil.U16LE(data, 16)
+	personID, _ := binutil.U32LE(data, 20)
+	label, rest, _ := bytes.Cut(data[headerSize:], []byte{0})
+	if rest = bytes.TrimRight(rest, "\x00"); len(rest) > 0 {
+		return nil, fmt.Errorf("colortagsettings.cache: %d bytes of unknown data after the label", len(rest))
 	}
 
-	// Parse any records present (usually 0 for this file)
-	return nil, nil
+	return []model.ColorTag{{
+		ID:       1,
+		Color:    fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8),
+		Label:    string(label),
+		PersonID: personID,
+	}}, nil
 }
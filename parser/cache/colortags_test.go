@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"encoding/binary"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kedoco/reunion-explore/model"
+)
+
+func TestParseColorTagSettings_Sample(t *testing.T) {
+	tags, err := ParseColorTagSettings(os.DirFS(sampleBundle), "colortagsettings.cache")
+	if err != nil {
+		t.Fatalf("ParseColorTagSettings() error = %v", err)
+	}
+	want := model.ColorTag{ID: 1, Color: "#014026", Label: "Descendant of Patrick KENNEDY", PersonID: 21}
+	if len(tags) != 1 || tags[0].ID != want.ID || tags[0].Color != want.Color || tags[0].Label != want.Label || tags[0].PersonID != want.PersonID {
+		t.Errorf("ParseColorTagSettings() = %+v, want %+v", tags, want)
+	}
+}
+
+func TestParseColorTagSettings_Malformed(t *testing.T) {
+	sample, err := os.ReadFile(sampleBundle + "/colortagsettings.cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withSize := func(data []byte) []byte {
+		binary.LittleEndian.PutUint32(data, uint32(len(data)))
+		return data
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"size past the end", sample[:len(sample)-4], "does not match the file length"},
+		{"data past the size", append(append([]byte{}, sample...), 0, 0, 0, 0), "does not match the file length"},
+		{"data after the label", withSize(append(append([]byte{}, sample...), "\x00gatc"...)), "after the label"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{"colortagsettings.cache": {Data: tt.data}}
+			if _, err := ParseColorTagSettings(fsys, "colortagsettings.cache"); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseColorTagSettings() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	padded := withSize(append(append([]byte{}, sample...), 0, 0))
+	fsys := fstest.MapFS{"colortagsettings.cache": {Data: padded}}
+	if tags, err := ParseColorTagSettings(fsys, "colortagsettings.cache"); err != nil || len(tags) != 1 || tags[0].Label != "Descendant of Patrick KENNEDY" {
+		t.Errorf("ParseColorTagSettings(padded) = %+v, %v, want the label", tags, err)
+	}
+}
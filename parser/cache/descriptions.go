@@ -2,14 +2,14 @@ package cache
 
 import (
 	"fmt"
-	"os"
+	"io/fs"
 )
 
 // ParseDescriptions parses the descriptions.cache file.
 // Format: size(4) + "idst"(4) + count(4) + extra(4) = 16 byte header
 // Then: a length-prefixed string containing device+user info.
-func ParseDescriptions(path string) (string, error) {
-	data, err := os.ReadFile(path)
+func ParseDescriptions(fsys fs.FS, name string) (string, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return "", fmt.Errorf("reading descriptions.cache: %w", err)
 	}
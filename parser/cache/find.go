@@ -2,13 +2,13 @@ package cache
 
 import (
 	"fmt"
-	"os"
+	"io/fs"
 )
 
 // ParseFind parses the find.cache file and returns the search text.
 // Format: size(4) + "10wf"(4) + data
-func ParseFind(path string) (string, error) {
-	data, err := os.ReadFile(path)
+func ParseFind(fsys fs.FS, name string) (string, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return "", fmt.Errorf("reading find.cache: %w", err)
 	}
@@ -2,7 +2,7 @@ package cache
 
 import (
 	"fmt"
-	"os"
+	"io/fs"
 
 	"github.com/kedoco/reunion-explore/internal/binutil"
 	"github.com/kedoco/reunion-explore/model"
@@ -12,8 +12,8 @@ import (
 // Format: size(4) + "2wps"(4) + count(4) = 12-byte header
 // Then: offset table of count * uint32
 // Each record at offset: size(1) + meta(5) + phonetic(2) + name_string
-func ParseFmNames(path string) ([]model.FirstNameEntry, error) {
-	data, err := os.ReadFile(path)
+func ParseFmNames(fsys fs.FS, name string) ([]model.FirstNameEntry, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return nil, fmt.Errorf("reading fmnames.cache: %w", err)
 	}
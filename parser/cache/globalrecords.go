@@ -3,15 +3,15 @@ package cache
 import (
 	"encoding/hex"
 	"fmt"
-	"os"
+	"io/fs"
 
 	"github.com/kedoco/reunion-explore/model"
 )
 
 // ParseGlobalRecords parses the globalRecords.cache file.
 // Format: 20 bytes total, magic "rblg" at offset 8.
-func ParseGlobalRecords(path string) (*model.GlobalRecordEntry, error) {
-	data, err := os.ReadFile(path)
+func ParseGlobalRecords(fsys fs.FS, name string) (*model.GlobalRecordEntry, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return nil, fmt.Errorf("reading globalRecords.cache: %w", err)
 	}
@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/kedoco/reunion-explore/internal/binutil"
+)
+
+// ParseIndex parses the index.cache file, which holds the order of persons
+// in Reunion's index: by surname, then given name.
+// Format: size(4) + "09ci"(4) + count(4) + unknown(36) = 48-byte header
+// Then: count * uint32 person IDs.
+func ParseIndex(fsys fs.FS, name string) ([]uint32, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("reading index.cache: %w", err)
+	}
+
+	const headerSize = 48
+
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("index.cache too short: %d bytes", len(data))
+	}
+
+	magic := string(data[4:8])
+	if magic != "09ci" {
+		return nil, fmt.Errorf("index.cache: unexpected magic %q", magic)
+	}
+
+	count, _ := binutil.U32LE(data, 8)
+	ids, err := ReadOffsetTable(data, headerSize, int(count))
+	if err != nil {
+		return nil, fmt.Errorf("index.cache: %w", err)
+	}
+
+	return ids, nil
+}
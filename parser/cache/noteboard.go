@@ -1,16 +1,43 @@
 package cache
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
+
+	"github.com/kedoco/reunion-explore/internal/binutil"
+	"github.com/kedoco/reunion-explore/model"
 )
 
-// ParseNoteboard parses the noteboard.cache file.
-// Format: small binary file with magic "10bn"/"1sbn".
-func ParseNoteboard(path string) ([]byte, error) {
-	data, err := os.ReadFile(path)
+// ParseNoteboard parses the noteboard.cache file, which holds the user's
+// noteboards.
+// Format: a set file (see readSets) with magic "10bn" and set magic "1sbn".
+// Each set body: count notes + label to the end of the set. Only empty
+// noteboards have been seen, so the layout of the notes is not known: a
+// noteboard with notes is returned with its note count but no label, and
+// reported in the error alongside the noteboards.
+func ParseNoteboard(fsys fs.FS, name string) ([]model.Noteboard, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return nil, fmt.Errorf("reading noteboard.cache: %w", err)
 	}
-	return data, nil
+
+	blocks, err := readSets(data, "noteboard.cache", "10bn", "1sbn")
+	if err != nil {
+		return nil, err
+	}
+
+	boards := make([]model.Noteboard, 0, len(blocks))
+	var errs []error
+	for _, b := range blocks {
+		board := model.Noteboard{NoteCount: int(b.count)}
+		if b.count > 0 {
+			errs = append(errs, fmt.Errorf("noteboard.cache: noteboard at offset %d has %d notes, note layout not known", b.offset, b.count))
+		} else {
+			board.Label, _ = binutil.ReadNullTermString(b.body, 0)
+		}
+		boards = append(boards, board)
+	}
+
+	return boards, errors.Join(errs...)
 }
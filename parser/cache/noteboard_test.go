@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kedoco/reunion-explore/model"
+)
+
+func TestParseNoteboard_Sample(t *testing.T) {
+	boards, err := ParseNoteboard(os.DirFS(sampleBundle), "noteboard.cache")
+	if err != nil {
+		t.Fatalf("ParseNoteboard() error = %v", err)
+	}
+	want := model.Noteboard{Label: "Default noteboard set"}
+	if len(boards) != 1 || boards[0] != want {
+		t.Errorf("ParseNoteboard() = %+v, want %+v", boards, want)
+	}
+}
+
+func TestParseNoteboard_Notes(t *testing.T) {
+	fsys := fstest.MapFS{"noteboard.cache": {Data: setFile("10bn", "1sbn", 2, []byte("opaque notes\x00Research"))}}
+
+	boards, err := ParseNoteboard(fsys, "noteboard.cache")
+	if err == nil {
+		t.Error("ParseNoteboard() error = nil, want the undecoded notes reported")
+	}
+	want := model.Noteboard{NoteCount: 2}
+	if len(boards) != 1 || boards[0] != want {
+		t.Errorf("ParseNoteboard() = %+v, want %+v", boards, want)
+	}
+}
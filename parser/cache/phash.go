@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/kedoco/reunion-explore/internal/binutil"
+	"github.com/kedoco/reunion-explore/model"
+)
+
+// ParsePHash parses the phash.cache file of per-person matching keys.
+// Format: size(4) + count(4) + "30hp"(4) + unknown(20) = 32-byte header
+// Then: count * 36-byte records, for person IDs 1 to count. Each record:
+// hash(8) + zero(4) + surname key(4) + given name key(4)
+// + spouse surname key(4) + birth(4) + death(4) + changed(4)
+// Birth and death are packed dates (see decodePackedDate); changed is a
+// date as stored in familydata fields. A record with no hash is an unused
+// person ID.
+func ParsePHash(fsys fs.FS, name string) ([]model.PersonHash, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("reading phash.cache: %w", err)
+	}
+
+	const headerSize = 32
+	const recordSize = 36
+
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("phash.cache too short: %d bytes", len(data))
+	}
+
+	magic := string(data[8:12])
+	if magic != "30hp" {
+		return nil, fmt.Errorf("phash.cache: unexpected magic %q", magic)
+	}
+
+	count, _ := binutil.U32LE(data, 4)
+	if need := headerSize + recordSize*int(count); need > len(data) {
+		return nil, fmt.Errorf("phash.cache: %d records need %d bytes, have %d", count, need, len(data))
+	}
+
+	hashes := make([]model.PersonHash, 0, count)
+	for i := 0; i < int(count); i++ {
+		off := headerSize + i*recordSize
+		var w [9]uint32
+		for j := range w {
+			w[j], _ = binutil.U32LE(data, off+4*j)
+		}
+		if w[0] == 0 && w[1] == 0 {
+			continue
+		}
+		hashes = append(hashes, model.PersonHash{
+			PersonID:         uint32(i + 1),
+			Hash:             [2]uint32{w[0], w[1]},
+			SurnameKey:       w[3],
+			GivenNameKey:     w[4],
+			SpouseSurnameKey: w[5],
+			Birth:            decodePackedDate(w[6]),
+			Death:            decodePackedDate(w[7]),
+			Changed:          decodeFieldDate(data[off+32 : off+36]),
+		})
+	}
+
+	return hashes, nil
+}
+
+// decodePackedDate decodes a date packed into a sortable uint32 as
+// (year+8000)<<15 | month<<11 | day<<5 | flags. Full dates carry flags
+// 0x0E; with flags 0x04 and 0x08 clear only the year is known and the
+// month and day are stored as 1. Qualifiers are not recorded.
+func decodePackedDate(v uint32) model.Date {
+	if v == 0 {
+		return model.Date{}
+	}
+	d := model.Date{Year: int(v>>15) - 8000, Precision: model.PrecisionYear}
+	flags := v & 0x1F
+	if flags&0x04 != 0 {
+		d.Month, d.Precision = int(v>>11&0x0F), model.PrecisionMonth
+	}
+	if flags&0x08 != 0 {
+		d.Day, d.Precision = int(v>>5&0x3F), model.PrecisionDay
+	}
+	return d
+}
+
+// decodeFieldDate decodes the 4-byte date of a familydata date sub-field:
+// flags(1) + day(1) + totalQ(2), where totalQ is (year+8000)*4 plus the
+// high two bits of the month and the day byte's top two bits hold the
+// low two. Only plain dates, without qualifier flags, are expected here.
+func decodeFieldDate(b []byte) model.Date {
+	totalQ, _ := binutil.U16LE(b, 2)
+	year := int(totalQ)/4 - 8000
+	month := int(totalQ)%4*4 + int(b[1]>>6)
+	day := int(b[1] & 0x3F)
+	if year < 1 || month > 12 {
+		return model.Date{}
+	}
+	d := model.Date{Year: year, Precision: model.PrecisionYear}
+	if month > 0 {
+		d.Month, d.Precision = month, model.PrecisionMonth
+		if day > 0 {
+			d.Day, d.Precision = day, model.PrecisionDay
+		}
+	}
+	return d
+}
@@ -2,7 +2,7 @@ package cache
 
 import (
 	"fmt"
-	"os"
+	"io/fs"
 
 	"github.com/kedoco/reunion-explore/internal/binutil"
 	"github.com/kedoco/reunion-explore/model"
@@ -11,9 +11,13 @@ import (
 // ParsePlaces parses the places.cache file.
 // Format: size(4) + "ahcp"(4) + count(4) + extra(4) = 16-byte header
 // Then: offset table of count * uint32
-// Each record at offset: size(4) + id(4) + ref(8) + UTF-8 string
-func ParsePlaces(path string) ([]model.Place, error) {
-	data, err := os.ReadFile(path)
+// Each record at offset: size(4) + index(4) + ref(8) + UTF-8 string.
+// The places are sorted by name; index is the position in that order. The
+// ref holds an unknown u16, the name's first two letters in reverse order,
+// then the familydata place ID(4), which is the ID given to the returned
+// place.
+func ParsePlaces(fsys fs.FS, name string) ([]model.Place, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return nil, fmt.Errorf("reading places.cache: %w", err)
 	}
@@ -43,7 +47,7 @@ func ParsePlaces(path string) ([]model.Place, error) {
 			continue
 		}
 		recSize, _ := binutil.U32LE(data, o)
-		id, _ := binutil.U32LE(data, o+4)
+		id, _ := binutil.U32LE(data, o+12)
 		ref := make([]byte, 8)
 		copy(ref, data[o+8:o+16])
 
@@ -2,7 +2,7 @@ package cache
 
 import (
 	"fmt"
-	"os"
+	"io/fs"
 
 	"github.com/kedoco/reunion-explore/internal/binutil"
 	"github.com/kedoco/reunion-explore/model"
@@ -13,8 +13,8 @@ import (
 // Then: 4-byte sub-header, followed by count variable-length records.
 // Each record: total_size(4) + n_entries(4) + place_id(4) + zero(4) + [ref_id(4) + type_code(4)] * n_entries
 // total_size includes the size field itself.
-func ParsePlaceUsage(path string) ([]model.PlaceUsage, error) {
-	data, err := os.ReadFile(path)
+func ParsePlaceUsage(fsys fs.FS, name string) ([]model.PlaceUsage, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return nil, fmt.Errorf("reading placeUsage.cache: %w", err)
 	}
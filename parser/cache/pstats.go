@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+
+	"github.com/kedoco/reunion-explore/internal/binutil"
+	"github.com/kedoco/reunion-explore/model"
+)
+
+// ParsePStats parses the pstats.cache file of per-person chart statistics.
+// Format: size(4) + "hp"(2) + unknown(2) = 8-byte header
+// Then: one 28-byte record per person ID from 1, all zero for persons
+// without statistics. Each record starts:
+// 2*spouses+1(1) + 2*ancestor generations(1) + unknown(14)
+// + 2*descendant generations+1(1) + 2*descendants(1) + unknown(10).
+func ParsePStats(fsys fs.FS, name string) ([]model.PersonStats, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("reading pstats.cache: %w", err)
+	}
+
+	const headerSize = 8
+	const recordSize = 28
+
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("pstats.cache too short: %d bytes", len(data))
+	}
+
+	magic := string(data[4:6])
+	if magic != "hp" {
+		return nil, fmt.Errorf("pstats.cache: unexpected magic %q", magic)
+	}
+	if size, _ := binutil.U32LE(data, 0); int(size) != len(data) {
+		return nil, fmt.Errorf("pstats.cache: header size %d, file has %d bytes", size, len(data))
+	}
+
+	var stats []model.PersonStats
+	empty := make([]byte, recordSize)
+	for i := 0; headerSize+(i+1)*recordSize <= len(data); i++ {
+		off := headerSize + i*recordSize
+		rec := data[off : off+recordSize]
+		if bytes.Equal(rec, empty) {
+			continue
+		}
+		stats = append(stats, model.PersonStats{
+			PersonID:              uint32(i + 1),
+			Spouses:               int(rec[0]) / 2,
+			AncestorGenerations:   int(rec[1]) / 2,
+			DescendantGenerations: int(rec[16]) / 2,
+			Descendants:           int(rec[17]) / 2,
+			Data:                  bytes.Clone(rec),
+		})
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/kedoco/reunion-explore/internal/binutil"
+	"github.com/kedoco/reunion-explore/model"
+)
+
+// ParseRelatives parses the relatives.cache file, which records how each
+// person is related to the home person.
+// Format: size(4) + count(4) + "cler"(4) + home person ID(4) + unknown(4)
+// = 20-byte header
+// Then: count * u32 relationship codes, for person IDs 1 to count (see
+// model.RelativeFromCode). A zero code means the person is not related or
+// does not exist.
+func ParseRelatives(fsys fs.FS, name string) (*model.RelativesCache, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("reading relatives.cache: %w", err)
+	}
+
+	const headerSize = 20
+
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("relatives.cache too short: %d bytes", len(data))
+	}
+
+	magic := string(data[8:12])
+	if magic != "cler" {
+		return nil, fmt.Errorf("relatives.cache: unexpected magic %q", magic)
+	}
+
+	count, _ := binutil.U32LE(data, 4)
+	if need := headerSize + 4*int(count); need > len(data) {
+		return nil, fmt.Errorf("relatives.cache: %d entries need %d bytes, have %d", count, need, len(data))
+	}
+	home, _ := binutil.U32LE(data, 12)
+
+	rc := &model.RelativesCache{PersonID: home}
+	for i := uint32(0); i < count; i++ {
+		code, _ := binutil.U32LE(data, headerSize+4*int(i))
+		if code != 0 {
+			rc.Relatives = append(rc.Relatives, model.RelativeFromCode(i+1, code))
+		}
+	}
+
+	return rc, nil
+}
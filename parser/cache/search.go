@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/kedoco/reunion-explore/internal/binutil"
+)
+
+// readSearchTerms reads the terms of a search cache, shNames.cache or
+// shGeneral.cache, which share a layout.
+// Format: size(4) + count(4) + magic(6) + padding(6) + unknown(12)
+// = 32-byte header
+// Then: count * (record length(u16) + text), where the record length
+// includes its own two bytes. Terms are upper case and sorted.
+func readSearchTerms(data []byte, file, magic string) ([]string, error) {
+	const headerSize = 32
+
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("%s too short: %d bytes", file, len(data))
+	}
+
+	if m := string(data[8 : 8+len(magic)]); m != magic {
+		return nil, fmt.Errorf("%s: unexpected magic %q", file, m)
+	}
+
+	count, _ := binutil.U32LE(data, 4)
+	terms := make([]string, 0, count)
+	pos := headerSize
+	for i := uint32(0); i < count; i++ {
+		n, err := binutil.U16LE(data, pos)
+		if err != nil || n < 2 || pos+int(n) > len(data) {
+			return terms, fmt.Errorf("%s: bad term %d at offset %d", file, i+1, pos)
+		}
+		terms = append(terms, string(data[pos+2:pos+int(n)]))
+		pos += int(n)
+	}
+
+	return terms, nil
+}
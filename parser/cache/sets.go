@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/kedoco/reunion-explore/internal/binutil"
+)
+
+// setBlock is one set of a set file, such as a bookmark set or a
+// noteboard: count is the number of items in it, and body holds the items
+// followed by the set's label.
+type setBlock struct {
+	offset int
+	count  uint32
+	body   []byte
+}
+
+// readSets reads the sets of bookmarks.cache and noteboard.cache, which
+// share a layout. Only files with a single empty set have been seen; the
+// meaning of the count word is inferred from those.
+// Format: size(4) + magic(4) + unknown(12) + set count(4) = 24-byte header
+// Then per set: size(4) + set magic(4) + unknown(8) + count(4) + body
+func readSets(data []byte, file, magic, setMagic string) ([]setBlock, error) {
+	const headerSize = 24
+	const setHeaderSize = 20
+
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("%s too short: %d bytes", file, len(data))
+	}
+
+	if m := string(data[4:8]); m != magic {
+		return nil, fmt.Errorf("%s: unexpected magic %q", file, m)
+	}
+
+	n, _ := binutil.U32LE(data, 20)
+	var sets []setBlock
+	pos := headerSize
+	for i := uint32(0); i < n; i++ {
+		if pos+setHeaderSize > len(data) {
+			return nil, fmt.Errorf("%s: set %d at offset %d truncated", file, i+1, pos)
+		}
+		if m := string(data[pos+4 : pos+8]); m != setMagic {
+			return nil, fmt.Errorf("%s: unexpected set magic %q at offset %d", file, m, pos)
+		}
+		size, _ := binutil.U32LE(data, pos)
+		end := pos + int(size)
+		if int(size) < setHeaderSize || end > len(data) {
+			return nil, fmt.Errorf("%s: set %d at offset %d has bad size %d", file, i+1, pos, size)
+		}
+		count, _ := binutil.U32LE(data, pos+16)
+		sets = append(sets, setBlock{offset: pos, count: count, body: data[pos+setHeaderSize : end]})
+		pos = end
+	}
+
+	return sets, nil
+}
@@ -2,15 +2,17 @@ package cache
 
 import (
 	"fmt"
-	"os"
+	"io/fs"
 )
 
-// ParseShGeneral parses the shGeneral.cache file.
-// Format: size(4) + padding(4) + "10hSeg"(6) + padding
-func ParseShGeneral(path string) ([]byte, error) {
-	data, err := os.ReadFile(path)
+// ParseShGeneral parses the shGeneral.cache file, the terms of the
+// general search index.
+// Format: a search cache (see readSearchTerms) with magic "10hSeg".
+func ParseShGeneral(fsys fs.FS, name string) ([]string, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return nil, fmt.Errorf("reading shGeneral.cache: %w", err)
 	}
-	return data, nil
+
+	return readSearchTerms(data, "shGeneral.cache", "10hSeg")
 }
@@ -2,7 +2,7 @@ package cache
 
 import (
 	"fmt"
-	"os"
+	"io/fs"
 	"strings"
 
 	"github.com/kedoco/reunion-explore/model"
@@ -11,8 +11,8 @@ import (
 // ParseSurnames parses the surnames.cache file.
 // Format: size(4) + "10ns"(4) + packed data
 // Records are parenthesized entries like "(SURNAME, GIVEN))" with binary separators.
-func ParseSurnames(path string) ([]model.SurnameEntry, error) {
-	data, err := os.ReadFile(path)
+func ParseSurnames(fsys fs.FS, name string) ([]model.SurnameEntry, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return nil, fmt.Errorf("reading surnames.cache: %w", err)
 	}
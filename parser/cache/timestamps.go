@@ -3,17 +3,23 @@ package cache
 import (
 	"encoding/hex"
 	"fmt"
-	"os"
+	"io/fs"
+	"time"
 
 	"github.com/kedoco/reunion-explore/internal/binutil"
 	"github.com/kedoco/reunion-explore/model"
 )
 
-// ParseTimestamps parses the timestamps.cache file.
+// ParseTimestamps parses the timestamps.cache file, which records when
+// records were created and modified.
 // Format: size(4) + "icst"(4) + count(4) + extra(4) = 16-byte header
-// Then: count * 20-byte fixed records.
-func ParseTimestamps(path string) ([]model.TimestampEntry, error) {
-	data, err := os.ReadFile(path)
+// Then: count * 20-byte records: unknown(4) + record ID(4)
+// + record kind(2) + unknown(2) + created(4) + modified(4).
+// Times are Unix seconds, as in the familydata record preamble, with zero
+// for unknown. The layout is read from the sample's single entry, record
+// 60 of kind 4; what the kind means is not known, so it is kept as is.
+func ParseTimestamps(fsys fs.FS, name string) ([]model.TimestampEntry, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return nil, fmt.Errorf("reading timestamps.cache: %w", err)
 	}
@@ -40,11 +46,28 @@ func ParseTimestamps(path string) ([]model.TimestampEntry, error) {
 		}
 		rec := make([]byte, recordSize)
 		copy(rec, data[off:off+recordSize])
+		id, _ := binutil.U32LE(rec, 4)
+		kind, _ := binutil.U16LE(rec, 8)
+		created, _ := binutil.U32LE(rec, 12)
+		modified, _ := binutil.U32LE(rec, 16)
 		entries = append(entries, model.TimestampEntry{
-			Data: rec,
-			Hex:  hex.EncodeToString(rec),
+			RecordID: id,
+			Kind:     kind,
+			Created:  unixTime(created),
+			Modified: unixTime(modified),
+			Data:     rec,
+			Hex:      hex.EncodeToString(rec),
 		})
 	}
 
 	return entries, nil
 }
+
+// unixTime returns the time of ts in Unix seconds, or the zero time if ts
+// is zero.
+func unixTime(ts uint32) time.Time {
+	if ts == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(ts), 0).UTC()
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestParseTimestamps_Sample(t *testing.T) {
+	entries, err := ParseTimestamps(os.DirFS(sampleBundle), "timestamps.cache")
+	if err != nil {
+		t.Fatalf("ParseTimestamps() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("ParseTimestamps() = %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.RecordID != 60 || e.Kind != 4 {
+		t.Errorf("entry = record %d, kind %d, want record 60, kind 4", e.RecordID, e.Kind)
+	}
+	if !e.Created.IsZero() {
+		t.Errorf("Created = %v, want zero", e.Created)
+	}
+	if got := e.Modified.Format(time.RFC3339); got != "2026-02-12T02:56:49Z" {
+		t.Errorf("Modified = %s, want 2026-02-12T02:56:49Z", got)
+	}
+	if e.Hex != "9180c8003c000000040060a30000000071418d69" {
+		t.Errorf("Hex = %s", e.Hex)
+	}
+}
+
+func TestParseTimestamps_Bad(t *testing.T) {
+	tests := map[string][]byte{
+		"short": []byte("\x24\x00\x00\x00icst"),
+		"magic": []byte("\x10\x00\x00\x00tsci\x00\x00\x00\x00\x00\x00\x00\x00"),
+	}
+	for name, data := range tests {
+		fsys := fstest.MapFS{"timestamps.cache": {Data: data}}
+		if entries, err := ParseTimestamps(fsys, "timestamps.cache"); err == nil {
+			t.Errorf("%s: ParseTimestamps() = %+v, want error", name, entries)
+		}
+	}
+}
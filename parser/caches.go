@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"io/fs"
+	"maps"
+	"slices"
+
+	reunion "github.com/kedoco/reunion-explore"
+	"github.com/kedoco/reunion-explore/bundle"
+	"github.com/kedoco/reunion-explore/model"
+	"github.com/kedoco/reunion-explore/parser/cache"
+)
+
+// cacheRecords counts the records parsed from each cache file that
+// parseBundle reads.
+var cacheRecords = map[string]func(ff *model.FamilyFile) int{
+	"placeUsage.cache":    func(ff *model.FamilyFile) int { return len(ff.PlaceUsages) },
+	"fmnames.cache":       func(ff *model.FamilyFile) int { return len(ff.FirstNames) },
+	"surnames.cache":      func(ff *model.FamilyFile) int { return len(ff.Surnames) },
+	"shNames.cache":       func(ff *model.FamilyFile) int { return len(ff.SearchNames) },
+	"shGeneral.cache":     func(ff *model.FamilyFile) int { return len(ff.SearchTerms) },
+	"timestamps.cache":    func(ff *model.FamilyFile) int { return len(ff.Timestamps) },
+	"globalRecords.cache": func(ff *model.FamilyFile) int { return count(ff.GlobalRecords != nil) },
+	"bookmarks.cache":     func(ff *model.FamilyFile) int { return len(ff.Bookmarks) },
+	"noteboard.cache":     func(ff *model.FamilyFile) int { return len(ff.Noteboards) },
+	"colortags.cache":     func(ff *model.FamilyFile) int { return len(ff.ColorTags) },
+	"colortagsettings.cache": func(ff *model.FamilyFile) int {
+		n := 0
+		for _, t := range ff.ColorTags {
+			n += count(t.Label != "" || t.Color != "")
+		}
+		return n
+	},
+	"relatives.cache": func(ff *model.FamilyFile) int {
+		if ff.Relatives == nil {
+			return 0
+		}
+		return len(ff.Relatives.Relatives)
+	},
+	"pstats.cache":       func(ff *model.FamilyFile) int { return len(ff.PersonStats) },
+	"phash.cache":        func(ff *model.FamilyFile) int { return len(ff.PersonHashes) },
+	"index.cache":        func(ff *model.FamilyFile) int { return len(ff.PersonIndex) },
+	"find.cache":         func(ff *model.FamilyFile) int { return count(ff.FindText != "") },
+	"descriptions.cache": func(ff *model.FamilyFile) int { return count(ff.Description != "") },
+}
+
+func count(ok bool) int {
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+// listCaches describes each cache file of the bundle, sorted by name,
+// with how parseBundle fared with it.
+func listCaches(fsys fs.FS, b *bundle.Bundle, ff *model.FamilyFile, ec *reunion.ErrorCollector) []model.CacheFile {
+	failed := make(map[string]string)
+	for _, pe := range ec.Errors() {
+		if pe.Message == "failed to parse" && pe.Err != nil {
+			failed[pe.File] = pe.Err.Error()
+		}
+	}
+
+	var caches []model.CacheFile
+	for _, name := range slices.Sorted(maps.Keys(b.Caches)) {
+		c := model.CacheFile{Name: name, Status: model.CacheNotParsed}
+		if data, err := fs.ReadFile(fsys, b.Caches[name]); err == nil {
+			c.Magic = cache.Magic(data)
+			c.Size = len(data)
+		}
+		if records, ok := cacheRecords[name]; ok {
+			c.Status = model.CacheParsed
+			if msg, ok := failed[name]; ok {
+				c.Status, c.Error = model.CacheFailed, msg
+			} else {
+				c.Records = records(ff)
+			}
+		}
+		caches = append(caches, c)
+	}
+	return caches
+}
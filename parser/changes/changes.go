@@ -1,47 +1,101 @@
+// Package changes decodes the .changes sync logs of member directories.
 package changes
 
 import (
+	"bytes"
 	"fmt"
-	"os"
+	"io/fs"
 
+	reunion "github.com/kedoco/reunion-explore"
+	"github.com/kedoco/reunion-explore/internal/binutil"
 	"github.com/kedoco/reunion-explore/model"
+	"github.com/kedoco/reunion-explore/parser/familydata"
 )
 
+// Magic starts every .changes file.
+var Magic = []byte("0sfr")
+
 // ParseChanges parses a .changes file containing sync log records.
-// Format: magic "0sfr" followed by variable-length records.
-func ParseChanges(path string) ([]model.ChangeRecord, error) {
-	data, err := os.ReadFile(path)
+// Format: "0sfr"(4), then per change: size(4) + a familydata record laid
+// out as in familydata.familydata, from its padding through its data (see
+// familydata.ScanRecords).
+//
+// The record's preamble timestamp gives the change's time. A record whose
+// data holds no more than the timestamp is taken as a deletion, one with
+// sequence number 1 as an insertion, and any other as an update. No
+// .changes file with entries has been available, so the layout is
+// inferred from familydata rather than confirmed.
+func ParseChanges(fsys fs.FS, name string) ([]model.ChangeRecord, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
-		return nil, fmt.Errorf("reading changes file %s: %w", path, err)
+		return nil, fmt.Errorf("reading changes file %s: %w", name, err)
 	}
 
-	if len(data) < 4 {
-		return nil, nil
+	if !bytes.HasPrefix(data, Magic) {
+		return nil, fmt.Errorf("changes file %s: unexpected magic %q", name, data[:min(len(data), len(Magic))])
 	}
 
-	// Scan for records by looking for the record marker pattern
 	var records []model.ChangeRecord
-	pos := 0
-
+	pos := len(Magic)
 	for pos < len(data) {
-		// Each record has a size prefix (u32 LE)
-		if pos+4 > len(data) {
-			break
-		}
-		size := int(data[pos]) | int(data[pos+1])<<8 | int(data[pos+2])<<16 | int(data[pos+3])<<24
-		if size <= 0 || pos+4+size > len(data) {
-			break
+		size, err := binutil.U32LE(data, pos)
+		if err != nil || size == 0 || pos+4+int(size) > len(data) {
+			return records, fmt.Errorf("changes file %s: bad change size at offset %d", name, pos)
 		}
 
 		rec := make([]byte, size)
-		copy(rec, data[pos+4:pos+4+size])
-		records = append(records, model.ChangeRecord{
+		copy(rec, data[pos+4:pos+4+int(size)])
+		c := model.ChangeRecord{
 			Offset: pos,
-			Size:   size,
+			Size:   int(size),
 			Data:   rec,
-		})
-		pos += 4 + size
+		}
+		decode(&c)
+		records = append(records, c)
+		pos += 4 + int(size)
 	}
 
 	return records, nil
 }
+
+// decode fills in the operation and record of c from its data, leaving
+// the operation unknown if the data does not start with a record.
+func decode(c *model.ChangeRecord) {
+	recs := familydata.ScanRecords(c.Data)
+	if len(recs) == 0 || recs[0].Offset != 0 {
+		return
+	}
+	rec := recs[0]
+	c.RecordType = uint16(rec.Type)
+	c.RecordID = rec.ID
+	c.SeqNum = rec.SeqNum
+	c.Time = rec.Modified()
+
+	switch {
+	case rec.DataLen <= 4:
+		c.Operation = model.ChangeDelete
+		return
+	case rec.SeqNum <= 1:
+		c.Operation = model.ChangeInsert
+	default:
+		c.Operation = model.ChangeUpdate
+	}
+
+	// The record decoders report problems they can skip past; a change
+	// that does not decode keeps its operation and raw data.
+	ec := reunion.NewErrorCollector(0)
+	switch rec.Type {
+	case familydata.RecordTypePerson:
+		c.Person, _ = familydata.ParsePerson(rec, ec)
+	case familydata.RecordTypeFamily:
+		c.Family, _ = familydata.ParseFamily(rec, ec)
+	case familydata.RecordTypeSchema:
+		c.EventDefinition, _ = familydata.ParseSchema(rec, ec)
+	case familydata.RecordTypePlace:
+		c.Place, _ = familydata.ParsePlace(rec, ec)
+	case familydata.RecordTypeSource:
+		c.Source, _ = familydata.ParseSource(rec, ec)
+	case familydata.RecordTypeNote:
+		c.Note, _ = familydata.ParseNote(rec, ec)
+	}
+}
@@ -0,0 +1,214 @@
+package familydata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	reunion "github.com/kedoco/reunion-explore"
+	"github.com/kedoco/reunion-explore/model"
+)
+
+// docContent is the decoded content of a document or report record, which
+// share their layouts.
+type docContent struct {
+	kind      string
+	entries   []model.ListEntry
+	criteria  *model.FindCriteria
+	settings  []model.Setting
+	rawFields []model.RawField
+	raw       []byte
+}
+
+// ParseDoc parses a 0x2108 document record from familydata. Titles come
+// from list records elsewhere in the file and are set by Parse.
+func ParseDoc(rec RawRecord, ec *reunion.ErrorCollector) (*model.Document, error) {
+	c := parseDocContent(rec)
+	return &model.Document{
+		ID:        rec.ID,
+		SeqNum:    rec.SeqNum,
+		Kind:      c.kind,
+		Entries:   c.entries,
+		Criteria:  c.criteria,
+		Settings:  c.settings,
+		RawFields: c.rawFields,
+		RawData:   c.raw,
+	}, nil
+}
+
+// ParseReport parses a 0x210C report record from familydata. Its layouts
+// are those of document records, except that reports hold no find
+// criteria.
+func ParseReport(rec RawRecord, ec *reunion.ErrorCollector) (*model.ReportDefinition, error) {
+	c := parseDocContent(rec)
+	if c.kind == model.RecordKindCriteria {
+		c = docContent{kind: model.RecordKindBinary, raw: docBody(rec)}
+	}
+	return &model.ReportDefinition{
+		ID:        rec.ID,
+		SeqNum:    rec.SeqNum,
+		Kind:      c.kind,
+		Entries:   c.entries,
+		Settings:  c.settings,
+		RawFields: c.rawFields,
+		RawData:   c.raw,
+	}, nil
+}
+
+// docBody returns the content of rec after its preamble.
+func docBody(rec RawRecord) []byte {
+	content := recordContent(rec)
+	if len(content) < recordPreambleLen {
+		return nil
+	}
+	return content[recordPreambleLen:]
+}
+
+// archiveSignatures start the Cocoa archives some records hold: NSArchiver
+// typedstreams, seen with both spellings of the signature, and
+// NSKeyedArchiver binary plists.
+var archiveSignatures = [][]byte{[]byte("typedstream"), []byte("streamtyped"), []byte("bplist00")}
+
+func parseDocContent(rec RawRecord) docContent {
+	body := docBody(rec)
+	if entries, ok := parseList(body); ok {
+		return docContent{kind: model.RecordKindList, entries: entries}
+	}
+	if fc, ok := parseCriteria(body); ok {
+		return docContent{kind: model.RecordKindCriteria, criteria: fc}
+	}
+	for _, sig := range archiveSignatures {
+		if bytes.HasPrefix(body, sig) {
+			return docContent{kind: model.RecordKindArchive, raw: body}
+		}
+	}
+	if _, fields, rest := splitContent(recordContent(rec)); len(fields) > 0 && len(rest) == 0 {
+		c := docContent{kind: model.RecordKindFields}
+		for _, f := range fields {
+			if s := cleanString(f.Data); s != "" && isText(s) {
+				c.settings = append(c.settings, model.Setting{Tag: f.Tag, Value: s})
+			}
+			c.rawFields = append(c.rawFields, model.RawField{
+				Tag:  f.Tag,
+				Data: f.Data,
+				Size: uint16(len(f.Data) + 4),
+			})
+		}
+		return c
+	}
+	return docContent{kind: model.RecordKindBinary, raw: body}
+}
+
+// parseList decodes a list record: a u16 (always 0), a u32 entry count,
+// then entries of u16 total length, u16 kind ("SF" in most lists), u32
+// record ID and the name.
+func parseList(body []byte) ([]model.ListEntry, bool) {
+	if len(body) < 6 {
+		return nil, false
+	}
+	count := int(binary.LittleEndian.Uint32(body[2:]))
+	pos := 6
+	var entries []model.ListEntry
+	for range count {
+		if pos+8 > len(body) {
+			return nil, false
+		}
+		n := int(binary.LittleEndian.Uint16(body[pos:]))
+		if n < 8 || pos+n > len(body) {
+			return nil, false
+		}
+		name := string(body[pos+8 : pos+n])
+		if !isText(name) {
+			return nil, false
+		}
+		entries = append(entries, model.ListEntry{
+			ID:   binary.LittleEndian.Uint32(body[pos+4:]),
+			Name: name,
+		})
+		pos += n
+	}
+	// The last slot of the record may be zero-padded.
+	if count == 0 || len(bytes.Trim(body[pos:], "\x00")) > 0 {
+		return nil, false
+	}
+	return entries, true
+}
+
+// parseCriteria decodes a saved find: a line naming the records searched
+// ("P" persons, "F" families, "PO" also seen) followed by one condition per
+// line, as hex field code, comparison code and value separated by "|".
+func parseCriteria(body []byte) (*model.FindCriteria, bool) {
+	text := strings.TrimRight(string(body), "\x00")
+	if !isText(text) {
+		return nil, false
+	}
+	target, rest, ok := strings.Cut(text, "\n")
+	if !ok || target == "" || strings.Trim(target, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return nil, false
+	}
+	fc := &model.FindCriteria{Target: target}
+	for line := range strings.SplitSeq(rest, "\n") {
+		parts := strings.SplitN(line, "|", 3)
+		if len(parts) != 3 {
+			return nil, false
+		}
+		field, err := strconv.ParseUint(parts[0], 16, 16)
+		if err != nil {
+			return nil, false
+		}
+		op, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, false
+		}
+		fc.Conditions = append(fc.Conditions, model.FindCondition{
+			Field: uint16(field),
+			Op:    op,
+			Value: parts[2],
+		})
+	}
+	return fc, true
+}
+
+// isText reports whether s is valid UTF-8 with no control characters other
+// than tabs and newlines.
+func isText(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if r < 0x20 && r != '\t' && r != '\n' {
+			return false
+		}
+	}
+	return true
+}
+
+// nameFromLists sets the title of each document and report that an entry
+// in a list record of its own type names.
+func nameFromLists(docs []model.Document, reports []model.ReportDefinition) {
+	byID := make(map[uint32]*model.Document, len(docs))
+	for i := range docs {
+		byID[docs[i].ID] = &docs[i]
+	}
+	for _, list := range docs {
+		for _, e := range list.Entries {
+			if d, ok := byID[e.ID]; ok && e.ID != list.ID {
+				d.Title, d.ListID = e.Name, list.ID
+			}
+		}
+	}
+
+	reportsByID := make(map[uint32]*model.ReportDefinition, len(reports))
+	for i := range reports {
+		reportsByID[reports[i].ID] = &reports[i]
+	}
+	for _, list := range reports {
+		for _, e := range list.Entries {
+			if r, ok := reportsByID[e.ID]; ok && e.ID != list.ID {
+				r.Title, r.ListID = e.Name, list.ID
+			}
+		}
+	}
+}
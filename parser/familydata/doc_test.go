@@ -0,0 +1,148 @@
+package familydata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	reunion "github.com/kedoco/reunion-explore"
+	"github.com/kedoco/reunion-explore/model"
+)
+
+// docRecord returns a record of type typ whose content after the preamble
+// is body.
+func docRecord(typ RecordType, id uint32, body []byte) RawRecord {
+	data := append(makePreamble(), body...)
+	return RawRecord{Type: typ, ID: id, SeqNum: 1, DataLen: uint32(len(data) - 4), Data: data}
+}
+
+// listBody encodes a list record body naming records by ID.
+func listBody(entries ...model.ListEntry) []byte {
+	body := make([]byte, 6)
+	binary.LittleEndian.PutUint32(body[2:], uint32(len(entries)))
+	for _, e := range entries {
+		body = binary.LittleEndian.AppendUint16(body, uint16(8+len(e.Name)))
+		body = append(body, "SF"...)
+		body = binary.LittleEndian.AppendUint32(body, e.ID)
+		body = append(body, e.Name...)
+	}
+	return body
+}
+
+func TestParseDoc(t *testing.T) {
+	entries := []model.ListEntry{{ID: 31, Name: "Marked"}, {ID: 47, Name: "Born in the 1600s"}}
+	var fields []byte
+	fields = append(fields, makeTLVField(0x006E, []byte("Person Sheet"))...)
+	fields = append(fields, makeTLVField(0x0190, []byte{0x01, 0x00})...)
+
+	tests := []struct {
+		name string
+		body []byte
+		want model.Document
+	}{
+		{"list", append(listBody(entries...), 0, 0), model.Document{
+			Kind:    model.RecordKindList,
+			Entries: entries,
+		}},
+		{"criteria", []byte("P\n180a|3|1599\n180a|2|1700"), model.Document{
+			Kind: model.RecordKindCriteria,
+			Criteria: &model.FindCriteria{Target: "P", Conditions: []model.FindCondition{
+				{Field: 0x180A, Op: 3, Value: "1599"},
+				{Field: 0x180A, Op: 2, Value: "1700"},
+			}},
+		}},
+		{"fields", fields, model.Document{
+			Kind:     model.RecordKindFields,
+			Settings: []model.Setting{{Tag: 0x006E, Value: "Person Sheet"}},
+			RawFields: []model.RawField{
+				{Tag: 0x006E, Data: []byte("Person Sheet"), Size: 16},
+				{Tag: 0x0190, Data: []byte{0x01, 0x00}, Size: 6},
+			},
+		}},
+		{"archive", []byte("streamtyped\x81\xe8\x03"), model.Document{
+			Kind:    model.RecordKindArchive,
+			RawData: []byte("streamtyped\x81\xe8\x03"),
+		}},
+		{"binary", []byte{0xff, 0xbf, 0x00, 0x30, 0x01, 0x00}, model.Document{
+			Kind:    model.RecordKindBinary,
+			RawData: []byte{0xff, 0xbf, 0x00, 0x30, 0x01, 0x00},
+		}},
+	}
+	for _, tt := range tests {
+		ec := reunion.NewErrorCollector(0)
+		got, err := ParseDoc(docRecord(RecordTypeDoc, 3, tt.body), ec)
+		if err != nil {
+			t.Fatalf("%s: ParseDoc() error = %v", tt.name, err)
+		}
+		tt.want.ID, tt.want.SeqNum = 3, 1
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("%s: ParseDoc() = %+v, want %+v", tt.name, *got, tt.want)
+		}
+	}
+}
+
+func TestParseReport_NoCriteria(t *testing.T) {
+	body := []byte("P\n4012|0|")
+	got, err := ParseReport(docRecord(RecordTypeReport, 5, body), reunion.NewErrorCollector(0))
+	if err != nil {
+		t.Fatalf("ParseReport() error = %v", err)
+	}
+	if got.Kind != model.RecordKindBinary || !bytes.Equal(got.RawData, body) {
+		t.Errorf("ParseReport() = %+v, want binary", got)
+	}
+}
+
+func TestParseReader_NamesFromLists(t *testing.T) {
+	var data []byte
+	data = append(data, make([]byte, 64)...)
+	// makeRecord writes the timestamp; the rest of the preamble is 2 bytes.
+	data = append(data, makeRecord(1, RecordTypeReport, 3, append([]byte{0, 0}, listBody(
+		model.ListEntry{ID: 58, Name: "Default"},
+		model.ListEntry{ID: 59, Name: "Hourglass"},
+	)...))...)
+	data = append(data, makeRecord(1, RecordTypeReport, 59, []byte{0, 0, 0x41, 0x81, 0, 0})...)
+	data = append(data, makeRecord(1, RecordTypeDoc, 31, []byte("\x00\x00P\n4012|0|"))...)
+
+	result, err := ParseReader(bytes.NewReader(data), reunion.NewErrorCollector(0))
+	if err != nil {
+		t.Fatalf("ParseReader() error = %v", err)
+	}
+	if len(result.Reports) != 2 || len(result.Documents) != 1 {
+		t.Fatalf("got %d reports, %d documents, want 2, 1", len(result.Reports), len(result.Documents))
+	}
+	if r := result.Reports[1]; r.Title != "Hourglass" || r.ListID != 3 {
+		t.Errorf("report 59 title, list = %q, %d, want Hourglass, 3", r.Title, r.ListID)
+	}
+	if d := result.Documents[0]; d.Title != "" || d.Criteria == nil {
+		t.Errorf("document 31 = %+v, want untitled criteria", d)
+	}
+}
+
+func TestParseReader_SampleDocuments(t *testing.T) {
+	result, err := ParseReader(bytes.NewReader(readSample(t)), reunion.NewErrorCollector(0))
+	if err != nil {
+		t.Fatalf("ParseReader() error = %v", err)
+	}
+	titles := make(map[uint32]string)
+	for _, d := range result.Documents {
+		titles[d.ID] = d.Title
+		if d.ID == 47 {
+			if d.Criteria == nil || d.Criteria.Target != "P" || len(d.Criteria.Conditions) != 2 {
+				t.Errorf("document 47 criteria = %+v", d.Criteria)
+			}
+		}
+	}
+	for id, want := range map[uint32]string{31: "Marked", 47: "Born in the 1600s", 66: "Birth Date"} {
+		if titles[id] != want {
+			t.Errorf("document %d title = %q, want %q", id, titles[id], want)
+		}
+	}
+	var hourglass bool
+	for _, r := range result.Reports {
+		hourglass = hourglass || r.ID == 59 && r.Title == "Hourglass"
+	}
+	if !hourglass {
+		t.Error("report 59 not titled Hourglass")
+	}
+}
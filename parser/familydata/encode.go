@@ -0,0 +1,530 @@
+package familydata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kedoco/reunion-explore/model"
+
+	reunion "github.com/kedoco/reunion-explore"
+)
+
+// recordPreambleLen is the size of the timestamp and repeated data length
+// that start every record's data, before its TLV fields.
+const recordPreambleLen = 6
+
+// recordContent returns the declared content of rec: the preamble and TLV
+// fields, without the slack and look-ahead bytes ScanRecords appends.
+func recordContent(rec RawRecord) []byte {
+	return rec.Data[:min(int(rec.DataLen)+4, len(rec.Data))]
+}
+
+// splitContent splits record content into its preamble, the complete TLV
+// fields, and any trailing bytes that do not form a field.
+func splitContent(content []byte) (preamble []byte, fields []TLVField, rest []byte) {
+	if len(content) < recordPreambleLen {
+		return make([]byte, recordPreambleLen), nil, content
+	}
+	pos := recordPreambleLen
+	for pos+4 <= len(content) {
+		totalLen := int(binary.LittleEndian.Uint16(content[pos:]))
+		if totalLen < 4 || pos+totalLen > len(content) {
+			break
+		}
+		fields = append(fields, TLVField{
+			Tag:    binary.LittleEndian.Uint16(content[pos+2:]),
+			Offset: pos - recordPreambleLen,
+			Data:   content[pos+4 : pos+totalLen],
+		})
+		pos += totalLen
+	}
+	return content[:recordPreambleLen], fields, content[pos:]
+}
+
+// appendTLV appends one TLV field to buf.
+func appendTLV(buf []byte, tag uint16, data []byte) ([]byte, error) {
+	if len(data)+4 > 0xFFFF {
+		return buf, fmt.Errorf("field 0x%04X too long: %d bytes", tag, len(data))
+	}
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(data)+4))
+	buf = binary.LittleEndian.AppendUint16(buf, tag)
+	return append(buf, data...), nil
+}
+
+// withContent returns rec with its content replaced. The preamble's
+// repeated data length is updated if it matched DataLen. The record keeps
+// its slot: slack bytes are zeroed, and the slot grows in 128-byte steps if
+// the new content does not fit. Look-ahead bytes into the next record are
+// kept so Writer handles the result like a scanned record. Unchanged
+// content leaves rec as it is.
+func withContent(rec RawRecord, content []byte) RawRecord {
+	old := recordContent(rec)
+	if bytes.Equal(content, old) {
+		return rec
+	}
+	extra := rec.Data[len(old):]
+	var lookahead []byte
+	if len(rec.Data) >= int(rec.DataLen)+8 {
+		extra, lookahead = extra[:len(extra)-4], extra[len(extra)-4:]
+	}
+
+	dataLen := len(content) - 4
+	if len(old) >= recordPreambleLen && len(content) >= recordPreambleLen &&
+		binary.LittleEndian.Uint16(old[4:]) == uint16(rec.DataLen) {
+		binary.LittleEndian.PutUint16(content[4:], uint16(dataLen))
+	}
+
+	body := len(content)
+	if len(extra) > 0 {
+		slot := max(recordHeaderLen+len(old)+len(extra), recordHeaderLen+len(content))
+		slot = (slot + slotSize - 1) / slotSize * slotSize
+		body = slot - recordHeaderLen
+	}
+	data := make([]byte, body, body+len(lookahead))
+	copy(data, content)
+	rec.Data = append(data, lookahead...)
+	rec.DataLen = uint32(dataLen)
+	return rec
+}
+
+// fieldEncoder rebuilds a record's TLV fields in their original order.
+type fieldEncoder struct {
+	out []byte
+	err error
+}
+
+func (e *fieldEncoder) add(tag uint16, data []byte) {
+	if e.err == nil {
+		e.out, e.err = appendTLV(e.out, tag, data)
+	}
+}
+
+// addString writes a string field, keeping the original bytes when they
+// still decode to s and dropping the field when s is empty.
+func (e *fieldEncoder) addString(f TLVField, s string) {
+	switch {
+	case cleanString(f.Data) == s:
+		e.add(f.Tag, f.Data)
+	case s != "":
+		e.add(f.Tag, []byte(s))
+	}
+}
+
+// lastIndex returns the index of the last field with one of the given
+// tags, or -1; firstIndex likewise returns the first.
+func lastIndex(fields []TLVField, tags ...uint16) int {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if slices.Contains(tags, fields[i].Tag) {
+			return i
+		}
+	}
+	return -1
+}
+
+func firstIndex(fields []TLVField, tags ...uint16) int {
+	for i, f := range fields {
+		if slices.Contains(tags, f.Tag) {
+			return i
+		}
+	}
+	return -1
+}
+
+// EncodePerson re-encodes rec's TLV fields from p, which was parsed from
+// rec and possibly modified, and returns the updated record.
+//
+// Fields keep their original order and bytes unless the value they carry
+// changed; an unmodified Person encodes to an identical record. Names,
+// titles, user ID and sex are rewritten from p, and fields for newly set
+// values are appended. Events and unrecognized fields are matched to
+// p.Events and p.RawFields by position: event dates are re-encoded when
+// they differ from the event's RawData, and anything else in RawData is
+// written as-is. An event without RawData is new: it is encoded from its
+// SchemaID and Date. Name source citations are kept verbatim.
+func EncodePerson(rec RawRecord, p *model.Person) (RawRecord, error) {
+	orig, err := ParsePerson(rec, reunion.NewErrorCollector(1))
+	if err != nil {
+		return rec, err
+	}
+	preamble, fields, rest := splitContent(recordContent(rec))
+
+	var given, surname, prefix, suffix, userID, sex = -1, -1, -1, -1, -1, -1
+	if p.GivenName != orig.GivenName {
+		given = lastIndex(fields, TagGivenName)
+	}
+	if p.Surname != orig.Surname {
+		surname = firstIndex(fields, TagSurname1, TagSurname2)
+	}
+	if p.PrefixTitle != orig.PrefixTitle {
+		prefix = lastIndex(fields, TagPrefixTitle)
+	}
+	if p.SuffixTitle != orig.SuffixTitle {
+		suffix = lastIndex(fields, TagSuffixTitle)
+	}
+	if p.UserID != orig.UserID {
+		userID = lastIndex(fields, TagUserID)
+	}
+	if p.Sex != orig.Sex {
+		sex = lastIndex(fields, TagSexFlags)
+	}
+
+	e := &fieldEncoder{out: slices.Clone(preamble)}
+	events, raws := 0, 0
+	for i, f := range fields {
+		switch {
+		case i == given:
+			e.addString(f, p.GivenName)
+		case i == surname:
+			e.addString(f, p.Surname)
+		case i == prefix:
+			e.addString(f, p.PrefixTitle)
+		case i == suffix:
+			e.addString(f, p.SuffixTitle)
+		case i == userID:
+			e.addString(f, p.UserID)
+		case i == sex:
+			e.add(f.Tag, sexFlags(f.Data, p.Sex))
+		case f.Tag == TagGivenName, f.Tag == TagSurname1, f.Tag == TagSurname2,
+			f.Tag == TagPrefixTitle, f.Tag == TagSuffixTitle, f.Tag == TagUserID,
+			f.Tag == TagSexFlags, f.Tag == TagNameSourceCiting:
+			e.add(f.Tag, f.Data)
+		case isEventTag(f.Tag):
+			if events < len(p.Events) {
+				e.addPersonEvent(p.Events[events])
+			}
+			events++
+		default:
+			if raws < len(p.RawFields) {
+				e.add(p.RawFields[raws].Tag, p.RawFields[raws].Data)
+			}
+			raws++
+		}
+	}
+
+	// Values that had no field to rewrite.
+	if given < 0 && p.GivenName != orig.GivenName && p.GivenName != "" {
+		e.add(TagGivenName, []byte(p.GivenName))
+	}
+	if surname < 0 && p.Surname != orig.Surname && p.Surname != "" {
+		e.add(TagSurname2, []byte(p.Surname))
+	}
+	if prefix < 0 && p.PrefixTitle != orig.PrefixTitle && p.PrefixTitle != "" {
+		e.add(TagPrefixTitle, []byte(p.PrefixTitle))
+	}
+	if suffix < 0 && p.SuffixTitle != orig.SuffixTitle && p.SuffixTitle != "" {
+		e.add(TagSuffixTitle, []byte(p.SuffixTitle))
+	}
+	if userID < 0 && p.UserID != orig.UserID && p.UserID != "" {
+		e.add(TagUserID, []byte(p.UserID))
+	}
+	if sex < 0 && p.Sex != orig.Sex {
+		e.add(TagSexFlags, sexFlags(nil, p.Sex))
+	}
+	for ; events < len(p.Events); events++ {
+		e.addPersonEvent(p.Events[events])
+	}
+	for ; raws < len(p.RawFields); raws++ {
+		e.add(p.RawFields[raws].Tag, p.RawFields[raws].Data)
+	}
+
+	if e.err != nil {
+		return rec, fmt.Errorf("encoding person %d: %w", p.ID, e.err)
+	}
+	return withContent(rec, append(e.out, rest...)), nil
+}
+
+// sexFlags returns the sex field data with its first byte set to sex.
+func sexFlags(data []byte, sex model.Sex) []byte {
+	out := slices.Clone(data)
+	if len(out) < 2 {
+		out = append(out, make([]byte, 2-len(out))...)
+	}
+	out[0] = byte(sex)
+	return out
+}
+
+func (e *fieldEncoder) addPersonEvent(ev model.PersonEvent) {
+	e.addEvent(ev.Tag, ev.SchemaID, ev.RawData, ev.Date, eventSubTLVOffset)
+}
+
+func (e *fieldEncoder) addFamilyEvent(ev model.FamilyEvent) {
+	pos := eventSubTLVOffset
+	if ev.Tag == TagMarriage {
+		pos = marriageSubTLVOffset
+	}
+	e.addEvent(ev.Tag, ev.SchemaID, ev.RawData, ev.Date, pos)
+}
+
+func (e *fieldEncoder) addEvent(tag, schemaID uint16, raw []byte, d model.Date, pos int) {
+	if e.err != nil {
+		return
+	}
+	data, err := eventData(tag, schemaID, raw, d, pos)
+	if err != nil {
+		e.err = err
+		return
+	}
+	e.add(tag, data)
+}
+
+// eventData returns an event's field data with its date sub-TLVs at pos
+// re-encoded if d differs from the date in raw. A nil raw starts a new
+// event of the given schema.
+func eventData(tag, schemaID uint16, raw []byte, d model.Date, pos int) ([]byte, error) {
+	if raw == nil {
+		if pos != eventSubTLVOffset {
+			return nil, fmt.Errorf("event 0x%04X has no encoded data", tag)
+		}
+		raw = newEventHeader(schemaID)
+	}
+	old, _ := extractDateAt(raw, pos)
+	if sameDate(old, d) {
+		return raw, nil
+	}
+	if len(raw) < pos {
+		return nil, fmt.Errorf("event 0x%04X too short to hold a date", tag)
+	}
+	enc, err := encodeDate(d)
+	if err != nil {
+		return nil, fmt.Errorf("event 0x%04X: %w", tag, err)
+	}
+
+	n := 0
+	if _, _, ok := decodeDateSubTLV(raw, pos); ok {
+		n = 8
+		if old.End != nil {
+			n = 16
+		}
+	}
+	data := slices.Concat(raw[:pos], enc, raw[pos+n:])
+	// The event sub-header starts with the field length.
+	if len(raw) >= 2 && int(binary.LittleEndian.Uint16(raw)) == len(raw) {
+		binary.LittleEndian.PutUint16(data, uint16(len(data)))
+	}
+	return data, nil
+}
+
+// newEventHeader returns the 18-byte sub-header of an event field with no
+// sub-TLVs: the field length, the value 6 that every event Reunion writes
+// carries at offset 12, and the schema ID. Bytes 2-5 hold a value whose
+// meaning is unknown; it is left zero.
+func newEventHeader(schemaID uint16) []byte {
+	h := make([]byte, eventSubTLVOffset)
+	binary.LittleEndian.PutUint16(h, eventSubTLVOffset)
+	binary.LittleEndian.PutUint32(h[12:], 6)
+	binary.LittleEndian.PutUint16(h[16:], schemaID)
+	return h
+}
+
+// sameDate reports whether two dates, including their end dates, are equal.
+func sameDate(a, b model.Date) bool {
+	if (a.End == nil) != (b.End == nil) || a.End != nil && *a.End != *b.End {
+		return false
+	}
+	a.End, b.End = nil, nil
+	return a == b
+}
+
+// encodeDate encodes d as a date sub-TLV. The zero Date encodes as no
+// sub-TLVs. See ExtractDate for the layout.
+func encodeDate(d model.Date) ([]byte, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	flags, err := dateFlags(d)
+	if err != nil {
+		return nil, err
+	}
+	if d.Year < 1 || d.Year > 9999 {
+		return nil, fmt.Errorf("year %d out of range", d.Year)
+	}
+	month, day := d.Month, d.Day
+	switch d.Precision {
+	case model.PrecisionYear:
+		month, day = 0, 0
+	case model.PrecisionMonth:
+		day = 0
+	}
+	if month < 0 || month > 12 || day < 0 || day > 31 {
+		return nil, fmt.Errorf("invalid date %s", d)
+	}
+
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint16(buf, 8)
+	buf[4] = flags
+	buf[5] = byte(month%4)<<6 | byte(day)
+	binary.LittleEndian.PutUint16(buf[6:], uint16((d.Year+8000)*4+month/4))
+	return buf, nil
+}
+
+// dateFlags returns the precision-flags byte Reunion writes for d: 0x00
+// for exact dates, 0xA0 for "about" a year, and 0x40 or, for a year, 0xE0
+// for "after" (see dateQualifierFlags). Year-only dates store month 0.
+// Other qualifiers and precisions, Julian and dual-dated years, and ranges
+// are refused, since how Reunion encodes them is not known.
+func dateFlags(d model.Date) (byte, error) {
+	switch {
+	case d.Julian:
+		return 0, fmt.Errorf("cannot encode Julian date %s", d)
+	case d.DualYear:
+		return 0, fmt.Errorf("cannot encode dual-dated year %s", d)
+	case d.End != nil:
+		return 0, fmt.Errorf("cannot encode date range %s", d)
+	}
+	yearOnly := d.Precision == model.PrecisionYear
+	switch {
+	case d.Qualifier == model.DateExact:
+		return 0x00, nil
+	case d.Qualifier == model.DateAbout && yearOnly:
+		return 0xA0, nil
+	case d.Qualifier == model.DateAfter && yearOnly:
+		return 0xE0, nil
+	case d.Qualifier == model.DateAfter:
+		return 0x40, nil
+	case d.Qualifier == model.DateAbout:
+		return 0, fmt.Errorf("cannot encode %s: only \"about\" a year is supported", d)
+	}
+	return 0, fmt.Errorf("cannot encode %s dates", d.Qualifier)
+}
+
+// EncodeFamily re-encodes rec's TLV fields from f, which was parsed from
+// rec and possibly modified, and returns the updated record. It follows
+// the rules of EncodePerson: partners and children are rewritten from f,
+// events (including the marriage) and unrecognized fields are matched by
+// position, and an unmodified Family encodes to an identical record.
+//
+// A partner cleared to 0 loses its field, as Reunion leaves out the field
+// of a missing partner. Child fields are rewritten in place when the
+// children change; each keeps the low byte of its original reference.
+// Additional children get the next child tags.
+func EncodeFamily(rec RawRecord, f *model.Family) (RawRecord, error) {
+	orig, err := ParseFamily(rec, reunion.NewErrorCollector(1))
+	if err != nil {
+		return rec, err
+	}
+	preamble, fields, rest := splitContent(recordContent(rec))
+
+	clear1 := f.Partner1 == 0 && orig.Partner1 != 0
+	clear2 := f.Partner2 == 0 && orig.Partner2 != 0
+	partner1, partner2 := -1, -1
+	if f.Partner1 != orig.Partner1 {
+		partner1 = lastIndex(fields, TagPartner1)
+	}
+	if f.Partner2 != orig.Partner2 {
+		partner2 = lastIndex(fields, TagPartner2)
+	}
+	childrenChanged := !slices.Equal(f.Children, orig.Children)
+	childLow := make(map[uint32]byte)
+	lastChild, nextChildTag := -1, uint16(0x00FA)
+	for i, field := range fields {
+		if isChildTag(field.Tag) && len(field.Data) >= 4 {
+			raw := binary.LittleEndian.Uint32(field.Data)
+			childLow[raw>>8] = byte(raw)
+			lastChild = i
+			nextChildTag = max(nextChildTag, field.Tag+1)
+		}
+	}
+
+	e := &fieldEncoder{out: slices.Clone(preamble)}
+	children, events, raws := 0, 0, 0
+	addChildren := func() {
+		for ; children < len(f.Children); children++ {
+			if !isChildTag(nextChildTag) {
+				if e.err == nil {
+					e.err = fmt.Errorf("too many children: %d", len(f.Children))
+				}
+				return
+			}
+			e.add(nextChildTag, childRef(f.Children[children], childLow))
+			nextChildTag++
+		}
+	}
+	for i, field := range fields {
+		switch {
+		case field.Tag == TagPartner1 && clear1, field.Tag == TagPartner2 && clear2:
+			// dropped
+		case i == partner1:
+			e.add(field.Tag, partnerRef(field.Data, f.Partner1))
+		case i == partner2:
+			e.add(field.Tag, partnerRef(field.Data, f.Partner2))
+		case field.Tag == TagPartner1, field.Tag == TagPartner2:
+			e.add(field.Tag, field.Data)
+		case isChildTag(field.Tag):
+			switch {
+			case !childrenChanged || len(field.Data) < 4:
+				e.add(field.Tag, field.Data)
+			case children < len(f.Children):
+				e.add(field.Tag, childRef(f.Children[children], childLow))
+				children++
+			}
+			if i == lastChild && childrenChanged {
+				addChildren()
+			}
+		case field.Tag == TagMarriage, isFamilyEventTag(field.Tag):
+			if events < len(f.Events) {
+				e.addFamilyEvent(f.Events[events])
+			}
+			events++
+		default:
+			if raws < len(f.RawFields) {
+				e.add(f.RawFields[raws].Tag, f.RawFields[raws].Data)
+			}
+			raws++
+		}
+	}
+
+	if partner1 < 0 && f.Partner1 != orig.Partner1 && !clear1 {
+		e.add(TagPartner1, partnerRef(nil, f.Partner1))
+	}
+	if partner2 < 0 && f.Partner2 != orig.Partner2 && !clear2 {
+		e.add(TagPartner2, partnerRef(nil, f.Partner2))
+	}
+	if childrenChanged {
+		addChildren()
+	}
+	for ; events < len(f.Events); events++ {
+		e.addFamilyEvent(f.Events[events])
+	}
+	for ; raws < len(f.RawFields); raws++ {
+		e.add(f.RawFields[raws].Tag, f.RawFields[raws].Data)
+	}
+
+	if e.err != nil {
+		return rec, fmt.Errorf("encoding family %d: %w", f.ID, e.err)
+	}
+	return withContent(rec, append(e.out, rest...)), nil
+}
+
+// partnerRef encodes a partner ID in the width of the original field data:
+// u16 if it was 2 bytes and the ID fits, otherwise u32.
+func partnerRef(data []byte, id uint32) []byte {
+	if len(data) == 2 && id <= 0xFFFF {
+		return binary.LittleEndian.AppendUint16(nil, uint16(id))
+	}
+	out := binary.LittleEndian.AppendUint32(nil, id)
+	if len(data) > 4 {
+		out = append(out, data[4:]...)
+	}
+	return out
+}
+
+// childRef encodes a child reference: the ID shifted left by 8, with the
+// low byte the child's original reference had.
+func childRef(id uint32, low map[uint32]byte) []byte {
+	return binary.LittleEndian.AppendUint32(nil, id<<8|uint32(low[id]))
+}
+
+// Touch returns rec marked as modified at t: the preamble timestamp, in
+// Unix seconds, is set to t and the sequence number, which Reunion
+// appears to increment each time it saves a record, goes up by one.
+func Touch(rec RawRecord, t time.Time) RawRecord {
+	if len(rec.Data) >= 4 {
+		rec.Data = slices.Clone(rec.Data)
+		binary.LittleEndian.PutUint32(rec.Data, uint32(t.Unix()))
+	}
+	rec.SeqNum++
+	return rec
+}
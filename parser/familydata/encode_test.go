@@ -0,0 +1,259 @@
+package familydata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"slices"
+	"testing"
+	"time"
+
+	reunion "github.com/kedoco/reunion-explore"
+	"github.com/kedoco/reunion-explore/model"
+)
+
+// encodeAll re-encodes every person and family record from its parsed
+// model, after passing the model through modify.
+func encodeAll(t *testing.T, records []RawRecord, modify func(p *model.Person, f *model.Family)) []RawRecord {
+	t.Helper()
+	ec := reunion.NewErrorCollector(0)
+	out := slices.Clone(records)
+	for i, rec := range records {
+		var err error
+		switch rec.Type {
+		case RecordTypePerson:
+			p, perr := ParsePerson(rec, ec)
+			if perr != nil {
+				t.Fatal(perr)
+			}
+			modify(p, nil)
+			out[i], err = EncodePerson(rec, p)
+		case RecordTypeFamily:
+			f, ferr := ParseFamily(rec, ec)
+			if ferr != nil {
+				t.Fatal(ferr)
+			}
+			modify(nil, f)
+			out[i], err = EncodeFamily(rec, f)
+		}
+		if err != nil {
+			t.Fatalf("encoding record %d: %v", rec.ID, err)
+		}
+	}
+	return out
+}
+
+func writeRecords(t *testing.T, prefix []byte, records []RawRecord) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Write(&buf, prefix, records); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestEncode_UnmodifiedSampleIsIdentical(t *testing.T) {
+	data := readSample(t)
+	records := ScanRecords(data)
+	encoded := encodeAll(t, records, func(*model.Person, *model.Family) {})
+	if got := writeRecords(t, FilePrefix(data, records), encoded); !bytes.Equal(got, data) {
+		t.Fatal("parse → encode → write is not byte-identical")
+	}
+}
+
+func TestEncodePerson_ModifiedSample(t *testing.T) {
+	data := readSample(t)
+	records := ScanRecords(data)
+	birth := model.Date{Year: 1917, Month: 5, Day: 30, Precision: model.PrecisionDay}
+	encoded := encodeAll(t, records, func(p *model.Person, f *model.Family) {
+		switch {
+		case p != nil && p.ID == 4:
+			p.GivenName = "Jack"
+			p.SuffixTitle = "Jr."
+			for i := range p.Events {
+				if p.Events[i].Date.String() == "29 May 1917" {
+					p.Events[i].Date = birth
+				}
+			}
+		case f != nil && f.ID == 1:
+			f.Events[0].Date = model.Date{Year: 1914, Qualifier: model.DateAbout, Precision: model.PrecisionYear}
+		}
+	})
+	out := writeRecords(t, FilePrefix(data, records), encoded)
+
+	reread := ScanRecords(out)
+	if len(reread) != len(records) {
+		t.Fatalf("rescanned %d records, want %d", len(reread), len(records))
+	}
+	ec := reunion.NewErrorCollector(0)
+	for i, rec := range reread {
+		if rec.Type != records[i].Type || rec.ID != records[i].ID || rec.SeqNum != records[i].SeqNum {
+			t.Fatalf("record %d header changed: %+v", i, rec)
+		}
+		switch {
+		case rec.Type == RecordTypePerson && rec.ID == 4:
+			p, _ := ParsePerson(rec, ec)
+			if p.GivenName != "Jack" || p.Surname != "KENNEDY" || p.SuffixTitle != "Jr." {
+				t.Errorf("person 4 name = %q %q %q", p.GivenName, p.Surname, p.SuffixTitle)
+			}
+			found := false
+			for _, ev := range p.Events {
+				found = found || ev.Date == birth
+			}
+			if !found {
+				t.Error("person 4 lost the modified birth date")
+			}
+		case rec.Type == RecordTypeFamily && rec.ID == 1:
+			f, _ := ParseFamily(rec, ec)
+			if got := f.Events[0].Date.String(); got != "about 1914" {
+				t.Errorf("family 1 marriage = %q, want %q", got, "about 1914")
+			}
+			if f.Events[0].Text == "" || len(f.Events[0].PlaceRefs) == 0 {
+				t.Error("family 1 marriage lost its place or memo")
+			}
+		case (rec.Offset-records[i].Offset)%slotSize != 0:
+			t.Errorf("record %d moved off its 128-byte slot alignment", i)
+		}
+	}
+	if ec.Len() > 0 {
+		t.Errorf("re-parse warnings: %v", ec.Errors())
+	}
+}
+
+func TestEncodeFamily_Children(t *testing.T) {
+	child := func(id uint32, low byte) []byte {
+		return binary.LittleEndian.AppendUint32(nil, id<<8|uint32(low))
+	}
+	content := makePreamble()
+	content = append(content, makeTLVField(TagPartner1, []byte{1, 0})...)
+	content = append(content, makeTLVField(0x00FA, child(3, 0))...)
+	content = append(content, makeTLVField(0x00FB, child(4, 2))...)
+	content = append(content, makeTLVField(0x0038, []byte("unknown"))...)
+	rec := RawRecord{Type: RecordTypeFamily, ID: 9, DataLen: uint32(len(content) - 4), Data: content}
+
+	ec := reunion.NewErrorCollector(0)
+	f, err := ParseFamily(rec, ec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Partner1 = 70000 // no longer fits the 2-byte field
+	f.Partner2 = 2
+	f.Children = []uint32{4, 3, 8}
+
+	enc, err := EncodeFamily(rec, f)
+	if err != nil {
+		t.Fatalf("EncodeFamily() error = %v", err)
+	}
+	got, err := ParseFamily(enc, ec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Partner1 != 70000 || got.Partner2 != 2 {
+		t.Errorf("partners = %d, %d, want 70000, 2", got.Partner1, got.Partner2)
+	}
+	if !slices.Equal(got.Children, f.Children) {
+		t.Errorf("Children = %v, want %v", got.Children, f.Children)
+	}
+	if len(got.RawFields) != 1 || string(got.RawFields[0].Data) != "unknown" {
+		t.Errorf("RawFields = %+v, want the unknown field kept", got.RawFields)
+	}
+	// Child 4 keeps the low byte of its original reference.
+	for _, field := range ParseTLVFields(enc.Data) {
+		if isChildTag(field.Tag) && binary.LittleEndian.Uint32(field.Data)>>8 == 4 && field.Data[0] != 2 {
+			t.Errorf("child 4 reference = % x, want low byte 02", field.Data)
+		}
+	}
+	if int(enc.DataLen)+4 != len(enc.Data) {
+		t.Errorf("DataLen = %d for %d bytes of content", enc.DataLen, len(enc.Data))
+	}
+}
+
+func TestEncodeDate_RoundTrips(t *testing.T) {
+	for _, s := range []string{
+		"29 May 1917",
+		"1917",
+		"Jun 2000",
+		"about 1850",
+		"after 1 Jan 1900",
+		"after 1900",
+	} {
+		d, err := model.ParseDate(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		enc, err := encodeDate(d)
+		if err != nil {
+			t.Errorf("encodeDate(%q) error = %v", s, err)
+			continue
+		}
+		got, err := extractDateAt(eventFieldData(enc), eventSubTLVOffset)
+		if err != nil || !sameDate(got, d) {
+			t.Errorf("encodeDate(%q) decodes to %q (%v)", s, got, err)
+		}
+	}
+}
+
+// TestEncodeDate_MatchesReunion checks encoded dates against the date
+// sub-TLVs of the sample file's events.
+func TestEncodeDate_MatchesReunion(t *testing.T) {
+	tests := []struct {
+		date string
+		want string // sub-TLV as Reunion wrote it
+	}{
+		{"29 May 1917", "08000000005df59a"}, // person 4, birth
+		{"1821", "0800000000007499"},        // person 22, birth
+		{"about 1823", "08000000a0007c99"},  // person 21, birth
+	}
+	for _, tt := range tests {
+		d, err := model.ParseDate(tt.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		enc, err := encodeDate(d)
+		if err != nil {
+			t.Fatalf("encodeDate(%q) error = %v", tt.date, err)
+		}
+		if got := hex.EncodeToString(enc); got != tt.want {
+			t.Errorf("encodeDate(%q) = %s, want %s", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeDate_RefusesUnknownFlags(t *testing.T) {
+	for _, s := range []string{
+		"about 3 Jun 1850",
+		"before Dec 1800",
+		"calculated 1790",
+		"estimated Apr 1760",
+		"between 1850 and Mar 1855",
+		"from 28 Jul 1914 to 11 Nov 1918",
+		"from 1914",
+		"11 Feb 1731/32 (Julian)",
+		"1731/32",
+	} {
+		d, err := model.ParseDate(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if enc, err := encodeDate(d); err == nil {
+			t.Errorf("encodeDate(%q) = % x, want an error", s, enc)
+		}
+	}
+}
+
+func TestTouch(t *testing.T) {
+	created := time.Date(2024, 4, 24, 12, 0, 0, 0, time.UTC)
+	enc := RawRecord{Type: RecordTypePerson, SeqNum: 1, ID: 51, Data: make([]byte, recordPreambleLen)}
+	binary.LittleEndian.PutUint32(enc.Data, uint32(created.Unix()))
+
+	touched := Touch(enc, created.Add(time.Hour))
+	if touched.SeqNum != 2 {
+		t.Errorf("SeqNum = %d, want 2", touched.SeqNum)
+	}
+	if ts := binary.LittleEndian.Uint32(touched.Data); ts != uint32(created.Add(time.Hour).Unix()) {
+		t.Errorf("timestamp = %d", ts)
+	}
+	if binary.LittleEndian.Uint32(enc.Data) != uint32(created.Unix()) {
+		t.Error("Touch modified the original record's data")
+	}
+}
@@ -14,21 +14,31 @@ const (
 	TagMarriage uint16 = 0x005F
 )
 
-// isChildTag returns true if the tag is a child reference (0xFA-0xFF).
+// marriageSubTLVOffset is where sub-TLV fields begin within a marriage
+// (tag 0x005F) field. The marriage sub-header is 12 bytes rather than the
+// 18 bytes used by ordinary event fields, and carries no schema ID.
+const marriageSubTLVOffset = 12
+
+// isChildTag returns true if the tag is a child reference (0xFA-0x18F).
+// Children past the sixth run on into 0x100 and up: the sample's family 1
+// keeps its nine children under 0xFA-0x102. Family events start at 0x190,
+// the lowest event tag in the sample, so the range ends below it.
 func isChildTag(tag uint16) bool {
-	return tag >= 0x00FA && tag <= 0x00FF
+	return tag >= 0x00FA && tag < 0x0190
 }
 
-// isFamilyEventTag returns true if the tag is a family event (>= 0x100).
+// isFamilyEventTag returns true if the tag is a family event (>= 0x190,
+// other than a media field).
 func isFamilyEventTag(tag uint16) bool {
-	return tag >= 0x0100
+	return tag >= 0x0190 && !isMediaTag(tag)
 }
 
 // ParseFamily parses a 0x20C8 family record into a Family model.
 func ParseFamily(rec RawRecord, ec *reunion.ErrorCollector) (*model.Family, error) {
 	f := &model.Family{
-		ID:     rec.ID,
-		SeqNum: rec.SeqNum,
+		ID:       rec.ID,
+		SeqNum:   rec.SeqNum,
+		Modified: rec.Modified(),
 	}
 
 	if len(rec.Data) < 6 {
@@ -55,6 +65,12 @@ func ParseFamily(rec RawRecord, ec *reunion.ErrorCollector) (*model.Family, erro
 				id, _ := binutil.U16LE(field.Data, 0)
 				f.Partner2 = uint32(id)
 			}
+		case field.Tag == TagMarriage:
+			evt, err := parseMarriageField(field.Data)
+			if err != nil {
+				ec.Add("familydata", rec.FieldOffset(field), "marriage date", err)
+			}
+			f.Events = append(f.Events, evt)
 		case isChildTag(field.Tag):
 			if len(field.Data) >= 4 {
 				raw, _ := binutil.U32LE(field.Data, 0)
@@ -65,12 +81,16 @@ func ParseFamily(rec RawRecord, ec *reunion.ErrorCollector) (*model.Family, erro
 				}
 			}
 		case isFamilyEventTag(field.Tag):
+			date, err := extractDateAt(field.Data, eventSubTLVOffset)
+			if err != nil {
+				ec.Add("familydata", rec.FieldOffset(field), "family event date", err)
+			}
 			evt := model.FamilyEvent{
 				Tag:             field.Tag,
 				PlaceRefs:       ExtractPlaceRefs(field.Data),
 				RawData:         field.Data,
 				SchemaID:        ParseEventField(field.Data),
-				Date:            ExtractDate(field.Data),
+				Date:            date,
 				Text:            ExtractEventText(field.Data),
 				SourceCitations: ExtractEventSourceCitations(field.Data),
 			}
@@ -86,3 +106,25 @@ func ParseFamily(rec RawRecord, ec *reunion.ErrorCollector) (*model.Family, erro
 
 	return f, nil
 }
+
+// ParseMarriageField decodes the marriage (tag 0x005F) field of a family
+// record. Its sub-TLVs (date, place, memo, citations) use the same encoding
+// as event sub-TLVs but start at offset 12.
+func ParseMarriageField(fieldData []byte) model.FamilyEvent {
+	evt, _ := parseMarriageField(fieldData)
+	return evt
+}
+
+// parseMarriageField is ParseMarriageField, also returning any problem with
+// the date's precision flags.
+func parseMarriageField(fieldData []byte) (model.FamilyEvent, error) {
+	date, err := extractDateAt(fieldData, marriageSubTLVOffset)
+	return model.FamilyEvent{
+		Tag:             TagMarriage,
+		PlaceRefs:       ExtractPlaceRefs(fieldData),
+		RawData:         fieldData,
+		Date:            date,
+		Text:            extractEventTextAt(fieldData, marriageSubTLVOffset),
+		SourceCitations: extractSourceCitationsAt(fieldData, marriageSubTLVOffset),
+	}, err
+}
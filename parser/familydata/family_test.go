@@ -2,6 +2,8 @@ package familydata
 
 import (
 	"encoding/binary"
+	"os"
+	"slices"
 	"testing"
 
 	reunion "github.com/kedoco/reunion-explore"
@@ -33,9 +35,15 @@ func TestParseFamily(t *testing.T) {
 	binary.LittleEndian.PutUint32(childData2, childID2<<8)
 	recData = append(recData, makeTLVField(0x00FB, childData2)...)
 
-	// Family event (tag 0x0100, >= 0x100)
+	// Seventh child (tag 0x0100): child tags run on past 0x00FF
+	childID3 := uint32(57)
+	childData3 := make([]byte, 4)
+	binary.LittleEndian.PutUint32(childData3, childID3<<8)
+	recData = append(recData, makeTLVField(0x0100, childData3)...)
+
+	// Family event (tag 0x0190, >= 0x190)
 	eventData := make([]byte, 20)
-	recData = append(recData, makeTLVField(0x0100, eventData)...)
+	recData = append(recData, makeTLVField(0x0190, eventData)...)
 
 	rec := RawRecord{
 		Type:   RecordTypeFamily,
@@ -62,8 +70,8 @@ func TestParseFamily(t *testing.T) {
 	if family.Partner2 != 102 {
 		t.Errorf("Partner2 = %d, want 102", family.Partner2)
 	}
-	if len(family.Children) != 2 {
-		t.Fatalf("Children count = %d, want 2", len(family.Children))
+	if len(family.Children) != 3 {
+		t.Fatalf("Children count = %d, want 3", len(family.Children))
 	}
 	if family.Children[0] != 55 {
 		t.Errorf("Children[0] = %d, want 55", family.Children[0])
@@ -71,6 +79,9 @@ func TestParseFamily(t *testing.T) {
 	if family.Children[1] != 56 {
 		t.Errorf("Children[1] = %d, want 56", family.Children[1])
 	}
+	if family.Children[2] != 57 {
+		t.Errorf("Children[2] = %d, want 57", family.Children[2])
+	}
 	if len(family.Events) != 1 {
 		t.Errorf("Events count = %d, want 1", len(family.Events))
 	}
@@ -111,8 +122,41 @@ func TestParseFamily_EmptyData(t *testing.T) {
 	}
 }
 
+// TestParseFamily_SampleChildren checks the child tag range against the
+// sample's family 1, Joseph and Rose Kennedy, whose nine children are
+// stored under tags 0x00FA-0x0102. The sample has no other tags below
+// 0x0190, where every family's first event field sits.
+func TestParseFamily_SampleChildren(t *testing.T) {
+	data, err := os.ReadFile(sampleFamilydata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs := ScanRecords(data)
+	i := slices.IndexFunc(recs, func(r RawRecord) bool {
+		return r.Type == RecordTypeFamily && r.ID == 1
+	})
+	if i < 0 {
+		t.Fatal("family 1 not found")
+	}
+	family, err := ParseFamily(recs[i], reunion.NewErrorCollector(0))
+	if err != nil {
+		t.Fatalf("ParseFamily() error = %v", err)
+	}
+
+	// In field order: Rosemary (person 5) was entered last, under 0x00FC.
+	want := []uint32{3, 4, 6, 7, 9, 10, 11, 12, 5}
+	if !slices.Equal(family.Children, want) {
+		t.Errorf("Children = %v, want %v", family.Children, want)
+	}
+	for _, e := range family.Events {
+		if e.Tag != TagMarriage && e.Tag < 0x0190 {
+			t.Errorf("event with tag 0x%04X, want children below 0x0190", e.Tag)
+		}
+	}
+}
+
 func TestIsChildTag(t *testing.T) {
-	for tag := uint16(0x00FA); tag <= 0x00FF; tag++ {
+	for tag := uint16(0x00FA); tag <= 0x018F; tag++ {
 		if !isChildTag(tag) {
 			t.Errorf("isChildTag(0x%04X) = false, want true", tag)
 		}
@@ -120,7 +164,30 @@ func TestIsChildTag(t *testing.T) {
 	if isChildTag(0x00F9) {
 		t.Error("isChildTag(0x00F9) should be false")
 	}
-	if isChildTag(0x0100) {
-		t.Error("isChildTag(0x0100) should be false")
+	if isChildTag(0x0190) {
+		t.Error("isChildTag(0x0190) should be false")
+	}
+}
+
+func TestParseMarriageField(t *testing.T) {
+	// 12-byte marriage sub-header, then date, place and memo sub-TLVs.
+	data := make([]byte, 12)
+	// 7 Oct 1914: totalQ = (1914+8000)*4 + 2, day byte = (2<<6)|7
+	data = append(data, makeTLVField(0x0000, []byte{0x00, 0x87, 0xEA, 0x9A})...)
+	data = append(data, makeTLVField(0x0000, []byte("[[pt:35]]"))...)
+	data = append(data, makeTLVField(0x0000, []byte("The ceremony took place."))...)
+
+	evt := ParseMarriageField(data)
+	if evt.Tag != TagMarriage {
+		t.Errorf("Tag = 0x%04X, want 0x%04X", evt.Tag, TagMarriage)
+	}
+	if got := evt.Date.String(); got != "7 Oct 1914" {
+		t.Errorf("Date = %q, want %q", got, "7 Oct 1914")
+	}
+	if len(evt.PlaceRefs) != 1 || evt.PlaceRefs[0] != 35 {
+		t.Errorf("PlaceRefs = %v, want [35]", evt.PlaceRefs)
+	}
+	if evt.Text != "The ceremony took place." {
+		t.Errorf("Text = %q, want %q", evt.Text, "The ceremony took place.")
 	}
 }
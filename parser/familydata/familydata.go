@@ -2,8 +2,11 @@
 package familydata
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"os"
+	"io"
+	"io/fs"
 
 	reunion "github.com/kedoco/reunion-explore"
 	"github.com/kedoco/reunion-explore/model"
@@ -19,92 +22,109 @@ type Result struct {
 	Sources          []model.Source
 	Notes            []model.Note
 	MediaRefs        []model.MediaRef
+	ViewStates       []model.ViewState
+	Documents        []model.Document
+	Reports          []model.ReportDefinition
 }
 
-// Parse reads and parses the familydata binary file.
-func Parse(path string, ec *reunion.ErrorCollector) (*Result, error) {
-	data, err := os.ReadFile(path)
+// headerReadLen is how much of the start of the file Parse reads for
+// ParseHeader: the fixed fields and the device strings after them.
+const headerReadLen = 4096
+
+// Parse reads and parses the familydata binary file name in fsys.
+func Parse(fsys fs.FS, name string, ec *reunion.ErrorCollector) (*Result, error) {
+	f, err := fsys.Open(name)
 	if err != nil {
 		return nil, fmt.Errorf("reading familydata: %w", err)
 	}
+	defer f.Close()
+	r, ok := f.(io.ReaderAt)
+	if !ok {
+		// Files in a zip archive cannot be read at an offset.
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return nil, fmt.Errorf("reading familydata: %w", err)
+		}
+		r = bytes.NewReader(data)
+	}
+	return ParseReader(r, ec)
+}
 
-	if len(data) < 16 {
-		return nil, fmt.Errorf("familydata too short: %d bytes", len(data))
+// ParseReader parses the familydata file read from r. Records are read one
+// at a time with Records.
+func ParseReader(r io.ReaderAt, ec *reunion.ErrorCollector) (*Result, error) {
+	head := make([]byte, headerReadLen)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("reading familydata: %w", err)
+	}
+	head = head[:n]
+
+	if len(head) < 16 {
+		return nil, fmt.Errorf("familydata too short: %d bytes", len(head))
 	}
 
 	result := &Result{}
 
 	// Parse header
-	header, err := ParseHeader(data)
+	header, err := ParseHeader(head)
 	if err != nil {
 		ec.Add("familydata", 0, "header parse error", err)
 	} else {
 		result.Header = header
 	}
 
-	// Scan all records
-	records := ScanRecords(data)
-
 	// Process each record type
-	for _, rec := range records {
+	for rec, err := range Records(r) {
+		if err != nil {
+			return nil, fmt.Errorf("reading familydata: %w", err)
+		}
+		var perr error
 		switch rec.Type {
 		case RecordTypePerson:
-			person, err := ParsePerson(rec, ec)
-			if err != nil {
-				ec.Add("familydata", rec.Offset, "person parse error", err)
-				continue
-			}
-			result.Persons = append(result.Persons, *person)
-
+			perr = parseInto(rec, ec, ParsePerson, &result.Persons)
+			perr = errors.Join(perr, parseMediaInto(rec, ec, &result.MediaRefs))
 		case RecordTypeFamily:
-			family, err := ParseFamily(rec, ec)
-			if err != nil {
-				ec.Add("familydata", rec.Offset, "family parse error", err)
-				continue
-			}
-			result.Families = append(result.Families, *family)
-
+			perr = parseInto(rec, ec, ParseFamily, &result.Families)
+			perr = errors.Join(perr, parseMediaInto(rec, ec, &result.MediaRefs))
 		case RecordTypeSchema:
-			def, err := ParseSchema(rec, ec)
-			if err != nil {
-				ec.Add("familydata", rec.Offset, "schema parse error", err)
-				continue
-			}
-			result.EventDefinitions = append(result.EventDefinitions, *def)
-
+			perr = parseInto(rec, ec, ParseSchema, &result.EventDefinitions)
 		case RecordTypePlace:
-			place, err := ParsePlace(rec, ec)
-			if err != nil {
-				ec.Add("familydata", rec.Offset, "place parse error", err)
-				continue
-			}
-			result.Places = append(result.Places, *place)
-
+			perr = parseInto(rec, ec, ParsePlace, &result.Places)
 		case RecordTypeNote:
-			note, err := ParseNote(rec, ec)
-			if err != nil {
-				ec.Add("familydata", rec.Offset, "note parse error", err)
-				continue
-			}
-			result.Notes = append(result.Notes, *note)
-
+			perr = parseInto(rec, ec, ParseNote, &result.Notes)
 		case RecordTypeSource:
-			source, err := ParseSource(rec, ec)
-			if err != nil {
-				ec.Add("familydata", rec.Offset, "source parse error", err)
-				continue
-			}
-			result.Sources = append(result.Sources, *source)
-
-		case RecordTypeMedia:
-			media, err := ParseMedia(rec, ec)
-			if err != nil {
-				ec.Add("familydata", rec.Offset, "media parse error", err)
-				continue
-			}
-			result.MediaRefs = append(result.MediaRefs, *media)
+			perr = parseInto(rec, ec, ParseSource, &result.Sources)
+		case RecordTypeViewState:
+			perr = parseInto(rec, ec, ParseViewState, &result.ViewStates)
+		case RecordTypeDoc:
+			perr = parseInto(rec, ec, ParseDoc, &result.Documents)
+		case RecordTypeReport:
+			perr = parseInto(rec, ec, ParseReport, &result.Reports)
+		}
+		if perr != nil {
+			ec.Add("familydata", rec.Offset, recordParseErrors[rec.Type], perr)
 		}
 	}
+	nameFromLists(result.Documents, result.Reports)
+	labelSources(result.Sources, result.EventDefinitions)
 
 	return result, nil
 }
+
+// parseInto parses rec and appends the result to list.
+func parseInto[T any](rec RawRecord, ec *reunion.ErrorCollector, parse func(RawRecord, *reunion.ErrorCollector) (*T, error), list *[]T) error {
+	v, err := parse(rec, ec)
+	if err != nil {
+		return err
+	}
+	*list = append(*list, *v)
+	return nil
+}
+
+// parseMediaInto appends the media of a person or family record to list.
+func parseMediaInto(rec RawRecord, ec *reunion.ErrorCollector, list *[]model.MediaRef) error {
+	media, err := ParseMedia(rec, ec)
+	*list = append(*list, media...)
+	return err
+}
@@ -111,6 +111,10 @@ func ExtractPlaceRefs(data []byte) []int {
 	return refs
 }
 
+// eventSubTLVOffset is where sub-TLV fields begin within event field data,
+// after the 18-byte event sub-header.
+const eventSubTLVOffset = 18
+
 // ExtractDate decodes a date from event sub-data.
 // Event data has an 18-byte fixed header, followed by sub-TLV fields.
 // The first sub-TLV (at offset 18) contains the date when its total length == 8.
@@ -124,28 +128,77 @@ func ExtractPlaceRefs(data []byte) []int {
 //
 //	bits 7-6: month offset within the group (0-3)
 //	bits 5-0: day of month (0 = unknown)
-//	month = group*4 + offset  (1-12)
+//	month = group*4 + offset  (1-12, 0 = year only)
 //
-// Precision flags are in byte[22]:
+// Precision flags are in byte[22]; only the values in dateQualifierFlags
+// are known. Exact year-only dates store flags 0x00 and month 0.
 //
-//	0x00 = exact date
-//	0xA0 = approximate ("about"), year-only
-//	0x40 = "after"
-//	0xE0 = "after", year-only
-func ExtractDate(fieldData []byte) string {
-	if len(fieldData) < 26 {
-		return ""
+// Flag values that are not understood are ignored here; ParsePerson and
+// ParseFamily report them through the ErrorCollector.
+func ExtractDate(fieldData []byte) model.Date {
+	d, _ := extractDateAt(fieldData, eventSubTLVOffset)
+	return d
+}
+
+// dateFlagQualifier is the meaning of a precision-flags byte.
+type dateFlagQualifier struct {
+	qualifier model.DateQualifier
+	yearOnly  bool // the month in the encoding is a meaningless default
+}
+
+// dateQualifierFlags maps known precision-flags bytes to a qualifier. The
+// values are whole codes rather than combinations of bits: 0x00 and 0xA0
+// occur in the sample file (exact dates, and "about 1823"), and 0x40 and
+// 0xE0 are the "after" forms from the original format notes. Qualifiers
+// such as "before", "between" and "estimated", and Julian or dual-dated
+// years, have not been seen, so their flags are unknown.
+var dateQualifierFlags = map[byte]dateFlagQualifier{
+	0x00: {qualifier: model.DateExact},
+	0x40: {qualifier: model.DateAfter},
+	0xA0: {qualifier: model.DateAbout, yearOnly: true},
+	0xE0: {qualifier: model.DateAfter, yearOnly: true},
+}
+
+// extractDateAt decodes a date sub-TLV located at pos within fieldData.
+// See ExtractDate for the byte layout; offsets there are relative to pos=18.
+// A date is returned whenever the year and month are plausible; the error
+// reports a precision-flags value that is not known, in which case the
+// date is decoded as exact.
+func extractDateAt(fieldData []byte, pos int) (model.Date, error) {
+	d, flags, ok := decodeDateSubTLV(fieldData, pos)
+	if !ok {
+		return model.Date{}, nil
+	}
+
+	q, known := dateQualifierFlags[flags]
+	if !known {
+		return d, fmt.Errorf("unknown date precision flags 0x%02X", flags)
+	}
+	d.Qualifier = q.qualifier
+	if q.yearOnly {
+		d.Month, d.Day = 0, 0
+		d.Precision = model.PrecisionYear
+	}
+	return d, nil
+}
+
+// decodeDateSubTLV decodes the calendar part of the 8-byte date sub-TLV at
+// pos and returns it with the raw precision flags. ok is false if there is
+// no plausible date there.
+func decodeDateSubTLV(fieldData []byte, pos int) (d model.Date, flags byte, ok bool) {
+	if len(fieldData) < pos+8 {
+		return model.Date{}, 0, false
 	}
-	// Sub-TLV at offset 18: date sub-TLV has exactly length 8 (4-byte header + 4-byte date)
-	subLen, err := binutil.U16LE(fieldData, 18)
+	// Date sub-TLV has exactly length 8 (4-byte header + 4-byte date)
+	subLen, err := binutil.U16LE(fieldData, pos)
 	if err != nil || subLen != 8 {
-		return ""
+		return model.Date{}, 0, false
 	}
 
-	precFlags := fieldData[22]
-	dayByte := fieldData[23]
-	monthYearLo := fieldData[24]
-	monthYearHi := fieldData[25]
+	flags = fieldData[pos+4]
+	dayByte := fieldData[pos+5]
+	monthYearLo := fieldData[pos+6]
+	monthYearHi := fieldData[pos+7]
 
 	totalQ := int(monthYearHi)<<8 | int(monthYearLo)
 	year := totalQ/4 - 8000
@@ -153,41 +206,29 @@ func ExtractDate(fieldData []byte) string {
 	// Month is split across two locations:
 	//   - bits 1-0 of totalQ give the high 2 bits (group 0-3)
 	//   - bits 7-6 of dayByte give the low 2 bits (offset 0-3 within group)
-	// Combined: month = group*4 + offset, yielding 1-12.
+	// Combined: month = group*4 + offset, yielding 1-12, or 0 when only
+	// the year is known.
 	group := totalQ % 4
 	month := group*4 + int(dayByte>>6)
 
 	day := int(dayByte & 0x3F)
 
-	if year < 1 || year > 9999 || month < 1 || month > 12 {
-		return ""
+	if year < 1 || year > 9999 || month > 12 {
+		return model.Date{}, 0, false
 	}
 
-	monthNames := [13]string{"", "Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
-
-	// Precision flags encode qualifier and precision:
-	//   bit 6 (0x40): "after" qualifier
-	//   bits 7+5 (0xA0): year-only precision (month is a meaningless default)
-	//   0x40 = "after" with month precision
-	//   0xA0 = "about" with year-only precision
-	//   0xE0 = "after" with year-only precision
-	yearOnly := precFlags&0xA0 == 0xA0
-
-	prefix := ""
+	d.Year = year
 	switch {
-	case precFlags&0x40 != 0:
-		prefix = "after "
-	case yearOnly:
-		prefix = "about "
-	}
-
-	if yearOnly {
-		return fmt.Sprintf("%s%d", prefix, year)
+	case month == 0:
+		d.Precision = model.PrecisionYear
+	case day > 0 && day <= 31:
+		d.Month, d.Day = month, day
+		d.Precision = model.PrecisionDay
+	default:
+		d.Month = month
+		d.Precision = model.PrecisionMonth
 	}
-	if day > 0 && day <= 31 {
-		return fmt.Sprintf("%s%d %s %d", prefix, day, monthNames[month], year)
-	}
-	return fmt.Sprintf("%s%s %d", prefix, monthNames[month], year)
+	return d, flags, true
 }
 
 // ExtractNoteRef extracts a note record ID from event sub-data.
@@ -195,7 +236,7 @@ func ExtractDate(fieldData []byte) string {
 // with tag 0x0000 whose 4-byte data is the referenced note's record ID.
 // Returns 0 if no note reference is found.
 func ExtractNoteRef(fieldData []byte) uint32 {
-	pos := 18
+	pos := eventSubTLVOffset
 	for pos+4 <= len(fieldData) {
 		subLen, err := binutil.U16LE(fieldData, pos)
 		if err != nil || subLen < 4 {
@@ -274,7 +315,12 @@ func ExtractSourceCitations(data []byte) []model.SourceCitation {
 // ExtractEventSourceCitations walks the sub-TLVs at offset 18 in event data
 // and passes the last sub-TLV's data to ExtractSourceCitations.
 func ExtractEventSourceCitations(fieldData []byte) []model.SourceCitation {
-	pos := 18
+	return extractSourceCitationsAt(fieldData, eventSubTLVOffset)
+}
+
+// extractSourceCitationsAt walks sub-TLVs starting at pos and decodes the
+// last one as a citation block.
+func extractSourceCitationsAt(fieldData []byte, pos int) []model.SourceCitation {
 	lastData := []byte(nil)
 	for pos+4 <= len(fieldData) {
 		subLen, err := binutil.U16LE(fieldData, pos)
@@ -297,7 +343,11 @@ func ExtractEventSourceCitations(fieldData []byte) []model.SourceCitation {
 // the first text sub-TLV (tag 0x0000, length > 8, contains printable text).
 // It strips [[pt:NNN]] place-ref markers from the result.
 func ExtractEventText(fieldData []byte) string {
-	pos := 18
+	return extractEventTextAt(fieldData, eventSubTLVOffset)
+}
+
+// extractEventTextAt returns the first memo sub-TLV at or after pos.
+func extractEventTextAt(fieldData []byte, pos int) string {
 	for pos+4 <= len(fieldData) {
 		subLen, err := binutil.U16LE(fieldData, pos)
 		if err != nil || subLen < 4 {
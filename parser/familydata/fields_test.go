@@ -2,7 +2,10 @@ package familydata
 
 import (
 	"encoding/binary"
+	"encoding/hex"
 	"testing"
+
+	"github.com/kedoco/reunion-explore/model"
 )
 
 // putU16LE writes a little-endian uint16 into buf at the given offset.
@@ -203,7 +206,7 @@ func TestExtractDate(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := ExtractDate(tt.data)
+			got := ExtractDate(tt.data).String()
 			if got != tt.want {
 				t.Errorf("ExtractDate() = %q, want %q", got, tt.want)
 			}
@@ -211,6 +214,149 @@ func TestExtractDate(t *testing.T) {
 	}
 }
 
+func TestExtractDate_Structured(t *testing.T) {
+	// about 1850: year-only precision; the month in the encoding is a default.
+	buf := make([]byte, 26)
+	putU16LE(buf, 18, 8)
+	buf[22] = 0xA0
+	buf[23] = 1 << 6
+	putU16LE(buf, 24, uint16((1850+8000)*4))
+
+	got := ExtractDate(buf)
+	want := model.Date{Year: 1850, Qualifier: model.DateAbout, Precision: model.PrecisionYear}
+	if got != want {
+		t.Errorf("ExtractDate() = %+v, want %+v", got, want)
+	}
+}
+
+// dateSubTLV encodes an 8-byte date sub-TLV; month 0 means year only.
+func dateSubTLV(flags byte, day, month, year int) []byte {
+	buf := make([]byte, 8)
+	putU16LE(buf, 0, 8)
+	buf[4] = flags
+	buf[5] = byte(month%4<<6) | byte(day&0x3F)
+	putU16LE(buf, 6, uint16((year+8000)*4+month/4))
+	return buf
+}
+
+// eventFieldData builds event field data: the 18-byte event sub-header
+// followed by the given sub-TLVs.
+func eventFieldData(subTLVs ...[]byte) []byte {
+	data := make([]byte, eventSubTLVOffset)
+	for _, sub := range subTLVs {
+		data = append(data, sub...)
+	}
+	return data
+}
+
+func TestExtractDate_Flags(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want model.Date
+	}{
+		{"exact", eventFieldData(dateSubTLV(0x00, 29, 5, 1917)),
+			model.Date{Year: 1917, Month: 5, Day: 29, Precision: model.PrecisionDay}},
+		{"exact month", eventFieldData(dateSubTLV(0x00, 0, 11, 1917)),
+			model.Date{Year: 1917, Month: 11, Precision: model.PrecisionMonth}},
+		{"exact year", eventFieldData(dateSubTLV(0x00, 0, 0, 1821)),
+			model.Date{Year: 1821, Precision: model.PrecisionYear}},
+		{"after", eventFieldData(dateSubTLV(0x40, 1, 1, 1900)),
+			model.Date{Year: 1900, Month: 1, Day: 1, Qualifier: model.DateAfter, Precision: model.PrecisionDay}},
+		{"about year", eventFieldData(dateSubTLV(0xA0, 0, 0, 1823)),
+			model.Date{Year: 1823, Qualifier: model.DateAbout, Precision: model.PrecisionYear}},
+		{"about year, month default", eventFieldData(dateSubTLV(0xA0, 0, 1, 1850)),
+			model.Date{Year: 1850, Qualifier: model.DateAbout, Precision: model.PrecisionYear}},
+		{"after year, month default", eventFieldData(dateSubTLV(0xE0, 0, 1, 1900)),
+			model.Date{Year: 1900, Qualifier: model.DateAfter, Precision: model.PrecisionYear}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractDateAt(tt.data, eventSubTLVOffset)
+			if err != nil {
+				t.Errorf("extractDateAt() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("extractDateAt() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Event fields from the sample file, as Reunion wrote them.
+const (
+	// Person 4, birth: 29 May 1917, exact, with a place and memo.
+	sampleBirthPerson4 = "f10060a30100000000000000060000000a0008000000005df59a0d0000005b5b70743a33345d5d" +
+		"b300000048652077617320626f726e20617420333a303020504d20696e20746865206d617374657220626564726f6f6d" +
+		"206f66207468652066616d696c7920686f6d65206174203833204265616c73205374726565742e204e616d6564206166" +
+		"74657220686973206772616e646661746865722c204a6f686e20e2809c486f6e6579204669747a2ce2809d204669747a" +
+		"676572616c642c2074686520666f726d6572206d61796f72206f6620426f73746f6e2e1700000013000000010000000b" +
+		"0062ad06000000323734"
+	// Person 22, birth: 1821, exact and year only (flags 0x00, month 0).
+	sampleBirthPerson22 = "260040a40100000000000000060000000a0008000000000074990c0000005b5b70743a365d5d"
+	// Person 21, birth: about 1823 (flags 0xA0, month 0), with a citation.
+	sampleBirthPerson21 = "440040a40100000000000000060000000a0008000000a0007c990c0000005b5b70743a365d5d" +
+		"040000001a00000016000000010000000e0016ac01000000706167652034"
+	// Family 13, marriage: about 1887, in the marriage field layout.
+	sampleMarriageFamily13 = "4800a0a5010000000e00000008000000a0007c9a0d0000005b5b70743a33355d5d0400000023" +
+		"0000001f000000020000000f0082af0600000070616765203136080069a803000000"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestExtractDate_Sample(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		pos   int
+		want  model.Date
+	}{
+		{"exact day", sampleBirthPerson4, eventSubTLVOffset,
+			model.Date{Year: 1917, Month: 5, Day: 29, Precision: model.PrecisionDay}},
+		{"exact year", sampleBirthPerson22, eventSubTLVOffset,
+			model.Date{Year: 1821, Precision: model.PrecisionYear}},
+		{"about year", sampleBirthPerson21, eventSubTLVOffset,
+			model.Date{Year: 1823, Qualifier: model.DateAbout, Precision: model.PrecisionYear}},
+		{"marriage about year", sampleMarriageFamily13, marriageSubTLVOffset,
+			model.Date{Year: 1887, Qualifier: model.DateAbout, Precision: model.PrecisionYear}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractDateAt(mustHex(t, tt.field), tt.pos)
+			if err != nil {
+				t.Errorf("extractDateAt() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("extractDateAt() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractDate_UnknownFlags(t *testing.T) {
+	// The person 4 birth with its flags byte (offset 22) replaced. Only
+	// the flags in dateQualifierFlags are known; the date is still decoded,
+	// as exact, and the flags are reported.
+	for _, flags := range []byte{0x01, 0x02, 0x10, 0x20, 0x30, 0x44, 0x50, 0x60, 0x70, 0x80, 0xF8} {
+		data := mustHex(t, sampleBirthPerson4)
+		data[22] = flags
+		got, err := extractDateAt(data, eventSubTLVOffset)
+		if err == nil {
+			t.Errorf("flags 0x%02X: no error", flags)
+		}
+		if got.String() != "29 May 1917" {
+			t.Errorf("flags 0x%02X: date = %q, want %q", flags, got, "29 May 1917")
+		}
+	}
+}
+
 func TestExtractNoteRef(t *testing.T) {
 	// Build event sub-data with a note reference sub-TLV at offset 18
 	// Sub-TLV: [totalLen=8 u16LE][tag=0x0000 u16LE][noteID u32LE]
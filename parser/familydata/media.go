@@ -1,30 +1,225 @@
 package familydata
 
 import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"mime"
+	"path"
+	"strings"
+
 	reunion "github.com/kedoco/reunion-explore"
 	"github.com/kedoco/reunion-explore/model"
 )
 
-// ParseMedia parses a 0x20D4 media metadata record from familydata.
-func ParseMedia(rec RawRecord, ec *reunion.ErrorCollector) (*model.MediaRef, error) {
-	m := &model.MediaRef{
-		ID:     rec.ID,
-		SeqNum: rec.SeqNum,
+// Media fields of person and family records: one per attached file, tagged
+// from TagMediaFirst in the order the files were added.
+const (
+	TagMediaFirst uint16 = 0x0258
+	TagMediaLast  uint16 = 0x02BB
+)
+
+// TagFrame is the field of a view state record holding a window frame.
+const TagFrame uint16 = 0x0014
+
+// mediaHeaderLen is the size of the fixed part of a media field; the
+// caption, file and comment follow it.
+const mediaHeaderLen = 80
+
+// isMediaTag returns true if the tag is a media field of a person or
+// family record.
+func isMediaTag(tag uint16) bool {
+	return tag >= TagMediaFirst && tag <= TagMediaLast
+}
+
+// ParseMedia decodes the media fields of a 0x20C4 person or 0x20C8 family
+// record, linking each to the record. Other record types have none.
+func ParseMedia(rec RawRecord, ec *reunion.ErrorCollector) ([]model.MediaRef, error) {
+	if rec.Type != RecordTypePerson && rec.Type != RecordTypeFamily {
+		return nil, nil
+	}
+	var media []model.MediaRef
+	for _, f := range ParseTLVFields(rec.Data) {
+		if !isMediaTag(f.Tag) {
+			continue
+		}
+		m, err := ParseMediaField(f.Data)
+		if err != nil {
+			ec.Add("familydata", rec.FieldOffset(f), "media field", err)
+			if m.RawData == nil {
+				continue
+			}
+		}
+		m.Tag = f.Tag
+		if rec.Type == RecordTypePerson {
+			m.PersonID = rec.ID
+		} else {
+			m.FamilyID = rec.ID
+		}
+		media = append(media, m)
 	}
+	return media, nil
+}
 
-	if len(rec.Data) < 6 {
-		return m, nil
+// ParseMediaField decodes the data of a media field. A bookmark that
+// cannot be read is reported along with the rest of the field:
+//
+//	Offset  Size  Description
+//	0       2     data length (u16LE)
+//	2       4     packed lengths (u32LE): caption at bits 5-13, file at
+//	              bits 20-31
+//	8       4     comment length at bits 14-29 (u32LE)
+//	32      4     key (u32LE)
+//	52      24    crop left, top, right, bottom and image width, height
+//	              (float32BE)
+//	80      N     caption, file, comment
+//
+// The file is a filename, or a macOS bookmark ("book") for files linked
+// from elsewhere on the Mac. Other bits of the header are not understood.
+func ParseMediaField(data []byte) (model.MediaRef, error) {
+	if len(data) < mediaHeaderLen {
+		return model.MediaRef{}, fmt.Errorf("media field too short: %d bytes", len(data))
+	}
+	lengths := binary.LittleEndian.Uint32(data[2:])
+	captionLen := int(lengths >> 5 & 0x1FF)
+	fileLen := int(lengths >> 20)
+	commentLen := int(binary.LittleEndian.Uint32(data[8:]) >> 14 & 0xFFFF)
+	if end := mediaHeaderLen + captionLen + fileLen + commentLen; end > len(data) {
+		return model.MediaRef{}, fmt.Errorf("media strings run to %d, past the %d-byte field", end, len(data))
+	}
+
+	m := model.MediaRef{
+		Key:     binary.LittleEndian.Uint32(data[32:]),
+		RawData: data,
+	}
+	var floats [6]float32
+	for i := range floats {
+		floats[i] = math.Float32frombits(binary.BigEndian.Uint32(data[52+4*i:]))
 	}
+	copy(m.Crop[:], floats[:4])
+	m.Width, m.Height = int(floats[4]), int(floats[5])
 
-	fields := ParseTLVFields(rec.Data)
+	pos := mediaHeaderLen
+	m.Caption = string(data[pos : pos+captionLen])
+	pos += captionLen
+	file := data[pos : pos+fileLen]
+	pos += fileLen
+	m.Comment = strings.TrimSpace(string(data[pos : pos+commentLen]))
 
-	for _, f := range fields {
-		m.RawFields = append(m.RawFields, model.RawField{
+	if bytes.HasPrefix(file, bookmarkMagic) {
+		p, err := bookmarkPath(file)
+		if err != nil {
+			return m, fmt.Errorf("media bookmark: %w", err)
+		}
+		m.Path = p
+		m.Filename = path.Base(p)
+	} else {
+		m.Filename = string(file)
+	}
+	if t := mime.TypeByExtension(strings.ToLower(path.Ext(m.Filename))); t != "" {
+		m.Type, _, _ = strings.Cut(t, ";")
+	}
+	return m, nil
+}
+
+// bookmarkMagic starts a macOS bookmark (NSURL bookmark data).
+var bookmarkMagic = []byte("book")
+
+// Bookmark item types and table-of-contents keys used by bookmarkPath.
+const (
+	bookmarkTypeString  = 0x0101
+	bookmarkTypeArray   = 0x0601
+	bookmarkKeyPath     = 0x1004
+	bookmarkTOCMagic    = 0xFFFFFFFE
+	bookmarkMinHeader   = 16
+	bookmarkTOCEntryLen = 12
+)
+
+// bookmarkPath returns the POSIX path of the file a macOS bookmark points
+// to. The bookmark's header gives the offset of its data; the data starts
+// with the offset of a table of contents, whose entries map keys to items,
+// all relative to the data. Each item is a u32LE length, a u32LE type and
+// the value. The path is an array item (key 0x1004) of string items, one
+// per path component.
+func bookmarkPath(b []byte) (string, error) {
+	if len(b) < bookmarkMinHeader {
+		return "", fmt.Errorf("too short: %d bytes", len(b))
+	}
+	base := int(binary.LittleEndian.Uint32(b[12:]))
+	u32 := func(off int) (int, bool) {
+		if off < 0 || base+off+4 > len(b) {
+			return 0, false
+		}
+		return int(binary.LittleEndian.Uint32(b[base+off:])), true
+	}
+	item := func(off int) (typ int, val []byte, ok bool) {
+		n, ok1 := u32(off)
+		t, ok2 := u32(off + 4)
+		start := base + off + 8
+		if !ok1 || !ok2 || n < 0 || start+n > len(b) {
+			return 0, nil, false
+		}
+		return t, b[start : start+n], true
+	}
+
+	toc, ok := u32(0)
+	if !ok {
+		return "", fmt.Errorf("no table of contents")
+	}
+	if magic, _ := u32(toc + 4); uint32(magic) != bookmarkTOCMagic {
+		return "", fmt.Errorf("bad table of contents at %d", toc)
+	}
+	count, _ := u32(toc + 16)
+	for i := range count {
+		entry := toc + 20 + i*bookmarkTOCEntryLen
+		key, ok := u32(entry)
+		if !ok {
+			break
+		}
+		if key != bookmarkKeyPath {
+			continue
+		}
+		off, _ := u32(entry + 4)
+		typ, arr, ok := item(off)
+		if !ok || typ != bookmarkTypeArray {
+			return "", fmt.Errorf("bad path item at %d", off)
+		}
+		var parts []string
+		for j := 0; j+4 <= len(arr); j += 4 {
+			typ, s, ok := item(int(binary.LittleEndian.Uint32(arr[j:])))
+			if !ok || typ != bookmarkTypeString {
+				return "", fmt.Errorf("bad path component %d", j/4)
+			}
+			parts = append(parts, string(s))
+		}
+		return "/" + strings.Join(parts, "/"), nil
+	}
+	return "", fmt.Errorf("no path")
+}
+
+// ParseViewState parses a 0x20D4 record from familydata, which holds the
+// saved state of a window or view. Window frames are decoded; other fields
+// are kept raw.
+func ParseViewState(rec RawRecord, ec *reunion.ErrorCollector) (*model.ViewState, error) {
+	v := &model.ViewState{
+		ID:     rec.ID,
+		SeqNum: rec.SeqNum,
+	}
+
+	for _, f := range ParseTLVFields(rec.Data) {
+		if f.Tag == TagFrame {
+			if s := cleanString(f.Data); s != "" {
+				v.Frames = append(v.Frames, s)
+			}
+			continue
+		}
+		v.RawFields = append(v.RawFields, model.RawField{
 			Tag:  f.Tag,
 			Data: f.Data,
 			Size: uint16(len(f.Data) + 4),
 		})
 	}
 
-	return m, nil
+	return v, nil
 }
@@ -0,0 +1,127 @@
+package familydata
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	reunion "github.com/kedoco/reunion-explore"
+)
+
+// makeMediaField builds the data of a media field holding caption, file
+// and comment.
+func makeMediaField(key uint32, caption, file, comment string) []byte {
+	data := make([]byte, mediaHeaderLen)
+	data = append(data, caption...)
+	data = append(data, file...)
+	data = append(data, comment...)
+	binary.LittleEndian.PutUint16(data[0:], uint16(len(data)))
+	binary.LittleEndian.PutUint32(data[2:], uint32(len(file))<<20|uint32(len(caption))<<5|8)
+	binary.LittleEndian.PutUint32(data[8:], uint32(len(comment))<<14)
+	binary.LittleEndian.PutUint32(data[32:], key)
+	for i, f := range []float32{10, 20, 110, 220, 300, 400} {
+		binary.BigEndian.PutUint32(data[52+4*i:], math.Float32bits(f))
+	}
+	return data
+}
+
+func TestParseMediaField(t *testing.T) {
+	m, err := ParseMediaField(makeMediaField(0x6abf1, "jfk 1947", "jfk 1947.JPG", "From the library. "))
+	if err != nil {
+		t.Fatalf("ParseMediaField() error = %v", err)
+	}
+	if m.Caption != "jfk 1947" || m.Filename != "jfk 1947.JPG" || m.Comment != "From the library." {
+		t.Errorf("strings = %q, %q, %q", m.Caption, m.Filename, m.Comment)
+	}
+	if m.Type != "image/jpeg" {
+		t.Errorf("Type = %q, want image/jpeg", m.Type)
+	}
+	if m.Key != 0x6abf1 {
+		t.Errorf("Key = %x, want 6abf1", m.Key)
+	}
+	if m.Width != 300 || m.Height != 400 || m.Crop != [4]float32{10, 20, 110, 220} {
+		t.Errorf("geometry = %dx%d crop %v", m.Width, m.Height, m.Crop)
+	}
+}
+
+func TestParseMediaField_Truncated(t *testing.T) {
+	data := makeMediaField(1, "caption", "file.jpg", "")
+	if _, err := ParseMediaField(data[:len(data)-1]); err == nil {
+		t.Error("ParseMediaField() of a truncated field succeeded")
+	}
+	if _, err := ParseMediaField(data[:mediaHeaderLen-1]); err == nil {
+		t.Error("ParseMediaField() of a short field succeeded")
+	}
+}
+
+func TestParseMedia_LinksOwner(t *testing.T) {
+	recData := makePreamble()
+	recData = append(recData, makeTLVField(TagMediaFirst, makeMediaField(1, "a", "a.png", ""))...)
+	recData = append(recData, makeTLVField(0x03E8, make([]byte, 20))...)
+	recData = append(recData, makeTLVField(TagMediaFirst+1, makeMediaField(2, "b", "b.gif", ""))...)
+
+	ec := reunion.NewErrorCollector(0)
+	media, err := ParseMedia(RawRecord{Type: RecordTypeFamily, ID: 7, Data: recData}, ec)
+	if err != nil {
+		t.Fatalf("ParseMedia() error = %v", err)
+	}
+	if len(media) != 2 {
+		t.Fatalf("got %d media, want 2", len(media))
+	}
+	for i, m := range media {
+		if m.FamilyID != 7 || m.PersonID != 0 || m.Tag != TagMediaFirst+uint16(i) {
+			t.Errorf("media[%d] = family %d person %d tag %04X", i, m.FamilyID, m.PersonID, m.Tag)
+		}
+	}
+
+	// Media fields are not family events.
+	f, _ := ParseFamily(RawRecord{Type: RecordTypeFamily, ID: 7, Data: recData}, ec)
+	if len(f.Events) != 1 || f.Events[0].Tag != 0x03E8 {
+		t.Errorf("family events = %+v, want only 0x03E8", f.Events)
+	}
+}
+
+func TestParseMedia_SampleBookmarks(t *testing.T) {
+	ec := reunion.NewErrorCollector(0)
+	var paths []string
+	count := 0
+	for _, rec := range ScanRecords(readSample(t)) {
+		media, _ := ParseMedia(rec, ec)
+		count += len(media)
+		for _, m := range media {
+			if m.Path != "" {
+				paths = append(paths, m.Path)
+			}
+		}
+	}
+	if count != 38 {
+		t.Errorf("sample has %d media, want 38", count)
+	}
+	want := []string{
+		"/Users/kevin/Pictures/Reunion Pictures/Sample Pictures/kennedy, joseph p banker.jpg",
+		"/Users/gregg/Pictures/Reunion Pictures/Sample Pictures/kennedy, joseph p 1907.jpg",
+	}
+	if len(paths) != len(want) || paths[0] != want[0] || paths[1] != want[1] {
+		t.Errorf("bookmark paths = %q, want %q", paths, want)
+	}
+	if errs := ec.Errors(); len(errs) > 0 {
+		t.Errorf("errors = %v", errs)
+	}
+}
+
+func TestParseViewState(t *testing.T) {
+	recData := makePreamble()
+	recData = append(recData, makeTLVField(0x000A, []byte{0xE6, 0, 0, 0})...)
+	recData = append(recData, makeTLVField(TagFrame, []byte("672 442 875 662 0 0 2560 1415"))...)
+
+	v, err := ParseViewState(RawRecord{Type: RecordTypeViewState, ID: 230, Data: recData}, reunion.NewErrorCollector(0))
+	if err != nil {
+		t.Fatalf("ParseViewState() error = %v", err)
+	}
+	if len(v.Frames) != 1 || v.Frames[0] != "672 442 875 662 0 0 2560 1415" {
+		t.Errorf("Frames = %q", v.Frames)
+	}
+	if len(v.RawFields) != 1 || v.RawFields[0].Tag != 0x000A {
+		t.Errorf("RawFields = %+v", v.RawFields)
+	}
+}
@@ -21,8 +21,9 @@ const (
 // ParsePerson parses a 0x20C4 person record into a Person model.
 func ParsePerson(rec RawRecord, ec *reunion.ErrorCollector) (*model.Person, error) {
 	p := &model.Person{
-		ID:     rec.ID,
-		SeqNum: rec.SeqNum,
+		ID:       rec.ID,
+		SeqNum:   rec.SeqNum,
+		Modified: rec.Modified(),
 	}
 
 	if len(rec.Data) < 6 {
@@ -60,12 +61,16 @@ func ParsePerson(rec RawRecord, ec *reunion.ErrorCollector) (*model.Person, erro
 			}
 		default:
 			if isEventTag(f.Tag) {
+				date, err := extractDateAt(f.Data, eventSubTLVOffset)
+				if err != nil {
+					ec.Add("familydata", rec.FieldOffset(f), "person event date", err)
+				}
 				evt := model.PersonEvent{
 					Tag:             f.Tag,
 					PlaceRefs:       ExtractPlaceRefs(f.Data),
 					RawData:         f.Data,
 					SchemaID:        ParseEventField(f.Data),
-					Date:            ExtractDate(f.Data),
+					Date:            date,
 					Text:            ExtractEventText(f.Data),
 					SourceCitations: ExtractEventSourceCitations(f.Data),
 				}
@@ -106,6 +111,7 @@ func cleanString(data []byte) string {
 }
 
 func isEventTag(tag uint16) bool {
-	// Event tags use codes >= 0x100 in the person record
-	return tag >= 0x0100
+	// Event tags use codes >= 0x100 in the person record, except for the
+	// media fields decoded by ParseMedia.
+	return tag >= 0x0100 && !isMediaTag(tag)
 }
@@ -116,3 +116,27 @@ func TestParsePerson_WithNoteRef(t *testing.T) {
 		t.Errorf("NoteRefs[0].NoteID = %d, want 99", person.NoteRefs[0].NoteID)
 	}
 }
+
+func TestParsePerson_ReportsUnknownDateFlags(t *testing.T) {
+	recData := makePreamble()
+	recData = append(recData, makeTLVField(0x03E8, eventFieldData(dateSubTLV(0x40, 1, 1, 1900)))...)
+	recData = append(recData, makeTLVField(0x03E9, eventFieldData(dateSubTLV(0x10, 1, 1, 1900)))...)
+
+	ec := reunion.NewErrorCollector(0)
+	person, err := ParsePerson(RawRecord{Type: RecordTypePerson, ID: 1, Offset: 0x100, Data: recData}, ec)
+	if err != nil {
+		t.Fatalf("ParsePerson() error = %v", err)
+	}
+	if len(person.Events) != 2 || person.Events[0].Date.String() != "after 1 Jan 1900" ||
+		person.Events[1].Date.String() != "1 Jan 1900" {
+		t.Fatalf("Events = %+v, want two dated events", person.Events)
+	}
+	errs := ec.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("collected %d errors, want 1: %v", len(errs), errs)
+	}
+	// Preamble 6 + first field (4-byte header + 26 bytes), after the 20-byte record header.
+	if want := 0x100 + 20 + 6 + 30; errs[0].Offset != want {
+		t.Errorf("error offset = 0x%X, want 0x%X", errs[0].Offset, want)
+	}
+}
@@ -1,21 +1,65 @@
 package familydata
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	reunion "github.com/kedoco/reunion-explore"
 	"github.com/kedoco/reunion-explore/model"
 )
 
-// ParsePlace parses a 0x20D8 place record from familydata.
+// Tag constants for place record fields.
+const (
+	TagPlaceName        uint16 = 0x001E
+	TagPlaceCoordinates uint16 = 0x0023
+)
+
+// ParsePlace parses a 0x20D8 place record from familydata: the full name
+// and, when set, the coordinates. Usage counts live in placeUsage.cache.
 func ParsePlace(rec RawRecord, ec *reunion.ErrorCollector) (*model.Place, error) {
 	p := &model.Place{
-		ID: rec.ID,
+		ID:     rec.ID,
+		SeqNum: rec.SeqNum,
 	}
 
-	if len(rec.Data) > 8 {
-		// Extract place name from record data
-		data := rec.Data[8:]
-		p.Name = ExtractString(data)
+	for _, f := range ParseTLVFields(rec.Data) {
+		switch f.Tag {
+		case TagPlaceName:
+			p.Name = cleanString(f.Data)
+			continue
+		case TagPlaceCoordinates:
+			c, err := parseCoordinates(cleanString(f.Data))
+			if err == nil {
+				p.Coordinates = c
+				continue
+			}
+			ec.Add("familydata", rec.FieldOffset(f), "place coordinates", err)
+		}
+		p.RawFields = append(p.RawFields, model.RawField{
+			Tag:  f.Tag,
+			Data: f.Data,
+			Size: uint16(len(f.Data) + 4),
+		})
 	}
 
 	return p, nil
 }
+
+// parseCoordinates parses coordinates stored as "latitude longitude" in
+// decimal degrees, e.g. "42.3584308 -71.0597732".
+func parseCoordinates(s string) (*model.Coordinates, error) {
+	parts := strings.Fields(s)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("coordinates %q: want latitude and longitude", s)
+	}
+	lat, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return nil, fmt.Errorf("coordinates %q: bad latitude", s)
+	}
+	lon, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return nil, fmt.Errorf("coordinates %q: bad longitude", s)
+	}
+	return &model.Coordinates{Latitude: lat, Longitude: lon}, nil
+}
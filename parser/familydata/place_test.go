@@ -0,0 +1,47 @@
+package familydata
+
+import (
+	"testing"
+
+	reunion "github.com/kedoco/reunion-explore"
+	"github.com/kedoco/reunion-explore/model"
+)
+
+func TestParsePlace(t *testing.T) {
+	recData := makePreamble()
+	recData = append(recData, makeTLVField(TagPlaceName, []byte("Boston, MA"))...)
+	recData = append(recData, makeTLVField(TagPlaceCoordinates, []byte("42.3584308 -71.0597732"))...)
+
+	ec := reunion.NewErrorCollector(0)
+	p, err := ParsePlace(RawRecord{Type: RecordTypePlace, ID: 35, Data: recData}, ec)
+	if err != nil {
+		t.Fatalf("ParsePlace() error = %v", err)
+	}
+	if p.Name != "Boston, MA" {
+		t.Errorf("Name = %q, want Boston, MA", p.Name)
+	}
+	if p.Coordinates == nil || *p.Coordinates != (model.Coordinates{Latitude: 42.3584308, Longitude: -71.0597732}) {
+		t.Errorf("Coordinates = %v", p.Coordinates)
+	}
+	if len(p.RawFields) != 0 || len(ec.Errors()) != 0 {
+		t.Errorf("RawFields = %+v, errors = %v", p.RawFields, ec.Errors())
+	}
+}
+
+func TestParsePlace_BadCoordinates(t *testing.T) {
+	recData := makePreamble()
+	recData = append(recData, makeTLVField(TagPlaceName, []byte("Nowhere"))...)
+	recData = append(recData, makeTLVField(TagPlaceCoordinates, []byte("91.0 10.0"))...)
+
+	ec := reunion.NewErrorCollector(0)
+	p, _ := ParsePlace(RawRecord{Type: RecordTypePlace, ID: 1, Data: recData}, ec)
+	if p.Coordinates != nil {
+		t.Errorf("Coordinates = %v, want none", p.Coordinates)
+	}
+	if len(p.RawFields) != 1 || p.RawFields[0].Tag != TagPlaceCoordinates {
+		t.Errorf("RawFields = %+v, want the coordinates field", p.RawFields)
+	}
+	if len(ec.Errors()) != 1 {
+		t.Errorf("errors = %v, want 1", ec.Errors())
+	}
+}
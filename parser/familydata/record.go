@@ -1,6 +1,10 @@
 package familydata
 
 import (
+	"bytes"
+	"fmt"
+	"time"
+
 	"github.com/kedoco/reunion-explore/internal/binutil"
 )
 
@@ -8,17 +12,40 @@ import (
 type RecordType uint16
 
 const (
-	RecordTypePerson RecordType = 0x20C4
-	RecordTypeFamily RecordType = 0x20C8
-	RecordTypeSchema RecordType = 0x20CC
-	RecordTypeSource RecordType = 0x20D0
-	RecordTypeMedia  RecordType = 0x20D4
-	RecordTypePlace  RecordType = 0x20D8
-	RecordTypeNote   RecordType = 0x2104
-	RecordTypeDoc    RecordType = 0x2108
-	RecordTypeReport RecordType = 0x210C
+	RecordTypePerson    RecordType = 0x20C4
+	RecordTypeFamily    RecordType = 0x20C8
+	RecordTypeSchema    RecordType = 0x20CC
+	RecordTypeSource    RecordType = 0x20D0
+	RecordTypeViewState RecordType = 0x20D4 // window and view state
+	RecordTypePlace     RecordType = 0x20D8
+	RecordTypeNote      RecordType = 0x2104
+	RecordTypeDoc       RecordType = 0x2108
+	RecordTypeReport    RecordType = 0x210C
 )
 
+var recordTypeNames = map[RecordType]string{
+	RecordTypePerson:    "person",
+	RecordTypeFamily:    "family",
+	RecordTypeSchema:    "event definition",
+	RecordTypeSource:    "source",
+	RecordTypeViewState: "view state",
+	RecordTypePlace:     "place",
+	RecordTypeNote:      "note",
+	RecordTypeDoc:       "document",
+	RecordTypeReport:    "report",
+}
+
+func (t RecordType) String() string {
+	if name, ok := recordTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("record type 0x%04X", uint16(t))
+}
+
+// TagUUID is the field holding a record's 16-byte random (version 4)
+// UUID, present in person, family, schema and source records.
+const TagUUID uint16 = 0x0038
+
 // Marker is the 4-byte record marker found in familydata.
 var Marker = []byte{0x05, 0x03, 0x02, 0x01}
 
@@ -32,107 +59,110 @@ type RawRecord struct {
 	Data    []byte     // full record data starting from offset
 }
 
-// ScanRecords scans the familydata for all records marked by the 05030201 pattern.
+// FieldOffset returns the file offset of a TLV field parsed from r.Data
+// with ParseTLVFields: the record's data starts 20 bytes into the record,
+// and the fields follow its 6-byte preamble.
+func (r RawRecord) FieldOffset(f TLVField) int {
+	return r.Offset + 20 + 6 + f.Offset
+}
+
+// Modified returns the time the record was last saved: its data starts
+// with the time in Unix seconds, which edit.Save sets through Touch. It
+// returns the zero time if the record has no data.
+func (r RawRecord) Modified() time.Time {
+	ts, err := binutil.U32LE(r.Data, 0)
+	if err != nil || ts == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(ts), 0).UTC()
+}
+
+// ScanRecords returns the records of a familydata file, found by their
+// 05030201 markers.
+//
+// Record layout around the marker:
+//
+//	marker-8: 4 bytes padding (or overflow of the previous record)
+//	marker-4: 2 bytes sequence number (LE)
+//	marker-2: 2 bytes type code (LE)
+//	marker+0: 4 bytes marker (05030201)
+//	marker+4: 4 bytes data size (LE)
+//	marker+8: 4 bytes record ID (LE)
+//	marker+12: data, starting with a 4-byte timestamp
+//
+// Once a record is found, the search for the next marker resumes after
+// the record's declared data, so marker bytes inside a payload, such as a
+// note's text, do not start a record. If the declared data runs past the
+// end of the file the header is not trusted, and the search resumes just
+// after the marker.
 func ScanRecords(data []byte) []RawRecord {
 	var records []RawRecord
+	var markers []int
 	pos := 0
-
-	for pos < len(data)-4 {
-		// Search for the marker
-		found := -1
-		for i := pos; i <= len(data)-4; i++ {
-			if data[i] == 0x05 && data[i+1] == 0x03 && data[i+2] == 0x02 && data[i+3] == 0x01 {
-				found = i
-				break
-			}
-		}
-		if found == -1 {
+	for {
+		i := bytes.Index(data[pos:], Marker)
+		if i < 0 {
 			break
 		}
-
-		// Record structure:
-		// offset-8: 4 bytes padding/zeros
-		// offset-4: 2 bytes sequence number (LE)
-		// offset-2: 2 bytes type code (LE)
-		// offset+0: 4 bytes marker (05030201)
-		// offset+4: 4 bytes data size (LE)
-		// offset+8: 4 bytes record ID (LE)
-		// offset+12: 4 bytes timestamp
-
-		markerPos := found
-		recStart := markerPos - 8
-		if recStart < 0 {
-			recStart = 0
-		}
-
-		rec := RawRecord{
-			Offset: recStart,
-		}
-
-		// Read type code (2 bytes before marker)
-		if markerPos >= 2 {
-			tc, _ := binutil.U16LE(data, markerPos-2)
-			rec.Type = RecordType(tc)
-		}
-
-		// Read sequence number (4 bytes before marker)
-		if markerPos >= 4 {
-			seq, _ := binutil.U16LE(data, markerPos-4)
-			rec.SeqNum = seq
-		}
-
-		// Read data length
-		if markerPos+8 <= len(data) {
-			dl, _ := binutil.U32LE(data, markerPos+4)
-			rec.DataLen = dl
-		}
-
-		// Read record ID
-		if markerPos+12 <= len(data) {
-			id, _ := binutil.U32LE(data, markerPos+8)
-			rec.ID = id
-		}
-
-		// Extract the full record data
-		dataStart := markerPos + 12
-		dataEnd := dataStart + int(rec.DataLen)
-		if dataEnd > len(data) {
-			dataEnd = len(data)
-		}
-		if dataStart < len(data) {
-			rec.Data = data[dataStart:dataEnd]
-		}
-
+		markerPos := pos + i
+		rec := recordHeader(data, 0, markerPos)
 		records = append(records, rec)
-		pos = markerPos + 4
+		markers = append(markers, markerPos)
+		pos = searchFrom(markerPos, rec.DataLen, len(data))
 	}
 
-	// Extend each record's data to include any bytes between the declared
-	// DataLen boundary and the next record's marker. Some records (notably
-	// families) have child reference data that overflows past the declared
-	// DataLen. The overflow bytes may sit in the inter-record gap or in the
-	// 4-byte "padding" area at the start of the next record's header.
-	// The TLV parser safely stops on zero padding (totalLen=0 < 4 → break)
-	// or small header values (totalLen < 4 → break).
+	// Each record's data runs to the end of its declared content or to the
+	// next record's marker, whichever is later, and the last record's to
+	// the end of the file. Some records (notably families) have child
+	// reference data that overflows past the declared DataLen. The overflow
+	// bytes may sit in the inter-record gap or in the 4-byte "padding" area
+	// at the start of the next record's header. The TLV parser safely stops
+	// on zero padding (totalLen=0 < 4 → break) or small header values
+	// (totalLen < 4 → break).
 	for i := range records {
-		dataStart := records[i].Offset + 20 // markerPos+12 = Offset+8+12 = Offset+20
-		// Use the next record's marker position (Offset+8) as the upper bound,
-		// since the 4 bytes between Offset and Offset+4 can contain overflow
-		// data rather than true padding.
-		var boundary int
-		if i+1 < len(records) {
-			boundary = records[i+1].Offset + 8 // next marker position
-		} else {
-			boundary = len(data)
-		}
-		if boundary > len(data) {
-			boundary = len(data)
+		dataStart := markers[i] + 12
+		if dataStart >= len(data) {
+			continue
 		}
-		declaredEnd := dataStart + int(records[i].DataLen)
-		if boundary > declaredEnd && dataStart < len(data) {
-			records[i].Data = data[dataStart:boundary]
+		dataEnd := min(dataStart+int(records[i].DataLen), len(data))
+		boundary := len(data)
+		if i+1 < len(records) {
+			boundary = markers[i+1]
 		}
+		records[i].Data = data[dataStart:max(dataEnd, boundary)]
 	}
 
 	return records
 }
+
+// recordHeader returns a record with the header fields around the marker
+// at markerPos, read from buf, which holds the file from offset base.
+// Fields that fall outside buf are left zero.
+func recordHeader(buf []byte, base, markerPos int) RawRecord {
+	at := markerPos - base
+	rec := RawRecord{Offset: max(markerPos-8, 0)}
+	if markerPos >= 2 {
+		tc, _ := binutil.U16LE(buf, at-2)
+		rec.Type = RecordType(tc)
+	}
+	if markerPos >= 4 {
+		rec.SeqNum, _ = binutil.U16LE(buf, at-4)
+	}
+	if dl, err := binutil.U32LE(buf, at+4); err == nil {
+		rec.DataLen = dl
+	}
+	if id, err := binutil.U32LE(buf, at+8); err == nil {
+		rec.ID = id
+	}
+	return rec
+}
+
+// searchFrom returns the offset at which to look for the marker after the
+// one at markerPos, in a file of size bytes: past the record's declared
+// data if that fits in the file, otherwise just past the marker.
+func searchFrom(markerPos int, dataLen uint32, size int) int {
+	if end := markerPos + 12 + int(dataLen); end <= size {
+		return end
+	}
+	return markerPos + len(Marker)
+}
@@ -1,7 +1,10 @@
 package familydata
 
 import (
+	"bytes"
 	"encoding/binary"
+	"math/rand/v2"
+	"sync"
 	"testing"
 )
 
@@ -126,3 +129,91 @@ func TestScanRecords_NoMarker(t *testing.T) {
 		t.Errorf("expected 0 records, got %d", len(records))
 	}
 }
+
+func TestScanRecords_EmbeddedMarker(t *testing.T) {
+	// A note whose text contains the marker bytes, as a pasted binary
+	// snippet might, followed by a person.
+	text := append([]byte("before "), Marker...)
+	text = append(text, []byte(" after, with more text to look like a header")...)
+	note := makeRecord(1, RecordTypeNote, 5, text)
+	person := makeRecord(2, RecordTypePerson, 6, []byte("person"))
+	data := append(append([]byte("HEADER"), note...), person...)
+
+	for name, records := range map[string][]RawRecord{
+		"ScanRecords": ScanRecords(data),
+		"Records":     collectRecords(t, data),
+	} {
+		if len(records) != 2 {
+			t.Fatalf("%s found %d records, want 2 (no phantom record inside the note)", name, len(records))
+		}
+		if records[0].Type != RecordTypeNote || records[1].Type != RecordTypePerson || records[1].ID != 6 {
+			t.Errorf("%s = %04X %d, %04X %d, want the note and person 6", name,
+				uint16(records[0].Type), records[0].ID, uint16(records[1].Type), records[1].ID)
+		}
+		if !bytes.Contains(records[0].Data, text) {
+			t.Errorf("%s: note data lost its text", name)
+		}
+	}
+}
+
+func TestScanRecords_BadDataLen(t *testing.T) {
+	// A record whose declared length runs past the end of the file is not
+	// trusted to skip the records after it.
+	rec1 := makeRecord(1, RecordTypePerson, 10, []byte("short"))
+	binary.LittleEndian.PutUint32(rec1[12:], 1<<20)
+	rec2 := makeRecord(2, RecordTypeFamily, 20, []byte("next"))
+	data := append(rec1, rec2...)
+
+	records := ScanRecords(data)
+	if len(records) != 2 || records[1].ID != 20 {
+		t.Fatalf("ScanRecords() = %+v, want records 10 and 20", records)
+	}
+	if got := collectRecords(t, data); len(got) != 2 || got[1].ID != 20 {
+		t.Fatalf("Records() = %+v, want records 10 and 20", got)
+	}
+}
+
+var synthetic = sync.OnceValue(func() []byte { return syntheticFamilydata(100 << 20) })
+
+// syntheticFamilydata returns about size bytes of familydata: a header,
+// then records in 128-byte slots with random text payloads, one in eight
+// of which contains the marker bytes.
+func syntheticFamilydata(size int) []byte {
+	rng := rand.New(rand.NewPCG(1, 2))
+	types := []RecordType{RecordTypePerson, RecordTypeFamily, RecordTypeNote, RecordTypePlace}
+	data := make([]byte, 0x34, size+4*slotSize)
+	copy(data, "3SDUAU~R")
+	for id := uint32(1); len(data) < size; id++ {
+		payload := make([]byte, 8+rng.IntN(500))
+		for i := range payload {
+			payload[i] = byte('a' + rng.IntN(26))
+		}
+		if id%8 == 0 {
+			copy(payload[len(payload)/2:], Marker)
+		}
+		rec := makeRecord(1, types[id%4], id, payload)
+		data = append(data, rec...)
+		data = append(data, make([]byte, (slotSize-len(rec)%slotSize)%slotSize)...)
+	}
+	return data
+}
+
+func BenchmarkScanRecords(b *testing.B) {
+	data := synthetic()
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		ScanRecords(data)
+	}
+}
+
+func BenchmarkRecords(b *testing.B) {
+	data := synthetic()
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		for _, err := range Records(bytes.NewReader(data)) {
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package familydata
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"iter"
+	"math"
+
+	"github.com/kedoco/reunion-explore/model"
+
+	reunion "github.com/kedoco/reunion-explore"
+)
+
+// scanChunk is how much of the file Records reads at a time while it
+// looks for record markers. Tests lower it to cross chunk boundaries.
+var scanChunk = 1 << 20
+
+// Records returns an iterator over the records of the familydata file read
+// from r, in file order. It finds the same records as ScanRecords, skipping
+// marker bytes inside declared record data the same way, but
+// reads the file a chunk at a time and gives each record a Data buffer of
+// its own: memory use is bounded by the largest record rather than the
+// file, and a model parsed from a record, whose RawData and RawFields
+// alias Data, keeps only that record alive.
+//
+// A read error is yielded with the zero RawRecord and ends the iteration.
+func Records(r io.ReaderAt) iter.Seq2[RawRecord, error] {
+	return func(yield func(RawRecord, error) bool) {
+		s := &fileScanner{r: r, size: -1}
+		pos, err := s.nextMarker(0)
+		for err == nil && pos >= 0 {
+			var rec RawRecord
+			if rec, err = s.header(pos); err != nil {
+				break
+			}
+			var next int
+			if next, err = s.nextAfter(pos, rec.DataLen); err != nil {
+				break
+			}
+			if rec.Data, err = s.data(pos, rec.DataLen, next); err != nil {
+				break
+			}
+			if !yield(rec, nil) {
+				return
+			}
+			pos = next
+		}
+		if err != nil {
+			yield(RawRecord{}, err)
+		}
+	}
+}
+
+// Persons returns an iterator over the persons in the familydata file read
+// from r. A record that fails to parse is reported to ec and skipped, as
+// Parse does; a read error is yielded and ends the iteration.
+func Persons(r io.ReaderAt, ec *reunion.ErrorCollector) iter.Seq2[*model.Person, error] {
+	return decode(r, RecordTypePerson, ParsePerson, ec)
+}
+
+// Families is Persons for family records.
+func Families(r io.ReaderAt, ec *reunion.ErrorCollector) iter.Seq2[*model.Family, error] {
+	return decode(r, RecordTypeFamily, ParseFamily, ec)
+}
+
+// EventDefinitions is Persons for event definition (schema) records.
+func EventDefinitions(r io.ReaderAt, ec *reunion.ErrorCollector) iter.Seq2[*model.EventDefinition, error] {
+	return decode(r, RecordTypeSchema, ParseSchema, ec)
+}
+
+// Places is Persons for place records.
+func Places(r io.ReaderAt, ec *reunion.ErrorCollector) iter.Seq2[*model.Place, error] {
+	return decode(r, RecordTypePlace, ParsePlace, ec)
+}
+
+// Notes is Persons for note records.
+func Notes(r io.ReaderAt, ec *reunion.ErrorCollector) iter.Seq2[*model.Note, error] {
+	return decode(r, RecordTypeNote, ParseNote, ec)
+}
+
+// Sources is Persons for source records. Their template and field labels,
+// which come from schema records, are left empty.
+func Sources(r io.ReaderAt, ec *reunion.ErrorCollector) iter.Seq2[*model.Source, error] {
+	return decode(r, RecordTypeSource, ParseSource, ec)
+}
+
+// MediaRefs returns an iterator over the media attached to the persons and
+// families in the familydata file read from r, as ParseMedia decodes them.
+func MediaRefs(r io.ReaderAt, ec *reunion.ErrorCollector) iter.Seq2[*model.MediaRef, error] {
+	return func(yield func(*model.MediaRef, error) bool) {
+		for rec, err := range Records(r) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			media, _ := ParseMedia(rec, ec)
+			for i := range media {
+				if !yield(&media[i], nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// ViewStates is Persons for view state records.
+func ViewStates(r io.ReaderAt, ec *reunion.ErrorCollector) iter.Seq2[*model.ViewState, error] {
+	return decode(r, RecordTypeViewState, ParseViewState, ec)
+}
+
+// Documents is Persons for document records. Their titles, which come from
+// list records elsewhere in the file, are left empty.
+func Documents(r io.ReaderAt, ec *reunion.ErrorCollector) iter.Seq2[*model.Document, error] {
+	return decode(r, RecordTypeDoc, ParseDoc, ec)
+}
+
+// ReportDefinitions is Documents for report records.
+func ReportDefinitions(r io.ReaderAt, ec *reunion.ErrorCollector) iter.Seq2[*model.ReportDefinition, error] {
+	return decode(r, RecordTypeReport, ParseReport, ec)
+}
+
+// recordParseErrors is the message Parse and the typed iterators report
+// for a record of each type that fails to parse.
+var recordParseErrors = map[RecordType]string{
+	RecordTypePerson:    "person parse error",
+	RecordTypeFamily:    "family parse error",
+	RecordTypeSchema:    "schema parse error",
+	RecordTypePlace:     "place parse error",
+	RecordTypeNote:      "note parse error",
+	RecordTypeSource:    "source parse error",
+	RecordTypeViewState: "view state parse error",
+	RecordTypeDoc:       "document parse error",
+	RecordTypeReport:    "report parse error",
+}
+
+func decode[T any](r io.ReaderAt, typ RecordType, parse func(RawRecord, *reunion.ErrorCollector) (*T, error), ec *reunion.ErrorCollector) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		for rec, err := range Records(r) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if rec.Type != typ {
+				continue
+			}
+			v, err := parse(rec, ec)
+			if err != nil {
+				ec.Add("familydata", rec.Offset, recordParseErrors[typ], err)
+				continue
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
+
+// fileScanner reads a familydata file through a window of at most
+// scanChunk bytes.
+type fileScanner struct {
+	r    io.ReaderAt
+	buf  []byte // file bytes from off
+	off  int
+	size int // file size once the end has been read, -1 before
+}
+
+// load fills the window with the file from off.
+func (s *fileScanner) load(off int) error {
+	if cap(s.buf) < scanChunk {
+		s.buf = make([]byte, scanChunk)
+	}
+	n, err := s.r.ReadAt(s.buf[:scanChunk], int64(off))
+	s.buf, s.off = s.buf[:n], off
+	if errors.Is(err, io.EOF) {
+		s.size = off + n
+		return nil
+	}
+	return err
+}
+
+// nextMarker returns the file offset of the first marker at or after pos,
+// or -1 if there is none.
+func (s *fileScanner) nextMarker(pos int) (int, error) {
+	for {
+		end := s.off + len(s.buf)
+		if pos >= s.off && pos <= end {
+			if i := bytes.Index(s.buf[pos-s.off:], Marker); i >= 0 {
+				return pos + i, nil
+			}
+			if end == s.size {
+				return -1, nil
+			}
+			// The last bytes of the window may start a marker.
+			pos = max(pos, end-len(Marker)+1)
+		}
+		if err := s.load(pos); err != nil {
+			return -1, err
+		}
+	}
+}
+
+// read returns a copy of the file from offset from up to to, or up to the
+// end of the file if that comes first.
+func (s *fileScanner) read(from, to int) ([]byte, error) {
+	if s.size >= 0 {
+		to = min(to, s.size)
+	}
+	if from >= to {
+		return nil, nil
+	}
+	if from >= s.off && to <= s.off+len(s.buf) {
+		return bytes.Clone(s.buf[from-s.off : to-s.off]), nil
+	}
+	var out []byte
+	for from < to {
+		chunk := make([]byte, min(to-from, scanChunk))
+		n, err := s.r.ReadAt(chunk, int64(from))
+		out = append(out, chunk[:n]...)
+		from += n
+		if errors.Is(err, io.EOF) {
+			if n > 0 {
+				s.size = from
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// header reads the header fields of the record whose marker is at
+// markerPos.
+func (s *fileScanner) header(markerPos int) (RawRecord, error) {
+	base := max(markerPos-4, 0)
+	head, err := s.read(base, markerPos+12)
+	if err != nil {
+		return RawRecord{}, err
+	}
+	return recordHeader(head, base, markerPos), nil
+}
+
+// nextAfter returns the offset of the marker after the record whose marker
+// is at markerPos, or -1, skipping the record's declared data as
+// ScanRecords does.
+func (s *fileScanner) nextAfter(markerPos int, dataLen uint32) (int, error) {
+	from := markerPos + len(Marker)
+	if end := markerPos + 12 + int(dataLen); end > from {
+		fits, err := s.reaches(end)
+		if err != nil {
+			return -1, err
+		}
+		if fits {
+			from = end
+		}
+	}
+	return s.nextMarker(from)
+}
+
+// reaches reports whether the file is at least n bytes long.
+func (s *fileScanner) reaches(n int) (bool, error) {
+	if s.size >= 0 {
+		return n <= s.size, nil
+	}
+	if n <= s.off+len(s.buf) {
+		return true, nil
+	}
+	var b [1]byte
+	_, err := s.r.ReadAt(b[:], int64(n-1))
+	if errors.Is(err, io.EOF) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// data reads the data of the record whose marker is at markerPos. It runs
+// to the end of its declared content or to the next marker, whichever is
+// later, and the last record's to the end of the file.
+func (s *fileScanner) data(markerPos int, dataLen uint32, next int) ([]byte, error) {
+	dataStart := markerPos + 12
+	dataEnd := dataStart + int(dataLen)
+	if next < 0 {
+		dataEnd = math.MaxInt
+	} else if next > dataEnd {
+		dataEnd = next
+	}
+	return s.read(dataStart, dataEnd)
+}
@@ -0,0 +1,125 @@
+package familydata
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	reunion "github.com/kedoco/reunion-explore"
+)
+
+func collectRecords(t *testing.T, data []byte) []RawRecord {
+	t.Helper()
+	var records []RawRecord
+	for rec, err := range Records(bytes.NewReader(data)) {
+		if err != nil {
+			t.Fatalf("Records() error = %v", err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestRecords_MatchesScanRecords(t *testing.T) {
+	var overflow []byte
+	overflow = append(overflow, []byte("HEADER")...)
+	overflow = append(overflow, makeRecord(1, RecordTypePerson, 10, []byte("short"))...)
+	overflow = append(overflow, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE)
+	overflow = append(overflow, makeRecord(2, RecordTypeFamily, 20, []byte("next"))...)
+	overflow = append(overflow, 0x01, 0x02, 0x03, 0x04)
+
+	defer func(n int) { scanChunk = n }(scanChunk)
+	for _, chunk := range []int{scanChunk, 4096, 7} {
+		scanChunk = chunk
+		for name, data := range map[string][]byte{"sample": readSample(t), "overflow": overflow} {
+			want := ScanRecords(data)
+			got := collectRecords(t, data)
+			if len(got) != len(want) {
+				t.Fatalf("%s, chunk %d: %d records, want %d", name, chunk, len(got), len(want))
+			}
+			for i := range want {
+				g, w := got[i], want[i]
+				if g.Offset != w.Offset || g.Type != w.Type || g.SeqNum != w.SeqNum ||
+					g.DataLen != w.DataLen || g.ID != w.ID || !bytes.Equal(g.Data, w.Data) {
+					t.Fatalf("%s, chunk %d: record %d = %+v, want %+v", name, chunk, i, g, w)
+				}
+			}
+		}
+	}
+}
+
+func TestRecords_OwnData(t *testing.T) {
+	data := readSample(t)
+	records := collectRecords(t, data)
+	records[0].Data[0] ^= 0xFF
+	if bytes.Equal(records[0].Data, ScanRecords(data)[0].Data) {
+		t.Error("record Data aliases the reader's buffer")
+	}
+}
+
+// failingReader fails every read that reaches past limit.
+type failingReader struct {
+	data  []byte
+	limit int64
+}
+
+var errRead = errors.New("disk on fire")
+
+func (r failingReader) ReadAt(p []byte, off int64) (int, error) {
+	if off+int64(len(p)) > r.limit {
+		return 0, errRead
+	}
+	return copy(p, r.data[off:]), nil
+}
+
+func TestRecords_ReadError(t *testing.T) {
+	defer func(n int) { scanChunk = n }(scanChunk)
+	scanChunk = 4096
+	data := readSample(t)
+
+	n := 0
+	var last error
+	for _, err := range Records(failingReader{data, 20000}) {
+		if err != nil {
+			last = err
+			continue
+		}
+		n++
+	}
+	if !errors.Is(last, errRead) {
+		t.Errorf("Records() final error = %v, want the read error", last)
+	}
+	if n == 0 || n >= len(ScanRecords(data)) {
+		t.Errorf("Records() yielded %d records before the error", n)
+	}
+}
+
+func TestPersons(t *testing.T) {
+	r := bytes.NewReader(readSample(t))
+	ec := reunion.NewErrorCollector(0)
+
+	n := 0
+	for p, err := range Persons(r, ec) {
+		if err != nil {
+			t.Fatalf("Persons() error = %v", err)
+		}
+		if p.GivenName == "" && p.Surname == "" {
+			t.Errorf("person %d has no name", p.ID)
+		}
+		n++
+	}
+	if n != 49 {
+		t.Errorf("Persons() yielded %d persons, want 49", n)
+	}
+
+	// Stopping early is allowed.
+	for p := range Notes(r, ec) {
+		if p == nil {
+			t.Error("Notes() yielded nil")
+		}
+		break
+	}
+	if ec.Len() != 0 {
+		t.Errorf("errors: %v", ec.Errors())
+	}
+}
@@ -1,11 +1,30 @@
 package familydata
 
 import (
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"slices"
+
 	reunion "github.com/kedoco/reunion-explore"
 	"github.com/kedoco/reunion-explore/model"
 )
 
-// ParseSource parses a 0x20D0 source record from familydata.
+// Tag constants for source record fields. Each field of the source's
+// template has its own tag from TagSourceFieldFirst, numbered in template
+// order.
+const (
+	TagSourceTemplate   uint16 = 0x000A
+	TagSourceNote       uint16 = 0x0064
+	TagSourceFieldFirst uint16 = 0x03E8
+)
+
+// sourceFieldHeaderLen is the size of the fixed part of a source field;
+// the length and text of a non-empty field follow.
+const sourceFieldHeaderLen = 18
+
+// ParseSource parses a 0x20D0 source record from familydata. Template and
+// field labels are the names of schema records and are set by Parse.
 func ParseSource(rec RawRecord, ec *reunion.ErrorCollector) (*model.Source, error) {
 	s := &model.Source{
 		ID:     rec.ID,
@@ -18,13 +37,27 @@ func ParseSource(rec RawRecord, ec *reunion.ErrorCollector) (*model.Source, erro
 
 	fields := ParseTLVFields(rec.Data)
 
-	// First pass: look for tag 0x0014 (display name) for the title
 	for _, f := range fields {
-		if f.Tag == TagDisplayName {
-			str := cleanString(f.Data)
-			if len(str) > 0 {
+		switch {
+		case f.Tag == TagDisplayName:
+			if str := cleanString(f.Data); len(str) > 0 {
 				s.Title = str
 			}
+		case f.Tag == TagSourceTemplate && len(f.Data) >= 2:
+			s.TemplateID = uint32(binary.LittleEndian.Uint16(f.Data))
+			continue
+		case f.Tag == TagSourceNote && len(f.Data) >= 4:
+			s.NoteID = binary.LittleEndian.Uint32(f.Data)
+			continue
+		case f.Tag >= TagSourceFieldFirst:
+			sf, err := ParseSourceField(f.Data)
+			if err != nil {
+				ec.Add("familydata", rec.FieldOffset(f), "source field", err)
+				break
+			}
+			sf.Tag = f.Tag
+			s.Fields = append(s.Fields, sf)
+			continue
 		}
 		s.RawFields = append(s.RawFields, model.RawField{
 			Tag:  f.Tag,
@@ -32,6 +65,10 @@ func ParseSource(rec RawRecord, ec *reunion.ErrorCollector) (*model.Source, erro
 			Size: uint16(len(f.Data) + 4),
 		})
 	}
+	// Fields are stored in the order they were last edited.
+	slices.SortStableFunc(s.Fields, func(a, b model.SourceField) int {
+		return cmp.Compare(a.Tag, b.Tag)
+	})
 
 	// Fallback: if no 0x0014 tag, use first non-empty string
 	if s.Title == "" {
@@ -46,3 +83,47 @@ func ParseSource(rec RawRecord, ec *reunion.ErrorCollector) (*model.Source, erro
 
 	return s, nil
 }
+
+// ParseSourceField decodes the data of a source field:
+//
+//	Offset  Size  Description
+//	0       2     data length (u16LE)
+//	16      2     schema ID of the field type (u16LE)
+//	18      4     text length plus 4 (u32LE), absent if the field is empty
+//	22      N     text
+//
+// Other bytes of the header are not understood.
+func ParseSourceField(data []byte) (model.SourceField, error) {
+	if len(data) < sourceFieldHeaderLen {
+		return model.SourceField{}, fmt.Errorf("source field too short: %d bytes", len(data))
+	}
+	sf := model.SourceField{TypeID: uint32(binary.LittleEndian.Uint16(data[16:]))}
+	if len(data) == sourceFieldHeaderLen {
+		return sf, nil
+	}
+	if len(data) < sourceFieldHeaderLen+4 {
+		return model.SourceField{}, fmt.Errorf("source field text length truncated: %d bytes", len(data))
+	}
+	n := int(binary.LittleEndian.Uint32(data[sourceFieldHeaderLen:]))
+	if end := sourceFieldHeaderLen + n; n < 4 || end > len(data) {
+		return model.SourceField{}, fmt.Errorf("source field text runs to %d, past the %d-byte field", end, len(data))
+	}
+	sf.Value = cleanString(data[sourceFieldHeaderLen+4 : sourceFieldHeaderLen+n])
+	return sf, nil
+}
+
+// labelSources names the templates and fields of sources after the schema
+// records defining them.
+func labelSources(sources []model.Source, defs []model.EventDefinition) {
+	names := make(map[uint32]string, len(defs))
+	for _, d := range defs {
+		names[d.ID] = d.DisplayName
+	}
+	for i := range sources {
+		s := &sources[i]
+		s.Template = names[s.TemplateID]
+		for j := range s.Fields {
+			s.Fields[j].Label = names[s.Fields[j].TypeID]
+		}
+	}
+}
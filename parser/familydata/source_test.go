@@ -0,0 +1,83 @@
+package familydata
+
+import (
+	"encoding/binary"
+	"testing"
+
+	reunion "github.com/kedoco/reunion-explore"
+	"github.com/kedoco/reunion-explore/model"
+)
+
+// makeSourceField builds the data of a source field of schema type typeID
+// holding text.
+func makeSourceField(typeID uint16, text string) []byte {
+	data := make([]byte, sourceFieldHeaderLen)
+	if text != "" {
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(text)+4))
+		data = append(data, text...)
+	}
+	binary.LittleEndian.PutUint16(data[0:], uint16(len(data)))
+	binary.LittleEndian.PutUint32(data[12:], 6)
+	binary.LittleEndian.PutUint16(data[16:], typeID)
+	return data
+}
+
+func TestParseSource(t *testing.T) {
+	recData := makePreamble()
+	recData = append(recData, makeTLVField(TagSourceTemplate, []byte{0x98, 0})...)
+	recData = append(recData, makeTLVField(TagSourceFieldFirst+1, makeSourceField(98, "1999"))...)
+	recData = append(recData, makeTLVField(TagSourceFieldFirst, makeSourceField(137, "https://example.com/"))...)
+	recData = append(recData, makeTLVField(TagSourceNote, []byte{27, 0, 0, 0})...)
+	recData = append(recData, makeTLVField(TagDisplayName, []byte("https://example.com/, 1999"))...)
+
+	ec := reunion.NewErrorCollector(0)
+	s, err := ParseSource(RawRecord{Type: RecordTypeSource, ID: 12, Data: recData}, ec)
+	if err != nil {
+		t.Fatalf("ParseSource() error = %v", err)
+	}
+	if s.Title != "https://example.com/, 1999" || s.TemplateID != 0x98 || s.NoteID != 27 {
+		t.Errorf("source = %q template %d note %d", s.Title, s.TemplateID, s.NoteID)
+	}
+	// Fields come back in tag order.
+	want := []model.SourceField{
+		{Tag: TagSourceFieldFirst, TypeID: 137, Value: "https://example.com/"},
+		{Tag: TagSourceFieldFirst + 1, TypeID: 98, Value: "1999"},
+	}
+	if len(s.Fields) != len(want) || s.Fields[0] != want[0] || s.Fields[1] != want[1] {
+		t.Errorf("Fields = %+v, want %+v", s.Fields, want)
+	}
+
+	sources := []model.Source{*s}
+	labelSources(sources, []model.EventDefinition{
+		{ID: 98, DisplayName: "Date"}, {ID: 137, DisplayName: "URL"}, {ID: 152, DisplayName: "Web Site"},
+	})
+	if l := sources[0]; l.Template != "Web Site" || l.Fields[0].Label != "URL" || l.Fields[1].Label != "Date" {
+		t.Errorf("labels = %q %q %q", l.Template, l.Fields[0].Label, l.Fields[1].Label)
+	}
+	if errs := ec.Errors(); len(errs) > 0 {
+		t.Errorf("errors = %v", errs)
+	}
+}
+
+func TestParseSourceField_Empty(t *testing.T) {
+	sf, err := ParseSourceField(makeSourceField(96, ""))
+	if err != nil {
+		t.Fatalf("ParseSourceField() error = %v", err)
+	}
+	if sf.TypeID != 96 || sf.Value != "" {
+		t.Errorf("field = %+v, want empty type 96", sf)
+	}
+}
+
+func TestParseSourceField_Truncated(t *testing.T) {
+	data := makeSourceField(91, "Author")
+	if _, err := ParseSourceField(data[:len(data)-1]); err == nil {
+		t.Error("ParseSourceField() of a truncated field succeeded")
+	}
+	if _, err := ParseSourceField(data[:sourceFieldHeaderLen+2]); err == nil {
+		t.Error("ParseSourceField() of a truncated length succeeded")
+	}
+	if _, err := ParseSourceField(data[:sourceFieldHeaderLen-1]); err == nil {
+		t.Error("ParseSourceField() of a short field succeeded")
+	}
+}
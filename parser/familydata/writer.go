@@ -0,0 +1,104 @@
+package familydata
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// recordHeaderLen is the size of the header Writer emits for each record:
+// sequence number, type code, marker, data length and record ID.
+const recordHeaderLen = 16
+
+// slotSize is the allocation unit for records. Reunion pads each record,
+// counted from its sequence number, to a multiple of 128 bytes.
+const slotSize = 128
+
+// FilePrefix returns the bytes of data that precede the first record's
+// sequence number: the file magic and fixed header fields. Writing it
+// followed by every record reproduces the file.
+func FilePrefix(data []byte, records []RawRecord) []byte {
+	if len(records) == 0 {
+		return data
+	}
+	return data[:records[0].Offset+4]
+}
+
+// Writer serializes records in the familydata layout, the inverse of
+// ScanRecords.
+//
+// ScanRecords extends each record's Data up to the next record's marker,
+// so Data ends with the next record's sequence number and type code.
+// Writer holds those 4 bytes back: they are dropped when another record
+// follows, since WriteRecord encodes that record's own header, and written
+// by Flush at the end of the file. Data that stops at the end of its
+// declared content (DataLen+4 bytes) has nothing held back.
+type Writer struct {
+	w    io.Writer
+	held []byte
+	err  error
+}
+
+// NewWriter returns a Writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WritePrefix writes the bytes that precede the first record; see
+// FilePrefix.
+func (w *Writer) WritePrefix(prefix []byte) error {
+	w.write(prefix)
+	return w.err
+}
+
+// WriteRecord writes rec's header fields followed by its Data.
+func (w *Writer) WriteRecord(rec RawRecord) error {
+	w.held = nil
+
+	var hdr [recordHeaderLen]byte
+	binary.LittleEndian.PutUint16(hdr[0:], rec.SeqNum)
+	binary.LittleEndian.PutUint16(hdr[2:], uint16(rec.Type))
+	copy(hdr[4:8], Marker)
+	binary.LittleEndian.PutUint32(hdr[8:], rec.DataLen)
+	binary.LittleEndian.PutUint32(hdr[12:], rec.ID)
+	w.write(hdr[:])
+
+	data := rec.Data
+	if len(data) >= int(rec.DataLen)+8 {
+		data, w.held = data[:len(data)-4], data[len(data)-4:]
+	}
+	w.write(data)
+	if w.err != nil {
+		return fmt.Errorf("writing record %d (type 0x%04X): %w", rec.ID, uint16(rec.Type), w.err)
+	}
+	return nil
+}
+
+// Flush writes the bytes held back from the last record. It must be
+// called after the last WriteRecord.
+func (w *Writer) Flush() error {
+	w.write(w.held)
+	w.held = nil
+	return w.err
+}
+
+func (w *Writer) write(b []byte) {
+	if w.err == nil && len(b) > 0 {
+		_, w.err = w.w.Write(b)
+	}
+}
+
+// Write writes a complete familydata file: prefix followed by records, in
+// order.
+func Write(w io.Writer, prefix []byte, records []RawRecord) error {
+	fw := NewWriter(w)
+	if err := fw.WritePrefix(prefix); err != nil {
+		return err
+	}
+	for _, rec := range records {
+		if err := fw.WriteRecord(rec); err != nil {
+			return err
+		}
+	}
+	return fw.Flush()
+}
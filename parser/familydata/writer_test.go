@@ -0,0 +1,72 @@
+package familydata
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+const sampleFamilydata = "../../testdata/Sample Family 14.familyfile14/familyfile.familydata"
+
+func readSample(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile(sampleFamilydata)
+	if err != nil {
+		t.Fatalf("reading sample: %v", err)
+	}
+	return data
+}
+
+func TestWrite_RoundTripsSample(t *testing.T) {
+	data := readSample(t)
+	records := ScanRecords(data)
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FilePrefix(data, records), records); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Fatalf("round trip differs: wrote %d bytes, read %d", buf.Len(), len(data))
+	}
+}
+
+func TestWrite_RoundTripsOverflow(t *testing.T) {
+	var data []byte
+	data = append(data, []byte("HEADER")...)
+	data = append(data, makeRecord(1, RecordTypePerson, 10, []byte("short"))...)
+	data = append(data, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE) // slack after the first record
+	data = append(data, makeRecord(2, RecordTypeFamily, 20, []byte("next"))...)
+	data = append(data, 0x01, 0x02, 0x03, 0x04) // trailing bytes after the last record
+
+	records := ScanRecords(data)
+	var buf bytes.Buffer
+	if err := Write(&buf, FilePrefix(data, records), records); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("round trip = % x\nwant % x", buf.Bytes(), data)
+	}
+}
+
+func TestWriter_HeaderFields(t *testing.T) {
+	rec := RawRecord{Type: RecordTypeNote, SeqNum: 0x0102, DataLen: 2, ID: 0x0A0B0C0D, Data: []byte{0xF0, 0xF1}}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.WriteRecord(rec); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		0x02, 0x01, 0x04, 0x21, // seq, type
+		0x05, 0x03, 0x02, 0x01, // marker
+		0x02, 0x00, 0x00, 0x00, // data length
+		0x0D, 0x0C, 0x0B, 0x0A, // ID
+		0xF0, 0xF1,
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("WriteRecord() = % x, want % x", buf.Bytes(), want)
+	}
+}
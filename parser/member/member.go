@@ -1,13 +1,18 @@
 package member
 
 import (
+	"io/fs"
+
+	reunion "github.com/kedoco/reunion-explore"
 	"github.com/kedoco/reunion-explore/bundle"
 	"github.com/kedoco/reunion-explore/model"
 	"github.com/kedoco/reunion-explore/parser/changes"
 )
 
-// ParseMembers creates Member models from bundle member directories.
-func ParseMembers(members []bundle.MemberDir) ([]model.Member, error) {
+// ParseMembers creates Member models from bundle member directories in
+// fsys. A .changes file that fails to parse is reported to ec, and the
+// changes read before the failure are kept.
+func ParseMembers(fsys fs.FS, members []bundle.MemberDir, ec *reunion.ErrorCollector) ([]model.Member, error) {
 	var result []model.Member
 
 	for _, md := range members {
@@ -20,10 +25,14 @@ func ParseMembers(members []bundle.MemberDir) ([]model.Member, error) {
 
 		if md.Changes != "" {
 			m.HasChanges = true
-			recs, err := changes.ParseChanges(md.Changes)
-			if err == nil {
-				m.Changes = recs
+			recs, err := changes.ParseChanges(fsys, md.Changes)
+			if err != nil {
+				ec.Add(md.Changes, -1, "failed to parse", err)
+			}
+			for i := range recs {
+				recs[i].Device = md.Name
 			}
+			m.Changes = recs
 		}
 
 		result = append(result, m)
@@ -2,8 +2,8 @@ package notes
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"io/fs"
+	"path"
 	"regexp"
 	"strconv"
 
@@ -13,15 +13,15 @@ import (
 // noteFilePattern matches note filenames like "p1-1106-13.note"
 var noteFilePattern = regexp.MustCompile(`^p(\d+)-(\d+)-(\d+)\.note$`)
 
-// ParseNoteFile reads and parses a single .note file.
-func ParseNoteFile(path string) (*model.Note, error) {
-	data, err := os.ReadFile(path)
+// ParseNoteFile reads and parses the .note file name in fsys.
+func ParseNoteFile(fsys fs.FS, name string) (*model.Note, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
-		return nil, fmt.Errorf("reading note file %s: %w", path, err)
+		return nil, fmt.Errorf("reading note file %s: %w", name, err)
 	}
 
 	text := string(data)
-	base := filepath.Base(path)
+	base := path.Base(name)
 
 	markup := ParseMarkup(text)
 	note := &model.Note{
@@ -48,11 +48,11 @@ func ParseNoteFile(path string) (*model.Note, error) {
 	return note, nil
 }
 
-// ParseAllNotes discovers and parses all note files from the given paths.
-func ParseAllNotes(paths []string) ([]model.Note, error) {
+// ParseAllNotes parses the named note files in fsys.
+func ParseAllNotes(fsys fs.FS, names []string) ([]model.Note, error) {
 	var notes []model.Note
-	for _, path := range paths {
-		note, err := ParseNoteFile(path)
+	for _, name := range names {
+		note, err := ParseNoteFile(fsys, name)
 		if err != nil {
 			continue // skip unparseable notes
 		}
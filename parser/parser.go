@@ -3,6 +3,10 @@ package parser
 
 import (
 	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
 	"strings"
 
 	reunion "github.com/kedoco/reunion-explore"
@@ -23,16 +27,19 @@ type V14Parser struct{}
 
 func (p *V14Parser) Version() reunion.Version { return reunion.Version14 }
 
-func (p *V14Parser) CanParse(bundlePath string) (bool, error) {
-	b, err := bundle.OpenBundle(bundlePath)
+func (p *V14Parser) CanParse(fsys fs.FS, root string) (bool, error) {
+	b, err := bundle.OpenBundleFS(fsys, root)
 	if err != nil {
 		return false, err
 	}
-	return b.FamilyData != "" && b.Signature != "", nil
+	if b.FamilyData == "" || b.Signature == "" {
+		return false, nil
+	}
+	return hasMagic(fsys, b.FamilyData, reunion.MagicBundle)
 }
 
-func (p *V14Parser) Parse(bundlePath string, opts reunion.ParseOptions) (*model.FamilyFile, error) {
-	b, err := bundle.OpenBundle(bundlePath)
+func (p *V14Parser) Parse(fsys fs.FS, root string, opts reunion.ParseOptions) (*model.FamilyFile, error) {
+	b, err := bundle.OpenBundleFS(fsys, root)
 	if err != nil {
 		return nil, fmt.Errorf("opening bundle: %w", err)
 	}
@@ -44,7 +51,7 @@ func (p *V14Parser) Parse(bundlePath string, opts reunion.ParseOptions) (*model.
 
 	// Parse signature
 	if b.Signature != "" {
-		sig, err := ParseSignature(b.Signature)
+		sig, err := ParseSignature(fsys, b.Signature)
 		if err != nil {
 			ec.Add("signature", -1, "failed to parse signature", err)
 		} else {
@@ -54,7 +61,7 @@ func (p *V14Parser) Parse(bundlePath string, opts reunion.ParseOptions) (*model.
 
 	// Parse familydata
 	if b.FamilyData != "" {
-		result, err := familydata.Parse(b.FamilyData, ec)
+		result, err := familydata.Parse(fsys, b.FamilyData, ec)
 		if err != nil {
 			return nil, fmt.Errorf("parsing familydata: %w", err)
 		}
@@ -64,33 +71,28 @@ func (p *V14Parser) Parse(bundlePath string, opts reunion.ParseOptions) (*model.
 		ff.EventDefinitions = result.EventDefinitions
 		ff.Sources = result.Sources
 		ff.MediaRefs = result.MediaRefs
+		ff.ViewStates = result.ViewStates
+		ff.Documents = result.Documents
+		ff.Reports = result.Reports
 		ff.Places = result.Places
 		// Inline notes from familydata
 		ff.Notes = result.Notes
 	}
 
-	// Enrich familydata place names with full-length names from places.cache.
-	// Familydata has correct IDs but truncated names; places.cache has full names.
-	if path, ok := b.Caches["places.cache"]; ok {
-		cachePlaces, err := cache.ParsePlaces(path)
-		if err != nil {
-			ec.Add("places.cache", -1, "failed to parse for name enrichment", err)
-		} else {
-			enrichPlaceNames(ff.Places, cachePlaces)
-		}
-	}
+	linkMediaFiles(ff.MediaRefs, b)
 
 	if path, ok := b.Caches["placeUsage.cache"]; ok {
-		usages, err := cache.ParsePlaceUsage(path)
+		usages, err := cache.ParsePlaceUsage(fsys, path)
 		if err != nil {
 			ec.Add("placeUsage.cache", -1, "failed to parse", err)
 		} else {
 			ff.PlaceUsages = usages
+			countPlaceUsages(ff.Places, usages)
 		}
 	}
 
 	if path, ok := b.Caches["fmnames.cache"]; ok {
-		names, err := cache.ParseFmNames(path)
+		names, err := cache.ParseFmNames(fsys, path)
 		if err != nil {
 			ec.Add("fmnames.cache", -1, "failed to parse", err)
 		} else {
@@ -99,7 +101,7 @@ func (p *V14Parser) Parse(bundlePath string, opts reunion.ParseOptions) (*model.
 	}
 
 	if path, ok := b.Caches["surnames.cache"]; ok {
-		entries, err := cache.ParseSurnames(path)
+		entries, err := cache.ParseSurnames(fsys, path)
 		if err != nil {
 			ec.Add("surnames.cache", -1, "failed to parse", err)
 		} else {
@@ -108,7 +110,7 @@ func (p *V14Parser) Parse(bundlePath string, opts reunion.ParseOptions) (*model.
 	}
 
 	if path, ok := b.Caches["shNames.cache"]; ok {
-		names, err := cache.ParseShNames(path)
+		names, err := cache.ParseShNames(fsys, path)
 		if err != nil {
 			ec.Add("shNames.cache", -1, "failed to parse", err)
 		} else {
@@ -116,8 +118,17 @@ func (p *V14Parser) Parse(bundlePath string, opts reunion.ParseOptions) (*model.
 		}
 	}
 
+	if path, ok := b.Caches["shGeneral.cache"]; ok {
+		terms, err := cache.ParseShGeneral(fsys, path)
+		if err != nil {
+			ec.Add("shGeneral.cache", -1, "failed to parse", err)
+		} else {
+			ff.SearchTerms = terms
+		}
+	}
+
 	if path, ok := b.Caches["timestamps.cache"]; ok {
-		entries, err := cache.ParseTimestamps(path)
+		entries, err := cache.ParseTimestamps(fsys, path)
 		if err != nil {
 			ec.Add("timestamps.cache", -1, "failed to parse", err)
 		} else {
@@ -126,7 +137,7 @@ func (p *V14Parser) Parse(bundlePath string, opts reunion.ParseOptions) (*model.
 	}
 
 	if path, ok := b.Caches["globalRecords.cache"]; ok {
-		entry, err := cache.ParseGlobalRecords(path)
+		entry, err := cache.ParseGlobalRecords(fsys, path)
 		if err != nil {
 			ec.Add("globalRecords.cache", -1, "failed to parse", err)
 		} else {
@@ -135,7 +146,7 @@ func (p *V14Parser) Parse(bundlePath string, opts reunion.ParseOptions) (*model.
 	}
 
 	if path, ok := b.Caches["bookmarks.cache"]; ok {
-		bk, err := cache.ParseBookmarks(path)
+		bk, err := cache.ParseBookmarks(fsys, path)
 		if err != nil {
 			ec.Add("bookmarks.cache", -1, "failed to parse", err)
 		} else {
@@ -143,8 +154,21 @@ func (p *V14Parser) Parse(bundlePath string, opts reunion.ParseOptions) (*model.
 		}
 	}
 
+	if path, ok := b.Caches["noteboard.cache"]; ok {
+		// Noteboards with notes come back with an error, since their
+		// notes are not decoded; keep the noteboards anyway.
+		boards, err := cache.ParseNoteboard(fsys, path)
+		switch {
+		case boards == nil:
+			ec.Add("noteboard.cache", -1, "failed to parse", err)
+		case err != nil:
+			ec.Add("noteboard.cache", -1, "noteboard notes not decoded", err)
+		}
+		ff.Noteboards = boards
+	}
+
 	if path, ok := b.Caches["colortags.cache"]; ok {
-		tags, err := cache.ParseColorTags(path)
+		tags, err := cache.ParseColorTags(fsys, path)
 		if err != nil {
 			ec.Add("colortags.cache", -1, "failed to parse", err)
 		} else {
@@ -152,8 +176,17 @@ func (p *V14Parser) Parse(bundlePath string, opts reunion.ParseOptions) (*model.
 		}
 	}
 
+	if path, ok := b.Caches["colortagsettings.cache"]; ok {
+		settings, err := cache.ParseColorTagSettings(fsys, path)
+		if err != nil {
+			ec.Add("colortagsettings.cache", -1, "failed to parse", err)
+		} else {
+			ff.ColorTags = applyColorTagSettings(ff.ColorTags, settings)
+		}
+	}
+
 	if path, ok := b.Caches["associations.cache"]; ok {
-		assocs, err := cache.ParseAssociations(path)
+		assocs, err := cache.ParseAssociations(fsys, path)
 		if err != nil {
 			ec.Add("associations.cache", -1, "failed to parse", err)
 		} else {
@@ -161,8 +194,45 @@ func (p *V14Parser) Parse(bundlePath string, opts reunion.ParseOptions) (*model.
 		}
 	}
 
+	if path, ok := b.Caches["relatives.cache"]; ok {
+		rc, err := cache.ParseRelatives(fsys, path)
+		if err != nil {
+			ec.Add("relatives.cache", -1, "failed to parse", err)
+		} else {
+			ff.Relatives = rc
+			checkRelatives(ff, ec)
+		}
+	}
+
+	if path, ok := b.Caches["pstats.cache"]; ok {
+		stats, err := cache.ParsePStats(fsys, path)
+		if err != nil {
+			ec.Add("pstats.cache", -1, "failed to parse", err)
+		} else {
+			ff.PersonStats = stats
+		}
+	}
+
+	if path, ok := b.Caches["phash.cache"]; ok {
+		hashes, err := cache.ParsePHash(fsys, path)
+		if err != nil {
+			ec.Add("phash.cache", -1, "failed to parse", err)
+		} else {
+			ff.PersonHashes = hashes
+		}
+	}
+
+	if path, ok := b.Caches["index.cache"]; ok {
+		ids, err := cache.ParseIndex(fsys, path)
+		if err != nil {
+			ec.Add("index.cache", -1, "failed to parse", err)
+		} else {
+			ff.PersonIndex = ids
+		}
+	}
+
 	if path, ok := b.Caches["find.cache"]; ok {
-		text, err := cache.ParseFind(path)
+		text, err := cache.ParseFind(fsys, path)
 		if err != nil {
 			ec.Add("find.cache", -1, "failed to parse", err)
 		} else {
@@ -171,7 +241,7 @@ func (p *V14Parser) Parse(bundlePath string, opts reunion.ParseOptions) (*model.
 	}
 
 	if path, ok := b.Caches["descriptions.cache"]; ok {
-		desc, err := cache.ParseDescriptions(path)
+		desc, err := cache.ParseDescriptions(fsys, path)
 		if err != nil {
 			ec.Add("descriptions.cache", -1, "failed to parse", err)
 		} else {
@@ -181,7 +251,7 @@ func (p *V14Parser) Parse(bundlePath string, opts reunion.ParseOptions) (*model.
 
 	// Parse note files from all members
 	if len(b.NoteFiles) > 0 {
-		fileNotes, err := notes.ParseAllNotes(b.NoteFiles)
+		fileNotes, err := notes.ParseAllNotes(fsys, b.NoteFiles)
 		if err != nil {
 			ec.Add("notes", -1, "failed to parse note files", err)
 		} else {
@@ -191,7 +261,7 @@ func (p *V14Parser) Parse(bundlePath string, opts reunion.ParseOptions) (*model.
 
 	// Parse members
 	if len(b.Members) > 0 {
-		members, err := member.ParseMembers(b.Members)
+		members, err := member.ParseMembers(fsys, b.Members, ec)
 		if err != nil {
 			ec.Add("members", -1, "failed to parse members", err)
 		} else {
@@ -199,6 +269,8 @@ func (p *V14Parser) Parse(bundlePath string, opts reunion.ParseOptions) (*model.
 		}
 	}
 
+	ff.Caches = listCaches(fsys, b, ff, ec)
+
 	// Collect warnings
 	for _, pe := range ec.Errors() {
 		ff.Warnings = append(ff.Warnings, pe.Error())
@@ -207,116 +279,77 @@ func (p *V14Parser) Parse(bundlePath string, opts reunion.ParseOptions) (*model.
 	return ff, nil
 }
 
-// enrichPlaceNames upgrades truncated familydata place names with full-length
-// names from places.cache. It first tries matching by ID. For remaining
-// unmatched places, it falls back to prefix matching, requiring the prefix
-// boundary to fall at a natural break point (comma, space) in the full name
-// to avoid false matches like "Paris" → "Paris, Texas".
-func enrichPlaceNames(places []model.Place, cachePlaces []model.Place) {
-	// Build ID-keyed lookup from cache
-	byID := make(map[uint32]string, len(cachePlaces))
-	cacheNames := make([]string, 0, len(cachePlaces))
-	for _, cp := range cachePlaces {
-		if cp.Name != "" {
-			byID[cp.ID] = cp.Name
-			cacheNames = append(cacheNames, cp.Name)
-		}
+// linkMediaFiles sets the thumbnail and member .media file of each media
+// reference. Both are named p{personID}-{key}-... or f{familyID}-{key}-...
+// with the key in hex; a .media file may instead keep the media's
+// filename.
+func linkMediaFiles(media []model.MediaRef, b *bundle.Bundle) {
+	var mediaFiles []string
+	for _, md := range b.Members {
+		mediaFiles = append(mediaFiles, md.MediaFiles...)
 	}
-
-	for i := range places {
-		name := places[i].Name
-		if name == "" {
-			continue
+	for i := range media {
+		m := &media[i]
+		prefix := fmt.Sprintf("p%d-%x-", m.PersonID, m.Key)
+		if m.FamilyID != 0 {
+			prefix = fmt.Sprintf("f%d-%x-", m.FamilyID, m.Key)
 		}
-		// Strategy 1: match by ID
-		if cn, ok := byID[places[i].ID]; ok && len(cn) > len(name) && strings.HasPrefix(cn, name) {
-			places[i].Name = cn
-			continue
-		}
-
-		// Strategy 2: prefix match with truncation detection.
-		// The binary format uses fixed-width fields, so truncation can cut
-		// mid-word ("Califo") or right after a delimiter ("Glen Cove, New ").
-		// We accept the match if:
-		//   - The name has a trailing space (clearly cut mid-field), OR
-		//   - The next char in the full name is a letter/digit (mid-word cut)
-		// We reject if the name ends at a clean word boundary and the full
-		// name continues with a delimiter (e.g., "Paris" → "Paris, Texas").
-		bestMatch := ""
-		for _, cn := range cacheNames {
-			if cn == name {
-				bestMatch = ""
+		for _, t := range b.Thumbnails {
+			if strings.HasPrefix(path.Base(t), prefix) {
+				m.Thumbnail = t
 				break
 			}
-			if len(cn) > len(name) && strings.HasPrefix(cn, name) {
-				trailingSpace := name[len(name)-1] == ' '
-				nextChar := cn[len(name)]
-				midWordCut := nextChar != ',' && nextChar != ' '
-				if trailingSpace || midWordCut {
-					if bestMatch == "" || len(cn) < len(bestMatch) {
-						bestMatch = cn
-					}
-				}
-			}
 		}
-		if bestMatch != "" {
-			places[i].Name = bestMatch
+		for _, f := range mediaFiles {
+			name := path.Base(f)
+			if strings.HasPrefix(name, prefix) || (m.Filename != "" && name == m.Filename) {
+				m.MediaFile = f
+				break
+			}
 		}
 	}
+}
 
-	// Strategy 3: fix truncated trailing components.
-	// Build component frequency from cache to identify reliable components.
-	// Components appearing only once that are prefixes of more-common ones
-	// are likely truncation artifacts in the cache itself.
-	compFreq := make(map[string]int)
-	for _, cp := range cachePlaces {
-		for _, part := range strings.Split(cp.Name, ", ") {
-			part = strings.TrimSpace(part)
-			if len(part) > 1 {
-				compFreq[part]++
-			}
-		}
+// countPlaceUsages sets the usage count of each place from its entries in
+// placeUsage.cache.
+func countPlaceUsages(places []model.Place, usages []model.PlaceUsage) {
+	counts := make(map[uint32]int, len(usages))
+	for _, u := range usages {
+		counts[u.PlaceID] += len(u.Entries)
 	}
-	// Build clean component set: exclude single-occurrence components that
-	// are strict prefixes of a more-frequent component.
-	components := make(map[string]bool)
-	for comp, freq := range compFreq {
-		if freq == 1 {
-			isPrefix := false
-			for other, oFreq := range compFreq {
-				if oFreq > 1 && len(other) > len(comp) && strings.HasPrefix(other, comp) {
-					isPrefix = true
-					break
-				}
-			}
-			if isPrefix {
-				continue // likely a truncation artifact
-			}
-		}
-		components[comp] = true
+	for i := range places {
+		places[i].UsageCount = counts[places[i].ID]
 	}
+}
 
-	for i := range places {
-		name := places[i].Name
-		commaIdx := strings.LastIndex(name, ", ")
-		if commaIdx == -1 {
+// applyColorTagSettings sets the color and label of each color tag from
+// colortagsettings.cache, adding tags that mark no one.
+func applyColorTagSettings(tags, settings []model.ColorTag) []model.ColorTag {
+	for _, st := range settings {
+		i := slices.IndexFunc(tags, func(t model.ColorTag) bool { return t.ID == st.ID })
+		if i < 0 {
+			st.PersonIDs = []uint32{}
+			tags = append(tags, st)
 			continue
 		}
-		lastPart := name[commaIdx+2:]
-		if lastPart == "" || components[lastPart] {
-			continue // empty or already a known-good component
-		}
-		// Find the shortest known component this is a prefix of
-		var match string
-		for comp := range components {
-			if len(comp) > len(lastPart) && strings.HasPrefix(comp, lastPart) {
-				if match == "" || len(comp) < len(match) {
-					match = comp
-				}
-			}
-		}
-		if match != "" {
-			places[i].Name = name[:commaIdx+2] + match
+		tags[i].Color, tags[i].Label = st.Color, st.Label
+		if tags[i].PersonID == 0 {
+			tags[i].PersonID = st.PersonID
 		}
 	}
+	return tags
+}
+
+// hasMagic reports whether the file name in fsys starts with magic.
+func hasMagic(fsys fs.FS, name, magic string) (bool, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	buf := make([]byte, len(magic))
+	if _, err := io.ReadFull(f, buf); err != nil {
+		return false, nil
+	}
+	return string(buf) == magic, nil
 }
@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	reunion "github.com/kedoco/reunion-explore"
+	"github.com/kedoco/reunion-explore/index"
+	"github.com/kedoco/reunion-explore/model"
+)
+
+// checkRelatives compares the relationships recorded in relatives.cache
+// with those derived from the family records, adding a warning for each
+// person on which they disagree. A stale cache is the usual cause.
+func checkRelatives(ff *model.FamilyFile, ec *reunion.ErrorCollector) {
+	rc := ff.Relatives
+	cached := make(map[uint32]model.Relative, len(rc.Relatives))
+	for _, r := range rc.Relatives {
+		cached[r.PersonID] = r
+	}
+	derived := index.BuildIndex(ff).Relatives(rc.PersonID)
+
+	ids := slices.Collect(maps.Keys(cached))
+	for id := range derived {
+		if _, ok := cached[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		c, d := cached[id], derived[id]
+		if c.Code() == d.Code() {
+			continue
+		}
+		// Entries start after the 20-byte header, from person 1.
+		ec.Add("relatives.cache", 20+4*(int(id)-1),
+			fmt.Sprintf("person %d is %s of person %d, family records give %s", id, c, rc.PersonID, d), nil)
+	}
+}
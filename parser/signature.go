@@ -2,13 +2,13 @@ package parser
 
 import (
 	"fmt"
-	"os"
+	"io/fs"
 	"strings"
 )
 
 // ParseSignature reads the familyfile.signature file and returns its content.
-func ParseSignature(path string) (string, error) {
-	data, err := os.ReadFile(path)
+func ParseSignature(fsys fs.FS, name string) (string, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return "", fmt.Errorf("reading signature: %w", err)
 	}
@@ -2,49 +2,72 @@
 //
 // Use Open() to parse a .familyfile14 bundle directory into a structured
 // FamilyFile model that can be serialized to JSON.
+// OpenFS reads the same from any io/fs file system, such as a zip archive.
 package reunion
 
 import (
 	"fmt"
+	"io/fs"
+	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 
 	"github.com/kedoco/reunion-explore/model"
 )
 
 // Open parses the Reunion bundle at bundlePath and returns a FamilyFile.
-// The version is detected from the bundle directory extension.
+// The version is detected from the bundle's content by Detect, so a
+// renamed bundle opens too. Files written by other Reunion versions give
+// ErrUnsupportedVer.
 func Open(bundlePath string, opts *ParseOptions) (*model.FamilyFile, error) {
+	dir, root := splitPath(bundlePath)
+	ff, err := OpenFS(os.DirFS(dir), root, opts)
+	if err != nil {
+		return nil, err
+	}
+	// Member paths are paths in the file system; report them on disk.
+	for i := range ff.Members {
+		m := &ff.Members[i]
+		m.DirPath = diskPath(dir, m.DirPath)
+		for j, name := range m.NoteFiles {
+			m.NoteFiles[j] = diskPath(dir, name)
+		}
+	}
+	return ff, nil
+}
+
+// OpenFS parses the Reunion bundle at root in fsys and returns a
+// FamilyFile. root is the bundle directory; "." names the top of fsys.
+// Paths in the returned model, such as member directories, are paths in
+// fsys.
+func OpenFS(fsys fs.FS, root string, opts *ParseOptions) (*model.FamilyFile, error) {
 	if opts == nil {
 		opts = &ParseOptions{}
 	}
 
-	v, err := detectVersion(bundlePath)
+	res, err := DetectFS(fsys, root)
 	if err != nil {
 		return nil, err
 	}
+	if res.Version == 0 {
+		reason := strings.Join(res.Reasons, "; ")
+		if res.Magic != "" && magicVersions[res.Magic] == nil {
+			return nil, fmt.Errorf("%w: %s", ErrBadMagic, reason)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrNotABundle, reason)
+	}
 
-	vp, err := getParser(v)
+	vp, err := getParser(res.Version)
 	if err != nil {
 		return nil, err
 	}
 
-	return vp.Parse(bundlePath, *opts)
+	return vp.Parse(fsys, root, *opts)
 }
 
-func detectVersion(bundlePath string) (Version, error) {
-	ext := filepath.Ext(bundlePath)
-	if !strings.HasPrefix(ext, ".familyfile") {
-		return 0, fmt.Errorf("%w: extension %q", ErrNotABundle, ext)
-	}
-	numStr := strings.TrimPrefix(ext, ".familyfile")
-	if numStr == "" {
-		return 0, fmt.Errorf("%w: no version number in extension %q", ErrUnsupportedVer, ext)
-	}
-	n, err := strconv.Atoi(numStr)
-	if err != nil {
-		return 0, fmt.Errorf("%w: %q", ErrUnsupportedVer, ext)
+func diskPath(dir, name string) string {
+	if name == "" {
+		return ""
 	}
-	return Version(n), nil
+	return filepath.Join(dir, filepath.FromSlash(name))
 }
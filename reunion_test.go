@@ -1,10 +1,24 @@
 package reunion_test
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	reunion "github.com/kedoco/reunion-explore"
+	"github.com/kedoco/reunion-explore/bundle"
+	"github.com/kedoco/reunion-explore/index"
 	"github.com/kedoco/reunion-explore/model"
 	_ "github.com/kedoco/reunion-explore/parser" // register v14 parser
 )
@@ -45,7 +59,8 @@ func TestOpen(t *testing.T) {
 		{"EventDefinitions", len(ff.EventDefinitions), 147},
 		{"Sources", len(ff.Sources), 25},
 		{"Notes", len(ff.Notes), 28},
-		{"MediaRefs", len(ff.MediaRefs), 36},
+		{"MediaRefs", len(ff.MediaRefs), 38},
+		{"ViewStates", len(ff.ViewStates), 36},
 		{"PlaceUsages", len(ff.PlaceUsages), 52},
 	}
 	for _, c := range counts {
@@ -117,6 +132,29 @@ func TestOpen(t *testing.T) {
 		t.Errorf("JFK birth citation sourceID = %d, want 6", birthEvt.SourceCitations[0].SourceID)
 	}
 
+	// JFK has three photos; Joseph P. Kennedy's are linked by bookmark
+	var jfkMedia []model.MediaRef
+	var banker *model.MediaRef
+	for i, m := range ff.MediaRefs {
+		if m.PersonID == 4 {
+			jfkMedia = append(jfkMedia, m)
+		}
+		if m.PersonID == 1 && m.Tag == 0x0258 {
+			banker = &ff.MediaRefs[i]
+		}
+	}
+	if len(jfkMedia) != 3 {
+		t.Errorf("JFK media count = %d, want 3", len(jfkMedia))
+	} else if m := jfkMedia[0]; m.Caption != "jfk 1947" || m.Filename != "jfk 1947.jpg" || m.Type != "image/jpeg" ||
+		path.Base(m.Thumbnail) != "p4-6abf1-1000.jpg" {
+		t.Errorf("JFK media[0] = %q %q %q %q", m.Caption, m.Filename, m.Type, m.Thumbnail)
+	}
+	if banker == nil {
+		t.Error("media 0x0258 of person 1 not found")
+	} else if want := "/Users/kevin/Pictures/Reunion Pictures/Sample Pictures/kennedy, joseph p banker.jpg"; banker.Path != want {
+		t.Errorf("person 1 media Path = %q, want %q", banker.Path, want)
+	}
+
 	// Source titles should be clean (using tag 0x0014)
 	for _, src := range ff.Sources {
 		for _, r := range src.Title {
@@ -127,6 +165,122 @@ func TestOpen(t *testing.T) {
 		}
 	}
 
+	// Source 6 is a book; its fields are labelled from schema records.
+	for _, src := range ff.Sources {
+		switch src.ID {
+		case 6:
+			var got []string
+			for _, f := range src.Fields {
+				got = append(got, f.Label+": "+f.Value)
+			}
+			want := []string{
+				"Title: The Fitzgeralds and the Kennedys, An American Saga",
+				"Author: Doris Kearns Goodwin",
+				"Publisher: Simon and Schuster",
+				"Date: 1987",
+			}
+			if src.Template != "Book" || !slices.Equal(got, want) {
+				t.Errorf("source 6 = %q %q, want Book %q", src.Template, got, want)
+			}
+		case 2:
+			if src.NoteID != 24 {
+				t.Errorf("source 2 NoteID = %d, want 24", src.NoteID)
+			}
+		}
+	}
+
+	// Place 35 is Boston, with coordinates and uses from placeUsage.cache
+	var boston *model.Place
+	for i := range ff.Places {
+		if ff.Places[i].ID == 35 {
+			boston = &ff.Places[i]
+		}
+	}
+	if boston == nil {
+		t.Error("place 35 not found")
+	} else if boston.Name != "Boston, MA" || boston.Coordinates == nil || boston.UsageCount != 16 {
+		t.Errorf("place 35 = %q %v %d uses", boston.Name, boston.Coordinates, boston.UsageCount)
+	}
+
+	// relatives.cache is relative to JFK and agrees with the family
+	// records; family 1's children past the sixth are JFK's siblings too.
+	if ff.Relatives == nil || ff.Relatives.PersonID != 4 {
+		t.Errorf("Relatives = %+v, want home person 4", ff.Relatives)
+	} else {
+		rels := make(map[uint32]string)
+		for _, r := range ff.Relatives.Relatives {
+			rels[r.PersonID] = r.String()
+		}
+		for id, want := range map[uint32]string{17: "spouse", 12: "sibling", 42: "aunt/uncle", 27: "spouse's parent", 31: "grandchild"} {
+			if rels[id] != want {
+				t.Errorf("relative %d = %q, want %q", id, rels[id], want)
+			}
+		}
+	}
+	for _, w := range ff.Warnings {
+		if strings.HasPrefix(w, "relatives.cache") {
+			t.Errorf("unexpected warning: %s", w)
+		}
+	}
+
+	// pstats.cache, phash.cache and index.cache
+	for _, st := range ff.PersonStats {
+		if st.PersonID == 4 && (st.Spouses != 1 || st.AncestorGenerations != 3 || st.DescendantGenerations != 2 || st.Descendants != 6) {
+			t.Errorf("person 4 stats = %+v", st)
+		}
+	}
+	if len(ff.PersonHashes) < 4 {
+		t.Errorf("PersonHashes = %d, want 49", len(ff.PersonHashes))
+	} else if h := ff.PersonHashes[3]; h.PersonID != 4 || h.Birth.String() != "29 May 1917" || h.Death.String() != "22 Nov 1963" {
+		t.Errorf("person 4 hash = %+v", h)
+	}
+	if len(ff.PersonIndex) != 49 || ff.PersonIndex[0] != 13 {
+		t.Errorf("PersonIndex = %v, want 49 IDs starting with 13 (BENNETT)", ff.PersonIndex)
+	}
+	for _, c := range ff.Caches {
+		if c.Status == model.CacheFailed {
+			t.Errorf("cache %s failed: %s", c.Name, c.Error)
+		}
+		if c.Name == "pstats.cache" && (c.Magic != "hp" || c.Size != 484 || c.Records != 4) {
+			t.Errorf("pstats.cache = %+v", c)
+		}
+		if c.Name == "associations.cache" && c.Status != model.CacheNotParsed {
+			t.Errorf("associations.cache status = %s, want not parsed", c.Status)
+		}
+	}
+
+	// Color tag 1 marks Patrick KENNEDY (21) and his 28 descendants
+	if len(ff.ColorTags) != 1 {
+		t.Errorf("ColorTags = %d, want 1", len(ff.ColorTags))
+	} else if ct := ff.ColorTags[0]; ct.Label != "Descendant of Patrick KENNEDY" || ct.PersonID != 21 ||
+		len(ct.PersonIDs) != 29 || !slices.Contains(ct.PersonIDs, 21) || slices.Contains(ct.PersonIDs, 17) {
+		t.Errorf("color tag = %+v", ct)
+	}
+
+	if len(ff.Bookmarks) != 1 || ff.Bookmarks[0].Label != "Default bookmarks set" || len(ff.Bookmarks[0].PersonIDs) != 0 {
+		t.Errorf("Bookmarks = %+v, want one empty default set", ff.Bookmarks)
+	}
+	if len(ff.Noteboards) != 1 || ff.Noteboards[0].Label != "Default noteboard set" || ff.Noteboards[0].NoteCount != 0 {
+		t.Errorf("Noteboards = %+v, want one default noteboard", ff.Noteboards)
+	}
+	if len(ff.SearchNames) != 61 || ff.SearchNames[0].Name != "ANTHONY PAUL KENNEDY" {
+		t.Errorf("SearchNames = %d, want 61 starting with ANTHONY PAUL KENNEDY", len(ff.SearchNames))
+	}
+
+	// Person 1 was the last record saved
+	for _, p := range ff.Persons {
+		want := "2024-02-20T19:29:17Z"
+		if p.ID == 1 {
+			want = "2026-02-12T01:43:01Z"
+		}
+		if got := p.Modified.Format(time.RFC3339); got != want {
+			t.Errorf("person %d modified %s, want %s", p.ID, got, want)
+		}
+	}
+	if len(ff.Timestamps) != 1 {
+		t.Errorf("Timestamps = %+v, want one entry", ff.Timestamps)
+	}
+
 	// JSON round-trip
 	jsonData, err := ff.ToJSON()
 	if err != nil {
@@ -150,6 +304,162 @@ func TestOpen(t *testing.T) {
 	}
 }
 
+func TestOpenFS_Changes(t *testing.T) {
+	familydata, err := os.ReadFile("testdata/Sample Family 14.familyfile14/familyfile.familydata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Person 4's record as saved in the sample, and a deleted family 99:
+	// each is padding(4) + seq(2) + type(2) + marker(4) + size(4) + ID(4)
+	// + data.
+	person := personRecord(t, familydata, 4)
+	deleted := binary.LittleEndian.AppendUint16(make([]byte, 4), 2)
+	deleted = binary.LittleEndian.AppendUint16(deleted, 0x20C8)
+	deleted = append(deleted, 0x05, 0x03, 0x02, 0x01)
+	deleted = binary.LittleEndian.AppendUint32(deleted, 4)
+	deleted = binary.LittleEndian.AppendUint32(deleted, 99)
+	deleted = binary.LittleEndian.AppendUint32(deleted, uint32(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC).Unix()))
+
+	changes := []byte("0sfr")
+	for _, rec := range [][]byte{person, deleted} {
+		changes = binary.LittleEndian.AppendUint32(changes, uint32(len(rec)))
+		changes = append(changes, rec...)
+	}
+	fsys := fstest.MapFS{
+		"Family.familyfile14/familyfile.familydata":        {Data: familydata},
+		"Family.familyfile14/familyfile.signature":         {Data: []byte("42\n")},
+		"Family.familyfile14/Laptop.member/Laptop.changes": {Data: changes},
+	}
+
+	ff, err := reunion.OpenFS(fsys, "Family.familyfile14", nil)
+	if err != nil {
+		t.Fatalf("OpenFS() error: %v", err)
+	}
+	if len(ff.Members) != 1 || len(ff.Members[0].Changes) != 2 {
+		t.Fatalf("Members = %+v, want Laptop with 2 changes", ff.Members)
+	}
+	upd, del := ff.Members[0].Changes[0], ff.Members[0].Changes[1]
+	if upd.Device != "Laptop" || upd.Operation != model.ChangeUpdate || upd.RecordType != 0x20C4 || upd.RecordID != 4 ||
+		upd.Person == nil || upd.Person.Surname != "KENNEDY" || upd.Time.Format(time.RFC3339) != "2024-02-20T19:29:17Z" {
+		t.Errorf("change 1 = %+v, want an update of person 4", upd)
+	}
+	if del.Operation != model.ChangeDelete || del.RecordType != 0x20C8 || del.RecordID != 99 || del.Family != nil {
+		t.Errorf("change 2 = %+v, want a deletion of family 99", del)
+	}
+
+	fsys["Family.familyfile14/Laptop.member/Laptop.changes"] = &fstest.MapFile{Data: []byte("junk")}
+	ff, err = reunion.OpenFS(fsys, "Family.familyfile14", nil)
+	if err != nil {
+		t.Fatalf("OpenFS() error: %v", err)
+	}
+	if !slices.ContainsFunc(ff.Warnings, func(w string) bool { return strings.Contains(w, "unexpected magic") }) {
+		t.Errorf("Warnings = %q, want an unexpected magic warning", ff.Warnings)
+	}
+}
+
+func TestOpenFS_NoteboardNotes(t *testing.T) {
+	read := func(name string) []byte {
+		data, err := os.ReadFile("testdata/Sample Family 14.familyfile14/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	// Give the sample's empty noteboard two notes: the word before the
+	// label is its note count.
+	noteboard := read("noteboard.cache")
+	binary.LittleEndian.PutUint32(noteboard[0x28:], 2)
+	fsys := fstest.MapFS{
+		"Family.familyfile14/familyfile.familydata": {Data: read("familyfile.familydata")},
+		"Family.familyfile14/familyfile.signature":  {Data: []byte("42\n")},
+		"Family.familyfile14/noteboard.cache":       {Data: noteboard},
+	}
+
+	ff, err := reunion.OpenFS(fsys, "Family.familyfile14", nil)
+	if err != nil {
+		t.Fatalf("OpenFS() error: %v", err)
+	}
+	if len(ff.Noteboards) != 1 || ff.Noteboards[0].NoteCount != 2 {
+		t.Errorf("Noteboards = %+v, want one noteboard with 2 notes", ff.Noteboards)
+	}
+	if !slices.ContainsFunc(ff.Warnings, func(w string) bool { return strings.Contains(w, "noteboard notes not decoded") }) {
+		t.Errorf("Warnings = %q, want the undecoded notes reported", ff.Warnings)
+	}
+	i := slices.IndexFunc(ff.Caches, func(c model.CacheFile) bool { return c.Name == "noteboard.cache" })
+	if i < 0 || ff.Caches[i].Status != model.CacheParsed || ff.Caches[i].Records != 1 {
+		t.Errorf("Caches = %+v, want noteboard.cache parsed with 1 record", ff.Caches)
+	}
+}
+
+func TestOpenFS_Conflicts(t *testing.T) {
+	familydata, err := os.ReadFile("testdata/Sample Family 14.familyfile14/familyfile.familydata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both members saved person 4 at the same sequence number, one with a
+	// new given name; both also synced family 1 unchanged.
+	person := personRecord(t, familydata, 4)
+	renamed := bytes.Replace(person, []byte("John Fitzgerald"), []byte("Jack Fitzgerald"), 1)
+	changes := func(recs ...[]byte) []byte {
+		out := []byte("0sfr")
+		for _, rec := range recs {
+			out = binary.LittleEndian.AppendUint32(out, uint32(len(rec)))
+			out = append(out, rec...)
+		}
+		return out
+	}
+	fsys := fstest.MapFS{
+		"Family.familyfile14/familyfile.familydata":          {Data: familydata},
+		"Family.familyfile14/familyfile.signature":           {Data: []byte("42\n")},
+		"Family.familyfile14/Desktop.member/Desktop.changes": {Data: changes(person)},
+		"Family.familyfile14/Laptop.member/Laptop.changes":   {Data: changes(person, renamed)},
+	}
+
+	ff, err := reunion.OpenFS(fsys, "Family.familyfile14", nil)
+	if err != nil {
+		t.Fatalf("OpenFS() error: %v", err)
+	}
+	conflicts := index.BuildIndex(ff).Conflicts
+	if len(conflicts) != 1 {
+		t.Fatalf("Conflicts = %+v, want person 4", conflicts)
+	}
+	cf := conflicts[0]
+	if cf.RecordType != 0x20C4 || cf.RecordID != 4 || cf.SeqNum != 23 || len(cf.Versions) != 2 {
+		t.Errorf("conflict = person %d seq %d with %d versions, want person 4 seq 23 with 2", cf.RecordID, cf.SeqNum, len(cf.Versions))
+	} else if cf.Versions[0].Device != "Desktop" || cf.Versions[1].Person.GivenName != "Jack Fitzgerald" {
+		t.Errorf("versions = %s %q, %s %q", cf.Versions[0].Device, cf.Versions[0].Person.GivenName, cf.Versions[1].Device, cf.Versions[1].Person.GivenName)
+	}
+
+	// Once Desktop has the same version, there is nothing to resolve.
+	fsys["Family.familyfile14/Desktop.member/Desktop.changes"] = &fstest.MapFile{Data: changes(renamed)}
+	if ff, err = reunion.OpenFS(fsys, "Family.familyfile14", nil); err != nil {
+		t.Fatalf("OpenFS() error: %v", err)
+	}
+	if conflicts := index.BuildIndex(ff).Conflicts; len(conflicts) != 0 {
+		t.Errorf("Conflicts = %+v, want none", conflicts)
+	}
+}
+
+// personRecord returns the bytes of person id's record in familydata,
+// from its padding to the end of its declared data.
+func personRecord(t *testing.T, familydata []byte, id uint32) []byte {
+	t.Helper()
+	marker := []byte{0x05, 0x03, 0x02, 0x01}
+	for pos := 0; ; {
+		i := bytes.Index(familydata[pos:], marker)
+		if i < 0 {
+			t.Fatalf("person %d not found", id)
+		}
+		m := pos + i
+		typ := binary.LittleEndian.Uint16(familydata[m-2:])
+		size := binary.LittleEndian.Uint32(familydata[m+4:])
+		if typ == 0x20C4 && binary.LittleEndian.Uint32(familydata[m+8:]) == id {
+			return familydata[m-8 : m+12+int(size)]
+		}
+		pos = m + 4
+	}
+}
+
 func TestOpen_NotABundle(t *testing.T) {
 	_, err := reunion.Open("/tmp/not-a-bundle.txt", nil)
 	if err == nil {
@@ -163,3 +473,232 @@ func TestOpen_MissingBundle(t *testing.T) {
 		t.Error("Open() should error for missing bundle")
 	}
 }
+
+// copySample copies the sample bundle to a directory named name in a
+// temporary directory.
+func copySample(t *testing.T, name string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.CopyFS(path, os.DirFS("testdata/Sample Family 14.familyfile14")); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// withHeaderVersion sets the version field in the familydata header of
+// the bundle at path.
+func withHeaderVersion(t *testing.T, path string, v uint16) string {
+	t.Helper()
+	name := filepath.Join(path, "familyfile.familydata")
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[0x0C], data[0x0D] = byte(v), byte(v>>8)
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpen_Versions(t *testing.T) {
+	sample, err := os.ReadFile("testdata/Sample Family 14.familyfile14/familyfile.familydata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A single file with the Reunion 9 magic. Only Reunion 14 files are
+	// parsed; other versions must be detected and refused.
+	v9 := filepath.Join(t.TempDir(), "Family File")
+	if err := os.WriteFile(v9, append([]byte(reunion.MagicV9), sample[8:]...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want int // 0: ErrUnsupportedVer
+	}{
+		{copySample(t, "Sample.familyfile"), 14}, // no number: from the content
+		{withHeaderVersion(t, copySample(t, "Sample.familyfile12"), 12), 0},
+		{withHeaderVersion(t, copySample(t, "Sample"), 10), 0},
+		{v9, 0},
+	}
+	for _, tt := range tests {
+		ff, err := reunion.Open(tt.path, nil)
+		if tt.want == 0 {
+			if !errors.Is(err, reunion.ErrUnsupportedVer) {
+				t.Errorf("Open(%s) error = %v, want ErrUnsupportedVer", filepath.Base(tt.path), err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Open(%s) error: %v", filepath.Base(tt.path), err)
+			continue
+		}
+		if ff.Version != tt.want {
+			t.Errorf("Open(%s).Version = %d, want %d", filepath.Base(tt.path), ff.Version, tt.want)
+		}
+		if len(ff.Persons) != 49 {
+			t.Errorf("Open(%s) found %d persons, want 49", filepath.Base(tt.path), len(ff.Persons))
+		}
+	}
+}
+
+func TestOpen_BadMagic(t *testing.T) {
+	bundle := copySample(t, "Sample.familyfile")
+	data := filepath.Join(bundle, "familyfile.familydata")
+	if err := os.WriteFile(data, []byte("NOTREUNIONDATA"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reunion.Open(bundle, nil); !errors.Is(err, reunion.ErrBadMagic) {
+		t.Errorf("Open(.familyfile with bad magic) error = %v, want ErrBadMagic", err)
+	}
+
+	single := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(single, []byte("NOTREUNIONDATA"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reunion.Open(single, nil); !errors.Is(err, reunion.ErrBadMagic) {
+		t.Errorf("Open(file with unknown magic) error = %v, want ErrBadMagic", err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	zipFile := filepath.Join(t.TempDir(), "Family.zip")
+	if err := os.WriteFile(zipFile, []byte("PK\x03\x04 zipped bundle"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	noData := copySample(t, "Empty.familyfile14")
+	if err := os.Remove(filepath.Join(noData, "familyfile.familydata")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		version    reunion.Version
+		confidence reunion.Confidence
+	}{
+		{"sample", "testdata/Sample Family 14.familyfile14", 14, reunion.ConfidenceHigh},
+		{"renamed", copySample(t, "Kennedy backup"), 14, reunion.ConfidenceHigh},
+		{"wrong extension", copySample(t, "Sample.familyfile12"), 14, reunion.ConfidenceMedium},
+		{"no version field", withHeaderVersion(t, copySample(t, "Sample"), 0), 14, reunion.ConfidenceMedium},
+		{"no familydata", noData, 14, reunion.ConfidenceLow},
+		{"zip", zipFile, 0, reunion.ConfidenceNone},
+		{"not a bundle", t.TempDir(), 0, reunion.ConfidenceNone},
+	}
+	for _, tt := range tests {
+		res, err := reunion.Detect(tt.path)
+		if err != nil {
+			t.Errorf("%s: Detect() error: %v", tt.name, err)
+			continue
+		}
+		if res.Version != tt.version || res.Confidence != tt.confidence {
+			t.Errorf("%s: Detect() = version %d, %s confidence, want %d, %s; reasons: %q",
+				tt.name, res.Version, res.Confidence, tt.version, tt.confidence, res.Reasons)
+		}
+		if len(res.Reasons) == 0 {
+			t.Errorf("%s: Detect() gave no reasons", tt.name)
+		}
+	}
+
+	if _, err := reunion.Detect("/tmp/nonexistent.familyfile14"); !errors.Is(err, reunion.ErrNotABundle) {
+		t.Errorf("Detect(missing path) error = %v, want ErrNotABundle", err)
+	}
+}
+
+func TestOpenFS_Zip(t *testing.T) {
+	const name = "Sample Family 14.familyfile14"
+	want, err := reunion.Open("testdata/"+name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A zip of the bundle directory, as Finder or Dropbox makes it.
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	testdata := os.DirFS("testdata")
+	err = fs.WalkDir(testdata, name, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(testdata, p)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(p)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := bundle.Find(zr)
+	if err != nil {
+		t.Fatalf("bundle.Find() error: %v", err)
+	}
+	if root != name {
+		t.Errorf("bundle.Find() = %q, want %q", root, name)
+	}
+	ff, err := reunion.OpenFS(zr, root, nil)
+	if err != nil {
+		t.Fatalf("OpenFS() error: %v", err)
+	}
+	if ff.Version != 14 || ff.Signature != want.Signature {
+		t.Errorf("OpenFS() = version %d, signature %q, want 14, %q", ff.Version, ff.Signature, want.Signature)
+	}
+	if len(ff.Persons) != len(want.Persons) || len(ff.Families) != len(want.Families) || len(ff.Places) != len(want.Places) {
+		t.Errorf("OpenFS() found %d persons, %d families, %d places, want %d, %d, %d",
+			len(ff.Persons), len(ff.Families), len(ff.Places), len(want.Persons), len(want.Families), len(want.Places))
+	}
+}
+
+func TestOpenFS_MapFS(t *testing.T) {
+	familydata, err := os.ReadFile("testdata/Sample Family 14.familyfile14/familyfile.familydata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"Family.familyfile14/familyfile.familydata":                     {Data: familydata},
+		"Family.familyfile14/familyfile.signature":                      {Data: []byte("42\n")},
+		"Family.familyfile14/Laptop.member/Laptop.notes/p4-1106-0.note": {Data: []byte("Born in Brookline.")},
+		"Family.familyfile14/Laptop.member/Laptop.changes":              {Data: []byte("0sfr")},
+	}
+
+	ff, err := reunion.OpenFS(fsys, "Family.familyfile14", nil)
+	if err != nil {
+		t.Fatalf("OpenFS() error: %v", err)
+	}
+	if ff.Signature != "42" || len(ff.Persons) != 49 {
+		t.Errorf("OpenFS() = signature %q, %d persons, want 42, 49", ff.Signature, len(ff.Persons))
+	}
+	if len(ff.Members) != 1 || ff.Members[0].Name != "Laptop" || !ff.Members[0].HasChanges {
+		t.Fatalf("Members = %+v, want Laptop with changes", ff.Members)
+	}
+	if got := ff.Members[0].DirPath; got != "Family.familyfile14/Laptop.member" {
+		t.Errorf("Members[0].DirPath = %q, want the path in the file system", got)
+	}
+	var note *model.Note
+	for i := range ff.Notes {
+		if ff.Notes[i].Filename == "p4-1106-0.note" {
+			note = &ff.Notes[i]
+		}
+	}
+	if note == nil || note.PersonID != 4 || note.DisplayText != "Born in Brookline." {
+		t.Errorf("note file = %+v, want person 4 \"Born in Brookline.\"", note)
+	}
+
+	if _, err := reunion.OpenFS(fsys, "Missing.familyfile14", nil); !errors.Is(err, reunion.ErrNotABundle) {
+		t.Errorf("OpenFS(missing root) error = %v, want ErrNotABundle", err)
+	}
+}
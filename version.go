@@ -2,6 +2,7 @@ package reunion
 
 import (
 	"fmt"
+	"io/fs"
 
 	"github.com/kedoco/reunion-explore/model"
 )
@@ -10,9 +11,33 @@ import (
 type Version int
 
 const (
+	Version8  Version = 8
+	Version9  Version = 9
+	Version10 Version = 10
+	Version11 Version = 11
+	Version12 Version = 12
+	Version13 Version = 13
 	Version14 Version = 14
 )
 
+// Magic strings at the start of a family file's data. Reunion 8 and 9
+// store a family file as a single file; Reunion 10 and later use a bundle
+// directory whose familyfile.familydata starts with MagicBundle. Only
+// Reunion 14 files are parsed; the others are detected so that they can be
+// reported as unsupported.
+const (
+	MagicV8     = "UDS3R~U8"
+	MagicV9     = "3SDU9U~R"
+	MagicBundle = "3SDUAU~R"
+)
+
+// magicVersions lists the versions that write each magic, newest first.
+var magicVersions = map[string][]Version{
+	MagicV8:     {Version8},
+	MagicV9:     {Version9},
+	MagicBundle: {Version14, Version13, Version12, Version11, Version10},
+}
+
 // ParseOptions controls parsing behavior.
 type ParseOptions struct {
 	// MaxErrors is the maximum number of non-fatal errors to collect before
@@ -21,10 +46,12 @@ type ParseOptions struct {
 }
 
 // VersionParser is the interface each version-specific parser must implement.
+// The family file is read from fsys: root is the bundle directory, or the
+// family file itself for versions that use a single file.
 type VersionParser interface {
 	Version() Version
-	CanParse(bundlePath string) (bool, error)
-	Parse(bundlePath string, opts ParseOptions) (*model.FamilyFile, error)
+	CanParse(fsys fs.FS, root string) (bool, error)
+	Parse(fsys fs.FS, root string, opts ParseOptions) (*model.FamilyFile, error)
 }
 
 var registry = map[Version]VersionParser{}
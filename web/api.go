@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/kedoco/reunion-explore/index"
 	"github.com/kedoco/reunion-explore/model"
@@ -15,9 +16,12 @@ import (
 
 // PersonRef is a lightweight person reference for lists and links.
 type PersonRef struct {
-	ID   uint32 `json:"id"`
-	Name string `json:"name"`
-	Sex  string `json:"sex"`
+	ID    uint32 `json:"id"`
+	Name  string `json:"name"`
+	Sex   string `json:"sex"`
+	Birth string `json:"birth,omitempty"`
+	Death string `json:"death,omitempty"`
+	Color string `json:"color,omitempty"` // "#rrggbb" of the person's first color tag
 }
 
 // TreeEntryRef is a lightweight ancestor/descendant entry.
@@ -53,6 +57,14 @@ type PersonDetail struct {
 	Children        []PersonRef             `json:"children,omitempty"`
 	Parents         []PersonRef             `json:"parents,omitempty"`
 	Siblings        []PersonRef             `json:"siblings,omitempty"`
+	Media           []model.MediaRef        `json:"media,omitempty"`
+	Modified        time.Time               `json:"modified,omitzero"`
+}
+
+// BookmarkSetRef is a bookmark set with its persons resolved.
+type BookmarkSetRef struct {
+	Label   string      `json:"label,omitempty"`
+	Persons []PersonRef `json:"persons"`
 }
 
 // ResolvedEvent is a person event with resolved schema and place names.
@@ -61,6 +73,7 @@ type ResolvedEvent struct {
 	SchemaName      string                  `json:"schema_name,omitempty"`
 	Tag             uint16                  `json:"tag"`
 	Date            string                  `json:"date,omitempty"`
+	DateDetail      *model.Date             `json:"date_detail,omitempty"`
 	Text            string                  `json:"text,omitempty"`
 	Places          []PlaceRef              `json:"places,omitempty"`
 	SourceCitations []SourceCitationDisplay  `json:"source_citations,omitempty"`
@@ -68,6 +81,17 @@ type ResolvedEvent struct {
 	IsFact          bool                    `json:"is_fact,omitempty"`
 }
 
+// TimelineEntry is a dated person or family event.
+type TimelineEntry struct {
+	Date       string     `json:"date"`
+	DateDetail model.Date `json:"date_detail"`
+	SchemaID   uint16     `json:"schema_id,omitempty"`
+	SchemaName string     `json:"schema_name,omitempty"`
+	Tag        uint16     `json:"tag"`
+	Person     *PersonRef `json:"person,omitempty"`
+	Family     *FamilyRef `json:"family,omitempty"`
+}
+
 // PlaceRef is a lightweight place reference.
 type PlaceRef struct {
 	ID   uint32 `json:"id"`
@@ -92,12 +116,13 @@ type FamilyRef struct {
 
 // FamilyDetail is a full family record with resolved names.
 type FamilyDetail struct {
-	ID             uint32      `json:"id"`
-	Partner1       uint32      `json:"partner1,omitempty"`
-	Partner2       uint32      `json:"partner2,omitempty"`
-	Partner1Detail *PersonRef  `json:"partner1_detail,omitempty"`
-	Partner2Detail *PersonRef  `json:"partner2_detail,omitempty"`
-	ChildrenDetail []PersonRef `json:"children_detail,omitempty"`
+	ID             uint32           `json:"id"`
+	Partner1       uint32           `json:"partner1,omitempty"`
+	Partner2       uint32           `json:"partner2,omitempty"`
+	Partner1Detail *PersonRef       `json:"partner1_detail,omitempty"`
+	Partner2Detail *PersonRef       `json:"partner2_detail,omitempty"`
+	ChildrenDetail []PersonRef      `json:"children_detail,omitempty"`
+	Media          []model.MediaRef `json:"media,omitempty"`
 }
 
 // StatsResponse contains summary counts.
@@ -115,6 +140,8 @@ type StatsResponse struct {
 	Sources             int `json:"sources"`
 	Notes               int `json:"notes"`
 	Media               int `json:"media"`
+	Documents           int `json:"documents"`
+	Reports             int `json:"reports"`
 }
 
 // SummaryResponse provides per-person statistics.
@@ -143,7 +170,33 @@ func (s *Server) personRef(id uint32) PersonRef {
 	if !ok {
 		return PersonRef{ID: id, Name: "?"}
 	}
-	return PersonRef{ID: id, Name: index.FormatName(p), Sex: p.Sex.String()}
+	return s.personRefFor(p)
+}
+
+func (s *Server) personRefFor(p *model.Person) PersonRef {
+	idx := s.load().idx
+	return PersonRef{
+		ID:    p.ID,
+		Name:  index.FormatName(p),
+		Sex:   p.Sex.String(),
+		Birth: idx.BirthDate(p.ID).String(),
+		Death: idx.DeathDate(p.ID).String(),
+		Color: idx.Color(p.ID),
+	}
+}
+
+func (s *Server) familyRef(f *model.Family) FamilyRef {
+	ref := FamilyRef{
+		ID:            f.ID,
+		ChildrenCount: len(f.Children),
+	}
+	if f.Partner1 > 0 {
+		ref.Partner1Name = s.load().idx.PersonName(f.Partner1)
+	}
+	if f.Partner2 > 0 {
+		ref.Partner2Name = s.load().idx.PersonName(f.Partner2)
+	}
+	return ref
 }
 
 func (s *Server) personRefs(ids []uint32) []PersonRef {
@@ -180,12 +233,16 @@ func (s *Server) resolveEvents(events []model.PersonEvent) []ResolvedEvent {
 			SchemaID:        evt.SchemaID,
 			SchemaName:      s.load().idx.SchemaName(evt.SchemaID),
 			Tag:             evt.Tag,
-			Date:            evt.Date,
+			Date:            evt.Date.String(),
 			Text:            evt.Text,
 			SourceCitations: s.resolveSourceCitations(evt.SourceCitations),
 			IsNote:          evt.Tag < 0x03E8, // tags below 1000 are note references
 			IsFact:          evt.Tag >= 0x0BB8,
 		}
+		if !evt.Date.IsZero() {
+			d := evt.Date
+			re.DateDetail = &d
+		}
 		for _, placeRef := range evt.PlaceRefs {
 			pid := uint32(placeRef)
 			name := s.load().idx.PlaceName(placeRef)
@@ -241,6 +298,18 @@ func (s *Server) renderMarkupHTML(nodes []model.MarkupNode) string {
 	return b.String()
 }
 
+// media returns the media attached to a person or family; an ID of 0
+// matches any.
+func (s *Server) media(personID, familyID uint32) []model.MediaRef {
+	var out []model.MediaRef
+	for _, m := range s.load().ff.MediaRefs {
+		if (personID == 0 || m.PersonID == personID) && (familyID == 0 || m.FamilyID == familyID) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
 func parseUint32(s string) uint32 {
 	var v uint32
 	for _, c := range s {
@@ -291,16 +360,36 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 		Sources:              len(s.load().ff.Sources),
 		Notes:                len(s.load().ff.Notes),
 		Media:                len(s.load().ff.MediaRefs),
+		Documents:            len(s.load().ff.Documents),
+		Reports:              len(s.load().ff.Reports),
 	})
 }
 
 func (s *Server) handlePersons(w http.ResponseWriter, r *http.Request) {
 	surname := r.URL.Query().Get("surname")
 	query := r.URL.Query().Get("q")
+	color := r.URL.Query().Get("color")
 	page := parseIntQuery(r, "page", 1)
 	perPage := parseIntQuery(r, "per_page", 100)
+	bornFrom, err := parseDateQuery(r, "born_from")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	bornTo, err := parseDateQuery(r, "born_to")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	modifiedSince, err := model.ParseTime(r.URL.Query().Get("modified_since"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid modified_since: "+err.Error())
+		return
+	}
+	ranged := !bornFrom.IsZero() || !bornTo.IsZero()
+	idx := s.load().idx
 
-	var refs []PersonRef
+	var persons []*model.Person
 	for i := range s.load().ff.Persons {
 		p := &s.load().ff.Persons[i]
 		if surname != "" && !strings.EqualFold(p.Surname, surname) {
@@ -312,7 +401,40 @@ func (s *Server) handlePersons(w http.ResponseWriter, r *http.Request) {
 				continue
 			}
 		}
-		refs = append(refs, PersonRef{ID: p.ID, Name: index.FormatName(p), Sex: p.Sex.String()})
+		if ranged && !idx.BirthDate(p.ID).Within(bornFrom, bornTo) {
+			continue
+		}
+		if color != "" && !idx.HasColor(p.ID, color) {
+			continue
+		}
+		if !modifiedSince.IsZero() && p.Modified.Before(modifiedSince) {
+			continue
+		}
+		persons = append(persons, p)
+	}
+
+	switch sortBy := r.URL.Query().Get("sort"); sortBy {
+	case "", "id":
+	case "name":
+		sort.SliceStable(persons, func(i, j int) bool {
+			return index.FormatName(persons[i]) < index.FormatName(persons[j])
+		})
+	case "birth":
+		sort.SliceStable(persons, func(i, j int) bool {
+			return idx.BirthDate(persons[i].ID).Compare(idx.BirthDate(persons[j].ID)) < 0
+		})
+	case "death":
+		sort.SliceStable(persons, func(i, j int) bool {
+			return idx.DeathDate(persons[i].ID).Compare(idx.DeathDate(persons[j].ID)) < 0
+		})
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid sort %q", sortBy))
+		return
+	}
+
+	refs := make([]PersonRef, 0, len(persons))
+	for _, p := range persons {
+		refs = append(refs, s.personRefFor(p))
 	}
 
 	total := len(refs)
@@ -398,6 +520,8 @@ func (s *Server) handlePerson(w http.ResponseWriter, r *http.Request) {
 		Children:        s.personRefs(s.load().idx.ChildrenOf(p.ID)),
 		Parents:         s.personRefs(s.load().idx.Parents(p.ID)),
 		Siblings:        s.personRefs(s.load().idx.Siblings(p.ID)),
+		Media:           s.media(p.ID, 0),
+		Modified:        p.Modified,
 	}
 
 	writeJSON(w, http.StatusOK, detail)
@@ -487,7 +611,7 @@ func (s *Server) handlePersonTreetops(w http.ResponseWriter, r *http.Request) {
 	persons := s.load().idx.Treetops(id)
 	refs := make([]PersonRef, 0, len(persons))
 	for _, p := range persons {
-		refs = append(refs, PersonRef{ID: p.ID, Name: index.FormatName(p), Sex: p.Sex.String()})
+		refs = append(refs, s.personRefFor(p))
 	}
 	writeJSON(w, http.StatusOK, refs)
 }
@@ -534,18 +658,8 @@ func (s *Server) handleFamilies(w http.ResponseWriter, r *http.Request) {
 	perPage := parseIntQuery(r, "per_page", 100)
 
 	refs := make([]FamilyRef, 0, len(s.load().ff.Families))
-	for _, f := range s.load().ff.Families {
-		ref := FamilyRef{
-			ID:            f.ID,
-			ChildrenCount: len(f.Children),
-		}
-		if f.Partner1 > 0 {
-			ref.Partner1Name = s.load().idx.PersonName(f.Partner1)
-		}
-		if f.Partner2 > 0 {
-			ref.Partner2Name = s.load().idx.PersonName(f.Partner2)
-		}
-		refs = append(refs, ref)
+	for i := range s.load().ff.Families {
+		refs = append(refs, s.familyRef(&s.load().ff.Families[i]))
 	}
 
 	total := len(refs)
@@ -597,6 +711,7 @@ func (s *Server) buildFamilyDetail(f *model.Family) FamilyDetail {
 	for _, cid := range f.Children {
 		d.ChildrenDetail = append(d.ChildrenDetail, s.personRef(cid))
 	}
+	d.Media = s.media(0, f.ID)
 	return d
 }
 
@@ -719,7 +834,7 @@ func (s *Server) handleSourcePersons(w http.ResponseWriter, r *http.Request) {
 		}
 		if found && !seen[p.ID] {
 			seen[p.ID] = true
-			refs = append(refs, PersonRef{ID: p.ID, Name: index.FormatName(p), Sex: p.Sex.String()})
+			refs = append(refs, s.personRefFor(p))
 		}
 	}
 	writeJSON(w, http.StatusOK, refs)
@@ -755,6 +870,48 @@ func (s *Server) handleNote(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, n)
 }
 
+func (s *Server) handleMedia(w http.ResponseWriter, r *http.Request) {
+	personID := uint32(parseIntQuery(r, "person_id", 0))
+	familyID := uint32(parseIntQuery(r, "family_id", 0))
+	media := s.media(personID, familyID)
+	if media == nil {
+		media = []model.MediaRef{}
+	}
+	writeJSON(w, http.StatusOK, media)
+}
+
+func (s *Server) handleDocuments(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.load().ff.Documents)
+}
+
+func (s *Server) handleReports(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.load().ff.Reports)
+}
+
+func (s *Server) handleBookmarks(w http.ResponseWriter, r *http.Request) {
+	sets := make([]BookmarkSetRef, 0, len(s.load().ff.Bookmarks))
+	for _, set := range s.load().ff.Bookmarks {
+		sets = append(sets, BookmarkSetRef{Label: set.Label, Persons: s.personRefs(set.PersonIDs)})
+	}
+	writeJSON(w, http.StatusOK, sets)
+}
+
+func (s *Server) handleNoteboard(w http.ResponseWriter, r *http.Request) {
+	boards := s.load().ff.Noteboards
+	if boards == nil {
+		boards = []model.Noteboard{}
+	}
+	writeJSON(w, http.StatusOK, boards)
+}
+
+func (s *Server) handleConflicts(w http.ResponseWriter, r *http.Request) {
+	conflicts := s.load().idx.Conflicts
+	if conflicts == nil {
+		conflicts = []index.Conflict{}
+	}
+	writeJSON(w, http.StatusOK, conflicts)
+}
+
 func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 	if query == "" {
@@ -768,7 +925,41 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	})
 	refs := make([]PersonRef, 0, len(persons))
 	for _, p := range persons {
-		refs = append(refs, PersonRef{ID: p.ID, Name: index.FormatName(p), Sex: p.Sex.String()})
+		refs = append(refs, s.personRefFor(p))
 	}
 	writeJSON(w, http.StatusOK, refs)
 }
+
+func (s *Server) handleTimeline(w http.ResponseWriter, r *http.Request) {
+	from, err := parseDateQuery(r, "from")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	to, err := parseDateQuery(r, "to")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	idx := s.load().idx
+	events := idx.EventsBetween(from, to)
+	entries := make([]TimelineEntry, 0, len(events))
+	for _, e := range events {
+		entry := TimelineEntry{
+			Date:       e.Date.String(),
+			DateDetail: e.Date,
+			SchemaID:   e.SchemaID,
+			SchemaName: idx.SchemaName(e.SchemaID),
+			Tag:        e.Tag,
+		}
+		if e.PersonID > 0 {
+			ref := s.personRef(e.PersonID)
+			entry.Person = &ref
+		} else if f, ok := idx.Families[e.FamilyID]; ok {
+			ref := s.familyRef(f)
+			entry.Family = &ref
+		}
+		entries = append(entries, entry)
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
@@ -5,7 +5,9 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/kedoco/reunion-explore/index"
 	"github.com/kedoco/reunion-explore/model"
 )
 
@@ -32,15 +34,22 @@ func buildOpenAPISpec() map[string]any {
 	schemaFromType(reflect.TypeOf(NoteDisplay{}), schemas)
 	schemaFromType(reflect.TypeOf(TreeEntryRef{}), schemas)
 	schemaFromType(reflect.TypeOf(PlaceRef{}), schemas)
+	schemaFromType(reflect.TypeOf(TimelineEntry{}), schemas)
 	schemaFromType(reflect.TypeOf(FamilyRef{}), schemas)
 	schemaFromType(reflect.TypeOf(FamilyDetail{}), schemas)
 	schemaFromType(reflect.TypeOf(StatsResponse{}), schemas)
 	schemaFromType(reflect.TypeOf(SummaryResponse{}), schemas)
 	schemaFromType(reflect.TypeOf(PaginatedResponse{}), schemas)
+	schemaFromType(reflect.TypeOf(BookmarkSetRef{}), schemas)
 	schemaFromType(reflect.TypeOf(model.Place{}), schemas)
 	schemaFromType(reflect.TypeOf(model.EventDefinition{}), schemas)
 	schemaFromType(reflect.TypeOf(model.Source{}), schemas)
 	schemaFromType(reflect.TypeOf(model.Note{}), schemas)
+	schemaFromType(reflect.TypeOf(model.MediaRef{}), schemas)
+	schemaFromType(reflect.TypeOf(model.Document{}), schemas)
+	schemaFromType(reflect.TypeOf(model.ReportDefinition{}), schemas)
+	schemaFromType(reflect.TypeOf(model.Noteboard{}), schemas)
+	schemaFromType(reflect.TypeOf(index.Conflict{}), schemas)
 
 	return map[string]any{
 		"openapi": "3.1.0",
@@ -62,6 +71,11 @@ func buildPaths() map[string]any {
 		"/api/persons": pathItemWithParams("get", "List persons", "PaginatedResponse",
 			queryParam("surname", "string", "Filter by surname"),
 			queryParam("q", "string", "Search query"),
+			queryParam("sort", "string", "Sort order: id, name, birth or death"),
+			queryParam("born_from", "string", "Only persons born on or after this date (e.g. 1900, May 1917, 1917-05-29)"),
+			queryParam("born_to", "string", "Only persons born on or before this date"),
+			queryParam("color", "string", "Only persons with this color tag (number, #rrggbb color or label)"),
+			queryParam("modified_since", "string", "Only persons modified at or after this time (e.g. 2024-02-20, 2024-02-20T19:29:17Z)"),
 			queryParam("page", "integer", "Page number"),
 			queryParam("per_page", "integer", "Items per page"),
 		),
@@ -89,9 +103,22 @@ func buildPaths() map[string]any {
 			queryParam("person_id", "integer", "Filter by person ID"),
 		),
 		"/api/notes/{id}": pathItemWithID("get", "Get note", "Note"),
+		"/api/media": pathItemWithParams("get", "List media", "array:MediaRef",
+			queryParam("person_id", "integer", "Filter by person ID"),
+			queryParam("family_id", "integer", "Filter by family ID"),
+		),
+		"/api/documents":   pathItem("get", "List document records", "array:Document"),
+		"/api/reports":     pathItem("get", "List report definitions", "array:ReportDefinition"),
+		"/api/bookmarks":   pathItem("get", "List bookmark sets", "array:BookmarkSetRef"),
+		"/api/noteboard":   pathItem("get", "List noteboards", "array:Noteboard"),
+		"/api/conflicts":   pathItem("get", "List records changed concurrently by sync members (experimental: the .changes layout is inferred)", "array:Conflict"),
 		"/api/search": pathItemWithParams("get", "Search persons", "array:PersonRef",
 			queryParam("q", "string", "Search query"),
 		),
+		"/api/timeline": pathItemWithParams("get", "List dated events chronologically", "array:TimelineEntry",
+			queryParam("from", "string", "Only events on or after this date"),
+			queryParam("to", "string", "Only events on or before this date"),
+		),
 	}
 }
 
@@ -245,6 +272,9 @@ func goTypeToJSONSchema(t reflect.Type, schemas map[string]any) map[string]any {
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
+	if t == reflect.TypeFor[time.Time]() {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
 
 	switch t.Kind() {
 	case reflect.String:
@@ -290,7 +320,7 @@ func parseJSONTag(tag string) (string, jsonTagOpts) {
 	name := parts[0]
 	opts := jsonTagOpts{}
 	for _, p := range parts[1:] {
-		if p == "omitempty" {
+		if p == "omitempty" || p == "omitzero" {
 			opts.omitempty = true
 		}
 	}
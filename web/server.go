@@ -134,7 +134,14 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("GET /api/sources/{id}/persons", s.handleSourcePersons)
 	mux.HandleFunc("GET /api/notes", s.handleNotes)
 	mux.HandleFunc("GET /api/notes/{id}", s.handleNote)
+	mux.HandleFunc("GET /api/media", s.handleMedia)
+	mux.HandleFunc("GET /api/documents", s.handleDocuments)
+	mux.HandleFunc("GET /api/reports", s.handleReports)
+	mux.HandleFunc("GET /api/bookmarks", s.handleBookmarks)
+	mux.HandleFunc("GET /api/noteboard", s.handleNoteboard)
+	mux.HandleFunc("GET /api/conflicts", s.handleConflicts)
 	mux.HandleFunc("GET /api/search", s.handleSearch)
+	mux.HandleFunc("GET /api/timeline", s.handleTimeline)
 	mux.HandleFunc("GET /api/openapi.json", s.handleOpenAPI)
 
 	// Static files with SPA fallback
@@ -206,6 +213,16 @@ func parseID(r *http.Request) (uint32, error) {
 	return uint32(n), nil
 }
 
+// parseDateQuery parses a date query parameter with model.ParseDate; a
+// missing parameter yields the zero Date.
+func parseDateQuery(r *http.Request, name string) (model.Date, error) {
+	d, err := model.ParseDate(r.URL.Query().Get(name))
+	if err != nil {
+		return model.Date{}, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return d, nil
+}
+
 func parseIntQuery(r *http.Request, name string, defaultVal int) int {
 	s := r.URL.Query().Get(name)
 	if s == "" {
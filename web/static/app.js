@@ -7,6 +7,7 @@ function app() {
     stats: null,
     searchQuery: '',
     searchResults: [],
+    bookmarks: [],
 
     // Persons
     personsList: [],
@@ -14,6 +15,8 @@ function app() {
     personsPerPage: 100,
     personsTotal: 0,
     surnameFilter: '',
+    colorFilter: '',
+    personsSort: 'id',
     personDetail: null,
     activePanel: null, // 'ancestors', 'descendants', 'treetops', 'summary'
     ancestorsList: [],
@@ -46,6 +49,7 @@ function app() {
     async init() {
       // Load stats on init
       this.stats = await this.api('/api/stats');
+      this.bookmarks = await this.api('/api/bookmarks') || [];
       // Handle hash routing
       window.addEventListener('hashchange', () => this.handleHash());
       this.handleHash();
@@ -141,6 +145,8 @@ function app() {
       this.loading = true;
       let url = `/api/persons?page=${this.personsPage}&per_page=${this.personsPerPage}`;
       if (this.surnameFilter) url += `&surname=${encodeURIComponent(this.surnameFilter)}`;
+      if (this.colorFilter) url += `&color=${encodeURIComponent(this.colorFilter)}`;
+      if (this.personsSort !== 'id') url += `&sort=${this.personsSort}`;
       const data = await this.api(url);
       if (data) {
         this.personsList = data.items || [];